@@ -233,10 +233,10 @@ func IntegrationExample() {
 	// Database integration
 	fmt.Println("3. Database Integration:")
 	fmt.Println(`
-   // Store hashed passwords using SM3
-   func hashPassword(password string) string {
-       hash := sm3.Sum([]byte(password))
-       return hex.EncodeToString(hash[:])
+   // Store salted, stretched password hashes using PBKDF2-HMAC-SM3
+   func hashPassword(password string, salt []byte) string {
+       key := sm3.PBKDF2([]byte(password), salt, 100000, 32)
+       return hex.EncodeToString(salt) + "$" + hex.EncodeToString(key)
    }`)
 }
 
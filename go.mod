@@ -8,11 +8,11 @@ require (
 	github.com/golang/protobuf v1.5.4
 	golang.org/x/crypto v0.25.0
 	golang.org/x/net v0.27.0
+	golang.org/x/sys v0.22.0
 	google.golang.org/grpc v1.65.0
 )
 
 require (
-	golang.org/x/sys v0.22.0 // indirect
 	golang.org/x/text v0.16.0 // indirect
 	google.golang.org/genproto/googleapis/rpc v0.0.0-20240528184218-531527333157 // indirect
 	google.golang.org/protobuf v1.34.1 // indirect
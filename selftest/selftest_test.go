@@ -0,0 +1,30 @@
+package selftest
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSelfTest(t *testing.T) {
+	if err := SelfTest(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSelfTestReportsVector(t *testing.T) {
+	saved := sm3Vectors[1].digest
+	defer func() { sm3Vectors[1].digest = saved }()
+	sm3Vectors[1].digest = strings.Repeat("00", 32)
+	err := SelfTest()
+	if err == nil || !strings.Contains(err.Error(), "SM3 GB/T 32905 A.2") {
+		t.Errorf("corrupted SM3 vector: got %v", err)
+	}
+
+	sm3Vectors[1].digest = saved
+	savedS := sm2Vectors[0].s
+	defer func() { sm2Vectors[0].s = savedS }()
+	sm2Vectors[0].s = strings.Repeat("11", 32)
+	if err := SelfTest(); err == nil || !strings.Contains(err.Error(), "SM2 GB/T 32918.5 signature: signature mismatch") {
+		t.Errorf("corrupted SM2 vector: got %v", err)
+	}
+}
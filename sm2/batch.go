@@ -0,0 +1,251 @@
+package sm2
+
+import (
+	"crypto/rand"
+	"errors"
+	"io"
+	"math/big"
+	"math/bits"
+
+	"golang.org/x/crypto/cryptobyte"
+	cbasn1 "golang.org/x/crypto/cryptobyte/asn1"
+)
+
+// batchGroupSize is the number of signatures BatchVerifyFast combines into
+// one equation. Only the x coordinate of each R is known, so checking a
+// group means trying all 2^batchGroupSize signs of the recovered points.
+const batchGroupSize = 6
+
+// batchRandomizerSize is the length in bytes of the random scalars the
+// equations of a group are multiplied by.
+const batchRandomizerSize = 16
+
+// batchEntry is a signature prepared for batch verification. (rx, ry) is
+// a point R with x(R) = r - e mod n, of unknown sign.
+type batchEntry struct {
+	index  int
+	s, t   *big.Int
+	rx, ry sm2P256FieldElement
+}
+
+// BatchVerifyFast reports whether every signature is valid for its message
+// under pub with the default user ID, like BatchVerifyAll, and returns the
+// index of the first invalid signature (-1 if all verify or on error).
+//
+// Each signature satisfies R = s*G + t*P with t = r + s. The points R of
+// up to batchGroupSize signatures are recovered from r and the equations
+// are combined with random 128-bit weights a_i into
+//
+//	sum(a_i*R_i) = (sum(a_i*s_i))*G + (sum(a_i*t_i))*P
+//
+// which needs a single full scalar multiplication by P per group. An SM2
+// signature does not fix the sign of R, so all 2^batchGroupSize sign
+// combinations are tried; an invalid group passes with probability below
+// 2^-120. When a group fails, its signatures are verified one by one to
+// find the invalid one, so a batch with invalid signatures costs more than
+// BatchVerifyAll.
+//
+// The short multiplications a_i*R_i bound the speedup: valid batches
+// verify about twice as fast as with BatchVerifyAll, not at the cost of
+// one verification. All signatures must be by pub on the SM2 curve; for other
+// curves BatchVerifyFast is BatchVerifyAll.
+func BatchVerifyFast(pub *PublicKey, messages, signatures [][]byte) (bool, int, error) {
+	if len(messages) != len(signatures) {
+		return false, -1, errors.New("messages and signatures count mismatch")
+	}
+	if _, ok := pub.Curve.(sm2P256Curve); !ok || !isValidPoint(pub.X, pub.Y) {
+		return BatchVerifyAll(pub, messages, signatures)
+	}
+	za, err := ZA(pub, default_uid)
+	if err != nil {
+		return false, -1, err
+	}
+
+	group := make([]batchEntry, 0, batchGroupSize)
+	// flush checks the pending group and returns the index of its first
+	// invalid signature, or -1.
+	flush := func() int {
+		defer func() { group = group[:0] }()
+		if len(group) == 0 || verifyBatchGroup(pub, group) {
+			return -1
+		}
+		for _, b := range group {
+			if !pub.Verify(messages[b.index], signatures[b.index]) {
+				return b.index
+			}
+		}
+		return -1
+	}
+	for i := range messages {
+		b, ok := prepareBatchEntry(za, messages[i], signatures[i])
+		if !ok {
+			// Malformed, invalid, or with an ambiguous x(R): verify it on
+			// its own, after the signatures before it.
+			if j := flush(); j >= 0 {
+				return false, j, nil
+			}
+			if !pub.Verify(messages[i], signatures[i]) {
+				return false, i, nil
+			}
+			continue
+		}
+		b.index = i
+		group = append(group, b)
+		if len(group) == batchGroupSize {
+			if j := flush(); j >= 0 {
+				return false, j, nil
+			}
+		}
+	}
+	if j := flush(); j >= 0 {
+		return false, j, nil
+	}
+	return true, -1, nil
+}
+
+// prepareBatchEntry parses sig and recovers a point R from r. It fails for
+// signatures that cannot be batched; those may still be valid.
+func prepareBatchEntry(za, msg, sig []byte) (batchEntry, bool) {
+	var (
+		b     batchEntry
+		r, s  = new(big.Int), new(big.Int)
+		inner cryptobyte.String
+	)
+	input := cryptobyte.String(sig)
+	if !input.ReadASN1(&inner, cbasn1.SEQUENCE) ||
+		!input.Empty() ||
+		!inner.ReadASN1Integer(r) ||
+		!inner.ReadASN1Integer(s) ||
+		!inner.Empty() {
+		return b, false
+	}
+	P256Sm2()
+	N, P := sm2P256.N, sm2P256.P
+	if r.Sign() <= 0 || s.Sign() <= 0 || r.Cmp(N) >= 0 || s.Cmp(N) >= 0 {
+		return b, false
+	}
+	t := new(big.Int).Add(r, s)
+	if t.Cmp(N) >= 0 {
+		t.Sub(t, N)
+	}
+	if t.Sign() == 0 {
+		return b, false
+	}
+	e, err := msgHash(za, msg)
+	if err != nil {
+		return b, false
+	}
+
+	// x(R) is r - e mod n, or that plus n when it is still below p.
+	x := e.Sub(r, e)
+	x.Mod(x, N)
+	if new(big.Int).Add(x, N).Cmp(P) < 0 {
+		return b, false
+	}
+	var xx, rhs, ax sm2P256FieldElement
+	sm2P256FromBig(&xx, x)
+	sm2P256Square(&rhs, &xx)
+	sm2P256Mul(&rhs, &rhs, &xx)
+	sm2P256Mul(&ax, &sm2P256.a, &xx)
+	sm2P256Add(&rhs, &rhs, &ax)
+	sm2P256Add(&rhs, &rhs, &sm2P256.b)
+	y := new(big.Int).ModSqrt(sm2P256ToBig(&rhs), P)
+	if y == nil {
+		return b, false
+	}
+	b.s, b.t = s, t
+	b.rx = xx
+	sm2P256FromBig(&b.ry, y)
+	return b, true
+}
+
+// verifyBatchGroup checks the combined equation of group, trying every
+// sign of the recovered points. A false result means the group contains an
+// invalid signature or, with negligible probability, that an intermediate
+// sum hit a special case of the addition formulas.
+func verifyBatchGroup(pub *PublicKey, group []batchEntry) bool {
+	var weights [batchGroupSize * batchRandomizerSize]byte
+	if _, err := io.ReadFull(rand.Reader, weights[:]); err != nil {
+		return false
+	}
+	N := sm2P256.N
+
+	// c accumulates sum(a_i*R_i) with every sign positive; pos[i] and
+	// neg[i] are +-2*a_i*R_i, the steps that flip the sign of R_i.
+	var cx, cy, cz sm2P256FieldElement
+	var pos, neg [batchGroupSize][3]sm2P256FieldElement
+	S, T, tmp := new(big.Int), new(big.Int), new(big.Int)
+	for i := range group {
+		b := &group[i]
+		a := new(big.Int).SetBytes(weights[i*batchRandomizerSize : (i+1)*batchRandomizerSize])
+		if i == 0 || a.Sign() == 0 {
+			// Fixing one weight to 1 does not weaken the check.
+			a.SetInt64(1)
+		}
+		S.Add(S, tmp.Mul(a, b.s))
+		T.Add(T, tmp.Mul(a, b.t))
+
+		var px, py, pz sm2P256FieldElement
+		if i == 0 {
+			px, py, pz = b.rx, b.ry, sm2P256Factor[1]
+			cx, cy, cz = px, py, pz
+		} else {
+			sm2P256ScalarMult(&px, &py, &pz, &b.rx, &b.ry, WNafReversed(sm2GenrateWNaf(a.Bytes())))
+			sm2P256PointAdd(&cx, &cy, &cz, &px, &py, &pz, &cx, &cy, &cz)
+		}
+		p := &pos[i]
+		sm2P256PointDouble(&p[0], &p[1], &p[2], &px, &py, &pz)
+		neg[i] = *p
+		sm2P256Negate(&neg[i][1])
+	}
+	S.Mod(S, N)
+	T.Mod(T, N)
+
+	var qx, qy, qz, tx, ty, tz, px, py sm2P256FieldElement
+	var scalar [32]byte
+	sm2P256GetScalar(&scalar, S.Bytes())
+	sm2P256ScalarBaseMult(&qx, &qy, &qz, &scalar)
+	sm2P256FromBig(&px, pub.X)
+	sm2P256FromBig(&py, pub.Y)
+	sm2P256ScalarMult(&tx, &ty, &tz, &px, &py, WNafReversed(sm2GenrateWNaf(T.Bytes())))
+	sm2P256PointAdd(&qx, &qy, &qz, &tx, &ty, &tz, &qx, &qy, &qz)
+	if sm2P256IsZero(&qz) {
+		return false
+	}
+
+	// Walk all sign combinations in Gray code order, one addition each.
+	var negated [batchGroupSize]bool
+	for k := 1; ; k++ {
+		if !sm2P256IsZero(&cz) && jacobianEqual(&cx, &cy, &cz, &qx, &qy, &qz) {
+			return true
+		}
+		if k == 1<<len(group) {
+			return false
+		}
+		i := bits.TrailingZeros(uint(k))
+		step := &neg[i]
+		if negated[i] {
+			step = &pos[i]
+		}
+		negated[i] = !negated[i]
+		sm2P256PointAdd(&cx, &cy, &cz, &step[0], &step[1], &step[2], &cx, &cy, &cz)
+	}
+}
+
+// jacobianEqual reports whether two Jacobian points with non-zero z are
+// the same affine point.
+func jacobianEqual(x1, y1, z1, x2, y2, z2 *sm2P256FieldElement) bool {
+	var z12, z22, u1, u2, s1, s2 sm2P256FieldElement
+	sm2P256Square(&z12, z1)
+	sm2P256Square(&z22, z2)
+	sm2P256Mul(&u1, x1, &z22)
+	sm2P256Mul(&u2, x2, &z12)
+	if !sm2P256Equal(&u1, &u2) {
+		return false
+	}
+	sm2P256Mul(&s1, y1, &z22)
+	sm2P256Mul(&s1, &s1, z2)
+	sm2P256Mul(&s2, y2, &z12)
+	sm2P256Mul(&s2, &s2, z1)
+	return sm2P256Equal(&s1, &s2)
+}
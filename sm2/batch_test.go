@@ -0,0 +1,108 @@
+package sm2
+
+import (
+	"crypto/rand"
+	"fmt"
+	"math/big"
+	"testing"
+)
+
+func TestBatchVerifyFast(t *testing.T) {
+	priv, err := GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	const n = 2*batchGroupSize + 3
+	messages := make([][]byte, n)
+	for i := range messages {
+		messages[i] = []byte(fmt.Sprintf("message %d", i))
+	}
+	signatures, err := BatchSign(priv, messages)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pub := &priv.PublicKey
+	for _, count := range []int{0, 1, batchGroupSize, n} {
+		ok, idx, err := BatchVerifyFast(pub, messages[:count], signatures[:count])
+		if err != nil || !ok || idx != -1 {
+			t.Errorf("%d valid signatures: got %v, %d, %v", count, ok, idx, err)
+		}
+	}
+
+	// Swapping two signatures makes both invalid; the first one is reported.
+	for _, pair := range [][2]int{{0, 1}, {3, batchGroupSize + 2}, {n - 2, n - 1}} {
+		sigs := append([][]byte(nil), signatures...)
+		sigs[pair[0]], sigs[pair[1]] = sigs[pair[1]], sigs[pair[0]]
+		ok, idx, err := BatchVerifyFast(pub, messages, sigs)
+		if err != nil || ok || idx != pair[0] {
+			t.Errorf("swapped %v: got %v, %d, %v", pair, ok, idx, err)
+		}
+	}
+
+	// A single corrupted s anywhere in the batch is found.
+	for i := 0; i < n; i++ {
+		r, s, err := SignDataToSignDigit(signatures[i])
+		if err != nil {
+			t.Fatal(err)
+		}
+		sigs := append([][]byte(nil), signatures...)
+		if sigs[i], err = SignDigitToSignData(r, s.Add(s, big.NewInt(1))); err != nil {
+			t.Fatal(err)
+		}
+		if ok, idx, _ := BatchVerifyFast(pub, messages, sigs); ok || idx != i {
+			t.Errorf("corrupted signature %d: got %v, %d", i, ok, idx)
+		}
+	}
+
+	// A signature that cannot be batched is checked on its own, in order.
+	sigs := append([][]byte(nil), signatures...)
+	sigs[batchGroupSize+1] = []byte("not a signature")
+	if ok, idx, _ := BatchVerifyFast(pub, messages, sigs); ok || idx != batchGroupSize+1 {
+		t.Errorf("malformed signature: got %v, %d", ok, idx)
+	}
+	sigs[2] = signatures[3]
+	if ok, idx, _ := BatchVerifyFast(pub, messages, sigs); ok || idx != 2 {
+		t.Errorf("invalid signature before a malformed one: got %v, %d", ok, idx)
+	}
+
+	other, err := GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok, idx, _ := BatchVerifyFast(&other.PublicKey, messages, signatures); ok || idx != 0 {
+		t.Errorf("wrong key: got %v, %d", ok, idx)
+	}
+	if _, _, err := BatchVerifyFast(pub, messages, signatures[:2]); err == nil {
+		t.Error("count mismatch accepted")
+	}
+}
+
+func BenchmarkBatchVerify(b *testing.B) {
+	priv, err := GenerateKey(rand.Reader)
+	if err != nil {
+		b.Fatal(err)
+	}
+	messages := make([][]byte, 60)
+	for i := range messages {
+		messages[i] = []byte(fmt.Sprintf("message %d", i))
+	}
+	signatures, err := BatchSign(priv, messages)
+	if err != nil {
+		b.Fatal(err)
+	}
+	for _, bc := range []struct {
+		name   string
+		verify func(*PublicKey, [][]byte, [][]byte) (bool, int, error)
+	}{
+		{"All", BatchVerifyAll},
+		{"Fast", BatchVerifyFast},
+	} {
+		b.Run(bc.name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if ok, _, _ := bc.verify(&priv.PublicKey, messages, signatures); !ok {
+					b.Fatal("batch does not verify")
+				}
+			}
+		})
+	}
+}
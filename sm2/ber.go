@@ -0,0 +1,152 @@
+package sm2
+
+import (
+	"errors"
+	"math/big"
+)
+
+// maxBERDepth bounds the nesting accepted by berToDER. SM2 signatures and
+// ciphertexts only need two levels.
+const maxBERDepth = 8
+
+var errBERTruncated = errors.New("SM2: truncated BER element")
+
+// berToDER re-encodes a single BER element as DER. It handles indefinite
+// and non-minimal lengths and flattens constructed OCTET STRINGs, which
+// covers the BER variants seen in SM2 signatures and ciphertexts.
+func berToDER(ber []byte) ([]byte, error) {
+	der, rest, err := convertBER(ber, 0)
+	if err != nil {
+		return nil, err
+	}
+	if len(rest) != 0 {
+		return nil, errors.New("SM2: trailing data after BER element")
+	}
+	return der, nil
+}
+
+func convertBER(b []byte, depth int) (der, rest []byte, err error) {
+	if depth > maxBERDepth {
+		return nil, nil, errors.New("SM2: BER nesting too deep")
+	}
+	if len(b) < 2 {
+		return nil, nil, errBERTruncated
+	}
+	tag := b[0]
+	if tag&0x1f == 0x1f {
+		return nil, nil, errors.New("SM2: BER high tag numbers are not supported")
+	}
+	constructed := tag&0x20 != 0
+	l := int(b[1])
+	b = b[2:]
+
+	var content []byte
+	indefinite := false
+	switch {
+	case l < 0x80:
+		if l > len(b) {
+			return nil, nil, errBERTruncated
+		}
+		content, b = b[:l], b[l:]
+	case l == 0x80:
+		if !constructed {
+			return nil, nil, errors.New("SM2: BER indefinite length on a primitive element")
+		}
+		indefinite = true
+	default:
+		n := l & 0x7f
+		if n > 4 || n > len(b) {
+			return nil, nil, errors.New("SM2: BER length too long")
+		}
+		length := 0
+		for _, c := range b[:n] {
+			length = length<<8 | int(c)
+		}
+		b = b[n:]
+		if length < 0 || length > len(b) {
+			return nil, nil, errBERTruncated
+		}
+		content, b = b[:length], b[length:]
+	}
+	if !constructed {
+		return appendDERElement(nil, tag, content), b, nil
+	}
+
+	var children [][]byte
+	for {
+		var child []byte
+		if indefinite {
+			if len(b) < 2 {
+				return nil, nil, errBERTruncated
+			}
+			if b[0] == 0 && b[1] == 0 {
+				b = b[2:]
+				break
+			}
+			if child, b, err = convertBER(b, depth+1); err != nil {
+				return nil, nil, err
+			}
+		} else {
+			if len(content) == 0 {
+				break
+			}
+			if child, content, err = convertBER(content, depth+1); err != nil {
+				return nil, nil, err
+			}
+		}
+		children = append(children, child)
+	}
+
+	var inner []byte
+	if tag == 0x24 {
+		// A constructed OCTET STRING is the concatenation of its segments,
+		// which DER encodes as a single primitive OCTET STRING.
+		for _, child := range children {
+			if child[0] != 0x04 {
+				return nil, nil, errors.New("SM2: BER OCTET STRING segment is not an OCTET STRING")
+			}
+			inner = append(inner, derContent(child)...)
+		}
+		return appendDERElement(nil, 0x04, inner), b, nil
+	}
+	for _, child := range children {
+		inner = append(inner, child...)
+	}
+	return appendDERElement(nil, tag, inner), b, nil
+}
+
+// appendDERElement appends tag, the minimal DER length and content to dst.
+func appendDERElement(dst []byte, tag byte, content []byte) []byte {
+	dst = append(dst, tag)
+	if n := len(content); n < 0x80 {
+		dst = append(dst, byte(n))
+	} else {
+		var l []byte
+		for ; n > 0; n >>= 8 {
+			l = append([]byte{byte(n)}, l...)
+		}
+		dst = append(dst, 0x80|byte(len(l)))
+		dst = append(dst, l...)
+	}
+	return append(dst, content...)
+}
+
+// derContent returns the content octets of an element produced by
+// appendDERElement.
+func derContent(der []byte) []byte {
+	if der[1] < 0x80 {
+		return der[2:]
+	}
+	return der[2+int(der[1]&0x7f):]
+}
+
+// ParseSignatureLenient parses an SM2 signature that may use BER, such as
+// indefinite or non-minimal lengths, and returns R and S. Re-encoding them
+// with SignDigitToSignData always yields strict DER.
+func ParseSignatureLenient(sig []byte) (r, s *big.Int, err error) {
+	der, err := berToDER(sig)
+	if err != nil {
+		return nil, nil, err
+	}
+	return SignDataToSignDigit(der)
+}
@@ -0,0 +1,100 @@
+package sm2
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/asn1"
+	"encoding/hex"
+	"testing"
+)
+
+func TestParseSignatureLenient(t *testing.T) {
+	der := fromHex(t, "3006020101020102")
+	for _, ber := range []string{
+		"3006020101020102",            // already DER
+		"3080020101020102" + "0000",   // indefinite length
+		"308106020101020102",          // non-minimal sequence length
+		"30090281010102820001" + "02", // non-minimal integer lengths
+	} {
+		r, s, err := ParseSignatureLenient(fromHex(t, ber))
+		if err != nil {
+			t.Errorf("%s: %v", ber, err)
+			continue
+		}
+		if r.Int64() != 1 || s.Int64() != 2 {
+			t.Errorf("%s: got r=%v s=%v", ber, r, s)
+		}
+		got, _ := SignDigitToSignData(r, s)
+		if !bytes.Equal(got, der) {
+			t.Errorf("%s: re-encoded to %x", ber, got)
+		}
+	}
+
+	priv, err := GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg := []byte("ber interop")
+	sig, err := priv.Sign(rand.Reader, msg, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Rewrite the real signature with an indefinite outer length.
+	ber := append(append([]byte{0x30, 0x80}, derContent(sig)...), 0, 0)
+	r, s, err := ParseSignatureLenient(ber)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, _ := SignDigitToSignData(r, s)
+	if !bytes.Equal(got, sig) || !priv.PublicKey.Verify(msg, got) {
+		t.Error("BER signature did not re-encode to the original DER")
+	}
+
+	for _, bad := range []string{"", "3080020101", "30820007020101020102", "0480010100"} {
+		if _, _, err := ParseSignatureLenient(fromHex(t, bad)); err == nil {
+			t.Errorf("%q accepted", bad)
+		}
+	}
+}
+
+func TestNormalizeCiphertextBER(t *testing.T) {
+	priv, err := GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ct, err := EncryptAsn1(&priv.PublicKey, []byte("ber ciphertext"), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var c sm2Cipher
+	if _, err := asn1.Unmarshal(ct, &c); err != nil {
+		t.Fatal(err)
+	}
+	x, _ := asn1.Marshal(c.XCoordinate)
+	y, _ := asn1.Marshal(c.YCoordinate)
+	text, _ := asn1.Marshal(c.CipherText)
+	// Indefinite length SEQUENCE with HASH split into two segments.
+	hash := append([]byte{0x24, 0x80}, appendDERElement(nil, 0x04, c.HASH[:10])...)
+	hash = append(appendDERElement(hash, 0x04, c.HASH[10:]), 0, 0)
+	ber := []byte{0x30, 0x80}
+	for _, f := range [][]byte{x, y, hash, text} {
+		ber = append(ber, f...)
+	}
+	ber = append(ber, 0, 0)
+
+	got, err := NormalizeCiphertext(ber)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, ct) {
+		t.Error("BER ciphertext did not normalize to the original DER")
+	}
+}
+
+func fromHex(t *testing.T, s string) []byte {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
@@ -0,0 +1,97 @@
+package sm2
+
+import (
+	"io"
+	"math/big"
+)
+
+// SignBlinded signs data with the default user ID like SignData, but blinds
+// every operation that involves a secret with fresh randomness from random,
+// or from rand.Reader if random is nil:
+//
+//   - the ephemeral point k*G is computed as k1*G + k2*G for a random split
+//     k = k1 + k2 mod n, so the comb multiplication never sees k itself;
+//   - the inverse of 1 + d is computed as b * ((1 + d) * b)^-1 for a random
+//     b, so the modular inversion itself runs on a freshly randomized value;
+//   - the private key enters r * d as r * d1 + r * d2 for a random split
+//     d = d1 + d2 mod n.
+//
+// This addresses differential power and timing analysis by an attacker who
+// can request many signatures with the same private key and observe the
+// signer: the intermediate values that depend on d differ on every call,
+// so traces cannot be averaged to recover it. It does not protect against
+// an attacker who learns a single ephemeral key, fault attacks, or a weak
+// random source. The blinded values are still computed with math/big,
+// which is not constant time, so blinding reduces what a trace reveals
+// about d but does not hide the timing of each operation. The signature is
+// an ordinary SM2 signature and verifies with Verify or VerifySignature.
+// SignBlinded returns ErrPrivateKeyOutOfRange if D is not in [1, n-2].
+func SignBlinded(priv *PrivateKey, data []byte, random io.Reader) ([]byte, error) {
+	digest, err := priv.PublicKey.Sm3Digest(data, nil)
+	if err != nil {
+		return nil, err
+	}
+	e := new(big.Int).SetBytes(digest)
+	c := priv.Curve
+	N := c.Params().N
+	if N.Sign() == 0 {
+		return nil, errZeroParam
+	}
+	if priv.D == nil || priv.D.Sign() <= 0 || priv.D.Cmp(new(big.Int).Sub(N, one)) >= 0 {
+		return nil, ErrPrivateKeyOutOfRange
+	}
+	// randFieldElement returns values in [1, n-1], so every blinding
+	// factor below is invertible.
+	draw := func() (*big.Int, error) { return randFieldElement(c, random) }
+	for {
+		k, err := draw()
+		if err != nil {
+			return nil, err
+		}
+		k1, err := draw()
+		if err != nil {
+			return nil, err
+		}
+		k2 := new(big.Int).Sub(k, k1)
+		k2.Mod(k2, N)
+		if k2.Sign() == 0 {
+			continue
+		}
+		x1, y1 := c.ScalarBaseMult(k1.Bytes())
+		x2, y2 := c.ScalarBaseMult(k2.Bytes())
+		x, _ := c.Add(x1, y1, x2, y2)
+
+		r := new(big.Int).Add(x, e)
+		r.Mod(r, N)
+		if r.Sign() == 0 || new(big.Int).Add(r, k).Cmp(N) == 0 {
+			continue
+		}
+
+		b, err := draw()
+		if err != nil {
+			return nil, err
+		}
+		inv := new(big.Int).Add(priv.D, one)
+		inv.Mul(inv, b)
+		inv.Mod(inv, N)
+		if inv.ModInverse(inv, N) == nil {
+			return nil, ErrPrivateKeyOutOfRange
+		}
+		inv.Mul(inv, b)
+
+		d1, err := draw()
+		if err != nil {
+			return nil, err
+		}
+		d2 := new(big.Int).Sub(priv.D, d1)
+		d2.Mod(d2, N)
+		s := new(big.Int).Sub(k, new(big.Int).Mul(r, d1))
+		s.Sub(s, new(big.Int).Mul(r, d2))
+		s.Mul(s, inv)
+		s.Mod(s, N)
+		if s.Sign() == 0 {
+			continue
+		}
+		return SignDigitToSignData(r, s)
+	}
+}
@@ -0,0 +1,68 @@
+package sm2
+
+import (
+	"bytes"
+	"crypto/rand"
+	"errors"
+	"math/big"
+	"testing"
+)
+
+func TestSignBlinded(t *testing.T) {
+	priv, err := GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := []byte("blinded signing oracle")
+	var prev []byte
+	for i := 0; i < 16; i++ {
+		sig, err := SignBlinded(priv, data, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !VerifySignature(&priv.PublicKey, data, sig) {
+			t.Fatal("blinded signature does not verify")
+		}
+		if bytes.Equal(sig, prev) {
+			t.Error("two calls gave the same signature")
+		}
+		prev = sig
+	}
+	if VerifySignature(&priv.PublicKey, []byte("other data"), prev) {
+		t.Error("blinded signature verified for different data")
+	}
+}
+
+type errorReader struct{}
+
+func (errorReader) Read([]byte) (int, error) { return 0, errors.New("no entropy") }
+
+func TestSignBlindedRandomError(t *testing.T) {
+	priv, err := GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := SignBlinded(priv, []byte("data"), errorReader{}); err == nil {
+		t.Error("signing succeeded without randomness")
+	}
+}
+
+func BenchmarkSignBlinded(b *testing.B) {
+	priv, _ := GenerateKey(rand.Reader)
+	data := []byte("benchmark")
+	for i := 0; i < b.N; i++ {
+		SignBlinded(priv, data, nil)
+	}
+}
+
+func TestSignBlindedKeyOutOfRange(t *testing.T) {
+	n := P256Sm2().Params().N
+	for _, d := range []*big.Int{new(big.Int).Sub(n, one), big.NewInt(0), n} {
+		priv := &PrivateKey{D: d}
+		priv.Curve = P256Sm2()
+		priv.X, priv.Y = priv.Curve.ScalarBaseMult(d.Bytes())
+		if _, err := SignBlinded(priv, []byte("data"), nil); err != ErrPrivateKeyOutOfRange {
+			t.Errorf("d = %v: got %v", d, err)
+		}
+	}
+}
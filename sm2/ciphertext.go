@@ -0,0 +1,207 @@
+package sm2
+
+import (
+	"crypto/subtle"
+	"encoding/asn1"
+	"errors"
+	"math/big"
+
+	"github.com/tjfoc/gmsm/sm3"
+)
+
+// Errors returned by PrivateKey.VerifyCiphertext, one per failed check.
+var (
+	ErrCiphertextMalformed    = errors.New("SM2: malformed ASN.1 ciphertext")
+	ErrCiphertextNotOnCurve   = errors.New("SM2: ciphertext C1 is not on the curve")
+	ErrCiphertextHashMismatch = errors.New("SM2: ciphertext hash mismatch")
+)
+
+// ErrInvalidC1 is returned by Decrypt when C1 is not a point on the curve
+// or is the point at infinity. It is the same error as
+// ErrCiphertextNotOnCurve.
+var ErrInvalidC1 = ErrCiphertextNotOnCurve
+
+// NormalizeCiphertext parses an ASN.1 encoded SM2 ciphertext and re-encodes
+// it in the GM/T 0009 order
+//
+//	SM2Cipher ::= SEQUENCE {
+//	    XCoordinate INTEGER,
+//	    YCoordinate INTEGER,
+//	    HASH        OCTET STRING,
+//	    CipherText  OCTET STRING
+//	}
+//
+// Some implementations emit the two OCTET STRINGs in C1C2C3 order, with the
+// ciphertext before the 32 byte SM3 hash. Such input is detected by the
+// field lengths and swapped back. When both OCTET STRINGs are 32 bytes long
+// the order cannot be told apart and the input is assumed to be canonical.
+//
+// BER input (indefinite or non-minimal lengths, constructed OCTET STRINGs)
+// is accepted as well; the result is always DER.
+func NormalizeCiphertext(ct []byte) ([]byte, error) {
+	if der, err := berToDER(ct); err == nil {
+		ct = der
+	}
+	x, y, hash, text, err := parseCipherFields(ct)
+	if err != nil {
+		return nil, err
+	}
+	if len(hash) != 32 {
+		if len(text) != 32 {
+			return nil, errors.New("SM2: ciphertext field HASH is not 32 bytes")
+		}
+		hash, text = text, hash
+	}
+	if !isValidPoint(x, y) {
+		return nil, errors.New("SM2: ciphertext fields XCoordinate/YCoordinate are not a curve point")
+	}
+	return asn1.Marshal(sm2Cipher{x, y, hash, text})
+}
+
+// parseCipherFields splits an SM2Cipher SEQUENCE into its four fields in the
+// order they appear, without checking which OCTET STRING holds the hash.
+func parseCipherFields(ct []byte) (x, y *big.Int, hash, text []byte, err error) {
+	var seq asn1.RawValue
+	rest, err := asn1.Unmarshal(ct, &seq)
+	if err != nil {
+		return nil, nil, nil, nil, errors.New("SM2: ciphertext is not an ASN.1 SEQUENCE: " + err.Error())
+	}
+	if len(rest) != 0 {
+		return nil, nil, nil, nil, errors.New("SM2: trailing data after ciphertext")
+	}
+	if seq.Class != asn1.ClassUniversal || seq.Tag != asn1.TagSequence || !seq.IsCompound {
+		return nil, nil, nil, nil, errors.New("SM2: ciphertext is not an ASN.1 SEQUENCE")
+	}
+
+	fields := seq.Bytes
+	x, fields, err = parseCipherInteger(fields, "XCoordinate")
+	if err != nil {
+		return nil, nil, nil, nil, err
+	}
+	y, fields, err = parseCipherInteger(fields, "YCoordinate")
+	if err != nil {
+		return nil, nil, nil, nil, err
+	}
+	hash, fields, err = parseCipherOctets(fields, "HASH")
+	if err != nil {
+		return nil, nil, nil, nil, err
+	}
+	text, fields, err = parseCipherOctets(fields, "CipherText")
+	if err != nil {
+		return nil, nil, nil, nil, err
+	}
+	if len(fields) != 0 {
+		return nil, nil, nil, nil, errors.New("SM2: unexpected field after CipherText")
+	}
+	return x, y, hash, text, nil
+}
+
+func parseCipherInteger(data []byte, name string) (*big.Int, []byte, error) {
+	if len(data) == 0 {
+		return nil, nil, errors.New("SM2: ciphertext field " + name + " is missing")
+	}
+	n := new(big.Int)
+	rest, err := asn1.Unmarshal(data, &n)
+	if err != nil {
+		return nil, nil, errors.New("SM2: ciphertext field " + name + ": " + err.Error())
+	}
+	return n, rest, nil
+}
+
+func parseCipherOctets(data []byte, name string) ([]byte, []byte, error) {
+	if len(data) == 0 {
+		return nil, nil, errors.New("SM2: ciphertext field " + name + " is missing")
+	}
+	var field asn1.RawValue
+	rest, err := asn1.Unmarshal(data, &field)
+	if err != nil {
+		return nil, nil, errors.New("SM2: ciphertext field " + name + ": " + err.Error())
+	}
+	if field.Class != asn1.ClassUniversal || field.Tag != asn1.TagOctetString || field.IsCompound {
+		return nil, nil, errors.New("SM2: ciphertext field " + name + " is not an OCTET STRING")
+	}
+	return field.Bytes, rest, nil
+}
+
+// CiphertextLen returns the size of the ciphertext Encrypt produces for a
+// plaintext of plaintextLen bytes, or with asn1Form set, an upper bound on the
+// size EncryptAsn1 produces.
+//
+// The raw formats C1C3C2 and C1C2C3 are always 97 + plaintextLen bytes:
+// 0x04, two 32 byte coordinates, the 32 byte hash and the ciphertext. In
+// the ASN.1 form the coordinates are DER INTEGERs, whose encoding depends
+// on their value: it grows by one byte when the top bit is set and shrinks
+// with leading zero bytes. The bound assumes two 33 byte INTEGERs and is
+// reached by about a quarter of all ciphertexts. It returns -1 for a
+// negative plaintextLen.
+func CiphertextLen(plaintextLen int, asn1Form bool) int {
+	if plaintextLen < 0 {
+		return -1
+	}
+	if !asn1Form {
+		return 97 + plaintextLen
+	}
+	content := 2*(2+33) + (2 + 32) + derElementLen(plaintextLen)
+	return derElementLen(content)
+}
+
+// derElementLen returns the size of a DER element with n content bytes.
+func derElementLen(n int) int {
+	size := 2 + n
+	if n >= 0x80 {
+		for l := n; l > 0; l >>= 8 {
+			size++
+		}
+	}
+	return size
+}
+
+// VerifyCiphertext reports whether ct, an ASN.1 ciphertext as accepted by
+// DecryptData, decrypts under priv. It performs the same C3 check as
+// Decrypt but streams the plaintext into the hash a block at a time
+// instead of returning it. The error is ErrCiphertextMalformed,
+// ErrCiphertextNotOnCurve or ErrCiphertextHashMismatch; a ciphertext whose
+// key stream is all zero, which Decrypt also rejects, reports the latter.
+func (priv *PrivateKey) VerifyCiphertext(ct []byte) error {
+	x, y, hash, text, err := parseCipherFields(ct)
+	if err != nil || len(hash) != 32 {
+		return ErrCiphertextMalformed
+	}
+	if !isValidPoint(x, y) {
+		return ErrCiphertextNotOnCurve
+	}
+	x2, y2 := priv.Curve.ScalarMult(x, y, priv.D.Bytes())
+	var x2Buf, y2Buf [32]byte
+	putFixedBytes(x2Buf[:], x2)
+	putFixedBytes(y2Buf[:], y2)
+
+	// t = KDF(x2 || y2, len(text)) is generated one SM3 block at a time
+	// and XORed with C2 into the C3 hash, as kdf would produce it.
+	c3 := sm3.New()
+	c3.Write(x2Buf[:])
+	kdfHash := sm3.New()
+	var block [32]byte
+	var nonZero byte
+	for i := 1; len(text) > 0; i++ {
+		kdfHash.Reset()
+		kdfHash.Write(x2Buf[:])
+		kdfHash.Write(y2Buf[:])
+		kdfHash.Write(intToBytes(i))
+		t := kdfHash.Sum(block[:0])
+		n := len(text)
+		if n > len(t) {
+			n = len(t)
+		}
+		for _, b := range t[:n] {
+			nonZero |= b
+		}
+		subtle.XORBytes(block[:n], t[:n], text[:n])
+		c3.Write(block[:n])
+		text = text[n:]
+	}
+	c3.Write(y2Buf[:])
+	if nonZero == 0 || subtle.ConstantTimeCompare(c3.Sum(nil), hash) != 1 {
+		return ErrCiphertextHashMismatch
+	}
+	return nil
+}
@@ -0,0 +1,166 @@
+package sm2
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/asn1"
+	"math/big"
+	"strings"
+	"testing"
+)
+
+func TestNormalizeCiphertext(t *testing.T) {
+	priv, err := GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg := []byte("vendor interoperability")
+	ct, err := EncryptAsn1(&priv.PublicKey, msg, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := NormalizeCiphertext(ct)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, ct) {
+		t.Error("canonical ciphertext was changed")
+	}
+
+	var c sm2Cipher
+	if _, err := asn1.Unmarshal(ct, &c); err != nil {
+		t.Fatal(err)
+	}
+	swapped, _ := asn1.Marshal(sm2Cipher{c.XCoordinate, c.YCoordinate, c.CipherText, c.HASH})
+	got, err = NormalizeCiphertext(swapped)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, ct) {
+		t.Error("C1C2C3 field order was not corrected")
+	}
+	if pt, err := DecryptAsn1(priv, got); err != nil || !bytes.Equal(pt, msg) {
+		t.Errorf("normalized ciphertext does not decrypt: %v", err)
+	}
+
+	badX, _ := asn1.Marshal(struct {
+		X    []byte
+		Y    *big.Int
+		H, C []byte
+	}{c.XCoordinate.Bytes(), c.YCoordinate, c.HASH, c.CipherText})
+	offCurve, _ := asn1.Marshal(sm2Cipher{c.XCoordinate, new(big.Int).Add(c.YCoordinate, one), c.HASH, c.CipherText})
+	shortHash, _ := asn1.Marshal(sm2Cipher{c.XCoordinate, c.YCoordinate, c.HASH[:20], c.CipherText})
+	missing, _ := asn1.Marshal(struct {
+		X, Y *big.Int
+		H    []byte
+	}{c.XCoordinate, c.YCoordinate, c.HASH})
+	for _, tc := range []struct {
+		name  string
+		input []byte
+		field string
+	}{
+		{"garbage", []byte("not asn.1"), "SEQUENCE"},
+		{"trailing", append(append([]byte{}, ct...), 0), "trailing"},
+		{"octet x", badX, "XCoordinate"},
+		{"off curve", offCurve, "YCoordinate"},
+		{"short hash", shortHash, "HASH"},
+		{"missing field", missing, "CipherText"},
+	} {
+		_, err := NormalizeCiphertext(tc.input)
+		if err == nil {
+			t.Errorf("%s: accepted", tc.name)
+		} else if !strings.Contains(err.Error(), tc.field) {
+			t.Errorf("%s: error %q does not name %s", tc.name, err, tc.field)
+		}
+	}
+}
+
+func TestCiphertextLen(t *testing.T) {
+	priv, err := GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, n := range []int{1, 31, 32, 60, 200, 300, 70000} {
+		msg := make([]byte, n)
+		raw, err := Encrypt(&priv.PublicKey, msg, rand.Reader, C1C2C3)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(raw) != CiphertextLen(n, false) {
+			t.Errorf("%d bytes: raw ciphertext is %d bytes, CiphertextLen says %d", n, len(raw), CiphertextLen(n, false))
+		}
+		bound := CiphertextLen(n, true)
+		longest := 0
+		for i := 0; i < 48; i++ {
+			ct, err := EncryptAsn1(&priv.PublicKey, msg, rand.Reader)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(ct) > bound {
+				t.Fatalf("%d bytes: ASN.1 ciphertext is %d bytes, above the bound %d", n, len(ct), bound)
+			}
+			if len(ct) > longest {
+				longest = len(ct)
+			}
+		}
+		if longest != bound {
+			t.Errorf("%d bytes: longest ASN.1 ciphertext is %d bytes, bound %d is not tight", n, longest, bound)
+		}
+	}
+	if CiphertextLen(-1, false) != -1 {
+		t.Error("negative length accepted")
+	}
+}
+
+func TestVerifyCiphertext(t *testing.T) {
+	priv, err := GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, n := range []int{1, 32, 33, 100} {
+		ct, err := EncryptAsn1(&priv.PublicKey, bytes.Repeat([]byte{7}, n), rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := priv.VerifyCiphertext(ct); err != nil {
+			t.Errorf("%d bytes: valid ciphertext rejected: %v", n, err)
+		}
+
+		var c sm2Cipher
+		if _, err := asn1.Unmarshal(ct, &c); err != nil {
+			t.Fatal(err)
+		}
+		c.CipherText[n-1] ^= 1
+		tampered, _ := asn1.Marshal(c)
+		if err := priv.VerifyCiphertext(tampered); err != ErrCiphertextHashMismatch {
+			t.Errorf("%d bytes: tampered C2: got %v", n, err)
+		}
+		if _, err := DecryptAsn1(priv, tampered); err == nil {
+			t.Errorf("%d bytes: Decrypt accepted what VerifyCiphertext rejected", n)
+		}
+		c.CipherText[n-1] ^= 1
+
+		c.YCoordinate = new(big.Int).Add(c.YCoordinate, big.NewInt(1))
+		offCurve, _ := asn1.Marshal(c)
+		if err := priv.VerifyCiphertext(offCurve); err != ErrCiphertextNotOnCurve {
+			t.Errorf("%d bytes: C1 off the curve: got %v", n, err)
+		}
+	}
+
+	other, err := GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ct, err := EncryptAsn1(&other.PublicKey, []byte("for someone else"), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := priv.VerifyCiphertext(ct); err != ErrCiphertextHashMismatch {
+		t.Errorf("wrong key: got %v", err)
+	}
+	for _, bad := range [][]byte{nil, []byte("garbage"), ct[:len(ct)-1], append(append([]byte(nil), ct...), 0)} {
+		if err := priv.VerifyCiphertext(bad); err != ErrCiphertextMalformed {
+			t.Errorf("malformed input %x: got %v", bad, err)
+		}
+	}
+}
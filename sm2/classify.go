@@ -0,0 +1,66 @@
+package sm2
+
+import (
+	"bytes"
+	"encoding/asn1"
+	"errors"
+)
+
+// Kinds returned by Classify.
+const (
+	KindSignature        = "signature"
+	KindCiphertextC1C3C2 = "ciphertext-c1c3c2"
+	KindCiphertextC1C2C3 = "ciphertext-c1c2c3"
+	KindUnknown          = "unknown"
+)
+
+// Classify guesses whether data is an ASN.1 encoded SM2 signature or
+// ciphertext by looking at its structure only; no key is involved.
+//
+//   - A DER SEQUENCE of exactly two INTEGERs R, S with 0 < R, S < N is a
+//     signature.
+//   - A SEQUENCE of two INTEGERs forming a curve point followed by two OCTET
+//     STRINGs is a ciphertext. If the first OCTET STRING is the 32 byte SM3
+//     hash it is reported as C1C3C2 (GM/T 0009), if only the second one is
+//     it is reported as C1C2C3. A 32 byte plaintext makes both fields 32
+//     bytes long; such input is reported as C1C3C2.
+//
+// Anything else is reported as KindUnknown with an error explaining why. In
+// particular raw ciphertexts as returned by Encrypt are recognised but not
+// classified, since C1||C3||C2 and C1||C2||C3 only differ in the position of
+// random looking bytes. A positive answer never proves that data verifies
+// or decrypts.
+func Classify(data []byte) (kind string, err error) {
+	if x, y, hash, text, err := parseCipherFields(data); err == nil {
+		if !isValidPoint(x, y) {
+			return KindUnknown, errors.New("SM2: ciphertext C1 is not a curve point")
+		}
+		switch {
+		case len(hash) == 32:
+			return KindCiphertextC1C3C2, nil
+		case len(text) == 32:
+			return KindCiphertextC1C2C3, nil
+		}
+		return KindUnknown, errors.New("SM2: ciphertext has no 32 byte hash field")
+	}
+
+	var sig sm2Signature
+	if rest, err := asn1.Unmarshal(data, &sig); err == nil && len(rest) == 0 {
+		// Re-encoding rejects extra SEQUENCE elements, which encoding/asn1
+		// silently ignores, and non-DER input.
+		if der, err := asn1.Marshal(sig); err == nil && bytes.Equal(der, data) {
+			n := P256Sm2().Params().N
+			if sig.R.Sign() > 0 && sig.R.Cmp(n) < 0 && sig.S.Sign() > 0 && sig.S.Cmp(n) < 0 {
+				return KindSignature, nil
+			}
+			return KindUnknown, errors.New("SM2: signature values out of range")
+		}
+	}
+
+	if len(data) > 97 && data[0] == 0x04 {
+		if _, err := Unmarshal(data[:65]); err == nil {
+			return KindUnknown, errors.New("SM2: raw ciphertext, C1C3C2 and C1C2C3 cannot be told apart")
+		}
+	}
+	return KindUnknown, errors.New("SM2: data is neither an ASN.1 signature nor ciphertext")
+}
@@ -0,0 +1,55 @@
+package sm2
+
+import (
+	"crypto/rand"
+	"encoding/asn1"
+	"testing"
+)
+
+func TestClassify(t *testing.T) {
+	priv, err := GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sig, err := priv.Sign(rand.Reader, []byte("classify me"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ct, err := EncryptAsn1(&priv.PublicKey, []byte("classify me too"), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var c sm2Cipher
+	if _, err := asn1.Unmarshal(ct, &c); err != nil {
+		t.Fatal(err)
+	}
+	swapped, _ := asn1.Marshal(sm2Cipher{c.XCoordinate, c.YCoordinate, c.CipherText, c.HASH})
+	raw132, _ := Encrypt(&priv.PublicKey, []byte("raw"), rand.Reader, C1C3C2)
+	raw123, _ := Encrypt(&priv.PublicKey, []byte("raw"), rand.Reader, C1C2C3)
+	threeInts, _ := asn1.Marshal(struct{ A, B, C int }{1, 2, 3})
+	zeroSig, _ := asn1.Marshal(struct{ R, S int }{0, 1})
+
+	for _, tc := range []struct {
+		name string
+		data []byte
+		kind string
+	}{
+		{"signature", sig, KindSignature},
+		{"asn1 c1c3c2", ct, KindCiphertextC1C3C2},
+		{"asn1 c1c2c3", swapped, KindCiphertextC1C2C3},
+		{"raw c1c3c2", raw132, KindUnknown},
+		{"raw c1c2c3", raw123, KindUnknown},
+		{"three integers", threeInts, KindUnknown},
+		{"zero signature", zeroSig, KindUnknown},
+		{"empty", nil, KindUnknown},
+		{"garbage", []byte("garbage"), KindUnknown},
+	} {
+		kind, err := Classify(tc.data)
+		if kind != tc.kind {
+			t.Errorf("%s: got %q, want %q", tc.name, kind, tc.kind)
+		}
+		if (kind == KindUnknown) != (err != nil) {
+			t.Errorf("%s: kind %q with error %v", tc.name, kind, err)
+		}
+	}
+}
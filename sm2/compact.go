@@ -0,0 +1,137 @@
+package sm2
+
+import (
+	"crypto/rand"
+	"errors"
+	"math/big"
+
+	"github.com/tjfoc/gmsm/sm3"
+)
+
+// CompactSignatureSize is the length of a signature made by SignCompact.
+const CompactSignatureSize = 65
+
+// ErrCompactRecovery is returned by RecoverCompact when no public key can
+// be recovered from the signature.
+var ErrCompactRecovery = errors.New("SM2: cannot recover public key from compact signature")
+
+// SignCompact signs data and returns the 65 byte r || s || v form, where r
+// and s are 32 bytes big-endian with s <= n/2 and v is the recovery byte:
+// bit 0 is the parity of the y coordinate of the ephemeral point and bit 1
+// is set when its x coordinate is at least n.
+//
+// The standard SM2 message hash mixes the signer's public key into ZA, so
+// it cannot be computed before the key is recovered. Compact signatures
+// therefore sign e = SM3(data) without ZA: they verify with
+// VerifyDigest(pub, sm3.Sm3Sum(data), sig), not with Verify or
+// VerifySignature.
+func SignCompact(priv *PrivateKey, data []byte) ([]byte, error) {
+	e := new(big.Int).SetBytes(sm3.Sm3Sum(data))
+	for i := 0; i < maxLowSAttempts; i++ {
+		r, s, err := signWithE(priv, e, rand.Reader)
+		if err != nil {
+			return nil, err
+		}
+		if !isLowS(s) {
+			continue
+		}
+		// Rebuild the ephemeral point R = s*G + (r + s)*P to read off
+		// its coordinates; signWithE does not return it.
+		t := new(big.Int).Add(r, s)
+		t.Mod(t, sm2P256.N)
+		x1, y1 := ScalarBaseMult(s.Bytes())
+		x2, y2 := ScalarMult(priv.X, priv.Y, t.Bytes())
+		x, y := Add(x1, y1, x2, y2)
+
+		sig := make([]byte, CompactSignatureSize)
+		putFixedBytes(sig[:32], r)
+		putFixedBytes(sig[32:64], s)
+		sig[64] = byte(y.Bit(0))
+		if x.Cmp(sm2P256.N) >= 0 {
+			sig[64] |= 2
+		}
+		return sig, nil
+	}
+	return nil, errors.New("SM2: failed to produce a low-S signature")
+}
+
+// RecoverCompact returns the public key that made the compact signature sig
+// over data with SignCompact. It rejects signatures that are not 65 bytes,
+// have r or s out of range, a high s or an unknown recovery byte. Any
+// valid key pair yields some public key, so callers must compare the
+// result against the key or address they expect.
+func RecoverCompact(data, sig []byte) (*PublicKey, error) {
+	if len(sig) != CompactSignatureSize {
+		return nil, errors.New("SM2: invalid compact signature length")
+	}
+	v := sig[64]
+	if v > 3 {
+		return nil, errors.New("SM2: invalid compact signature recovery byte")
+	}
+	N := sm2P256.N
+	r := new(big.Int).SetBytes(sig[:32])
+	s := new(big.Int).SetBytes(sig[32:64])
+	if r.Sign() <= 0 || s.Sign() <= 0 || r.Cmp(N) >= 0 || s.Cmp(N) >= 0 {
+		return nil, ErrSignatureOutOfRange
+	}
+	if !isLowS(s) {
+		return nil, ErrHighS
+	}
+	t := new(big.Int).Add(r, s)
+	t.Mod(t, N)
+	if t.Sign() == 0 {
+		return nil, ErrSignatureOutOfRange
+	}
+
+	// r = e + x1 mod n, so x1 is r - e mod n, plus n if the overflow bit
+	// is set.
+	e := new(big.Int).SetBytes(sm3.Sm3Sum(data))
+	x := new(big.Int).Sub(r, e)
+	x.Mod(x, N)
+	if v&2 != 0 {
+		x.Add(x, N)
+	}
+	y, ok := liftX(x, uint(v&1))
+	if !ok {
+		return nil, ErrCompactRecovery
+	}
+
+	// s*G + t*P = R, so P = t^-1 * (R - s*G) = t^-1 * (R + (n-s)*G).
+	negS := new(big.Int).Sub(N, s)
+	x1, y1 := ScalarBaseMult(negS.Bytes())
+	x2, y2 := Add(x, y, x1, y1)
+	if x2.Sign() == 0 && y2.Sign() == 0 {
+		return nil, ErrCompactRecovery
+	}
+	tInv := new(big.Int).ModInverse(t, N)
+	px, py := ScalarMult(x2, y2, tInv.Bytes())
+	if px.Sign() == 0 && py.Sign() == 0 {
+		return nil, ErrCompactRecovery
+	}
+	return &PublicKey{Curve: P256Sm2(), X: px, Y: py}, nil
+}
+
+// liftX returns the y coordinate with the given parity of the curve point
+// with x coordinate x, or false if there is none.
+func liftX(x *big.Int, parity uint) (*big.Int, bool) {
+	P := sm2P256.P
+	if x.Cmp(P) >= 0 {
+		return nil, false
+	}
+	// y^2 = x^3 - 3x + b
+	y2 := new(big.Int).Mul(x, x)
+	y2.Mul(y2, x)
+	threeX := new(big.Int).Lsh(x, 1)
+	threeX.Add(threeX, x)
+	y2.Sub(y2, threeX)
+	y2.Add(y2, sm2P256.B)
+	y2.Mod(y2, P)
+	y := new(big.Int).ModSqrt(y2, P)
+	if y == nil {
+		return nil, false
+	}
+	if y.Bit(0) != parity {
+		y.Sub(P, y)
+	}
+	return y, isValidPoint(x, y)
+}
@@ -0,0 +1,84 @@
+package sm2
+
+import (
+	"crypto/rand"
+	"math/big"
+	"testing"
+
+	"github.com/tjfoc/gmsm/sm3"
+)
+
+func TestSignCompact(t *testing.T) {
+	for i := 0; i < 8; i++ {
+		priv, err := GenerateKey(rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		data := []byte("compact signature test message")
+		sig, err := SignCompact(priv, data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(sig) != CompactSignatureSize {
+			t.Fatalf("got %d byte signature", len(sig))
+		}
+		pub, err := RecoverCompact(data, sig)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if pub.X.Cmp(priv.X) != 0 || pub.Y.Cmp(priv.Y) != 0 {
+			t.Fatal("recovered the wrong public key")
+		}
+
+		der, err := SignDigitToSignData(new(big.Int).SetBytes(sig[:32]), new(big.Int).SetBytes(sig[32:64]))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !VerifyDigest(&priv.PublicKey, sm3.Sm3Sum(data), der) {
+			t.Error("compact signature does not verify with VerifyDigest")
+		}
+
+		if other, err := RecoverCompact([]byte("other message"), sig); err == nil &&
+			other.X.Cmp(priv.X) == 0 && other.Y.Cmp(priv.Y) == 0 {
+			t.Error("recovered the signer's key for different data")
+		}
+		flipped := append([]byte(nil), sig...)
+		flipped[64] ^= 1
+		if other, err := RecoverCompact(data, flipped); err == nil &&
+			other.X.Cmp(priv.X) == 0 && other.Y.Cmp(priv.Y) == 0 {
+			t.Error("recovered the signer's key with a flipped parity bit")
+		}
+	}
+}
+
+func TestRecoverCompactRejects(t *testing.T) {
+	priv, err := GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := []byte("message")
+	sig, err := SignCompact(priv, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := RecoverCompact(data, sig[:64]); err == nil {
+		t.Error("accepted a short signature")
+	}
+	bad := append([]byte(nil), sig...)
+	bad[64] = 4
+	if _, err := RecoverCompact(data, bad); err == nil {
+		t.Error("accepted recovery byte 4")
+	}
+	bad = append([]byte(nil), sig...)
+	for i := 0; i < 32; i++ {
+		bad[i] = 0
+	}
+	if _, err := RecoverCompact(data, bad); err != ErrSignatureOutOfRange {
+		t.Errorf("r = 0: got %v", err)
+	}
+	bad = append([]byte(nil), sig...)
+	putFixedBytes(bad[32:64], new(big.Int).Add(halfN, one))
+	if _, err := RecoverCompact(data, bad); err != ErrHighS {
+		t.Errorf("high s: got %v", err)
+	}
+}
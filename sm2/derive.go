@@ -0,0 +1,71 @@
+package sm2
+
+import (
+	"encoding/binary"
+	"errors"
+	"math/big"
+
+	"github.com/tjfoc/gmsm/sm3"
+)
+
+// HardenedKeyStart is the first child index of hardened derivation.
+const HardenedKeyStart = 1 << 31
+
+// DeriveChildKey derives the child key at index from parent and its 32 byte
+// chain code, in the manner of BIP32 with HMAC-SM3 in place of HMAC-SHA512.
+// With data = 0x00 || D || index for hardened indexes (index >=
+// HardenedKeyStart) and data = 0x02/0x03 || X || index (the SEC1 compressed
+// public key) otherwise,
+//
+//	IL = HMAC-SM3(chainCode, data || 0x01)
+//	IR = HMAC-SM3(chainCode, data || 0x02)
+//
+// and the child key is (D + IL) mod n with chain code IR. HMAC-SM3 only
+// yields 32 bytes, hence the two calls; the keys are not compatible with
+// BIP32 wallets. An error is returned, and the next index should be used,
+// if IL >= n or the child key is zero.
+func DeriveChildKey(parent *PrivateKey, chainCode []byte, index uint32) (child *PrivateKey, childChainCode []byte, err error) {
+	if len(chainCode) != 32 {
+		return nil, nil, errors.New("SM2: chain code must be 32 bytes")
+	}
+	n := P256Sm2().Params().N
+	if parent.D == nil || parent.D.Sign() <= 0 || parent.D.Cmp(n) >= 0 {
+		return nil, nil, errors.New("SM2: invalid parent private key")
+	}
+
+	data := make([]byte, 33, 33+4+1)
+	if index >= HardenedKeyStart {
+		putFixedBytes(data[1:], parent.D)
+	} else {
+		x, y := parent.Curve.ScalarBaseMult(parent.D.Bytes())
+		data[0] = 0x02 | byte(y.Bit(0))
+		putFixedBytes(data[1:], x)
+	}
+	data = binary.BigEndian.AppendUint32(data, index)
+
+	h := sm3.NewHMAC(chainCode)
+	h.Write(data)
+	h.Write([]byte{0x01})
+	il := new(big.Int).SetBytes(h.Sum(nil))
+	h.Reset()
+	h.Write(data)
+	h.Write([]byte{0x02})
+	childChainCode = h.Sum(nil)
+	for i := range data {
+		data[i] = 0
+	}
+	if il.Cmp(n) >= 0 {
+		return nil, nil, errors.New("SM2: derived key out of range, use the next index")
+	}
+	d := il.Add(il, parent.D)
+	d.Mod(d, n)
+	if d.Sign() == 0 {
+		return nil, nil, errors.New("SM2: derived key is zero, use the next index")
+	}
+
+	child = new(PrivateKey)
+	child.Curve = parent.Curve
+	child.D = d
+	child.X, child.Y = child.Curve.ScalarBaseMult(d.Bytes())
+	return child, childChainCode, nil
+}
@@ -0,0 +1,67 @@
+package sm2
+
+import (
+	"encoding/hex"
+	"math/big"
+	"testing"
+
+	"github.com/tjfoc/gmsm/sm3"
+)
+
+func TestDeriveChildKey(t *testing.T) {
+	d, _ := new(big.Int).SetString("3945208f7b2144b13f36e38ac6d39f95889393692860b51a42fb81ef4df7c5b8", 16)
+	parent := new(PrivateKey)
+	parent.Curve = P256Sm2()
+	parent.D = d
+	parent.X, parent.Y = parent.Curve.ScalarBaseMult(d.Bytes())
+	chain := make([]byte, 32)
+	for i := range chain {
+		chain[i] = byte(i)
+	}
+
+	for _, v := range []struct {
+		index     uint32
+		key, code string
+	}{
+		{
+			0,
+			"fc5c1e606614a0262ea4993d12630e353a654009f92fadc9a0d719b7401a9095",
+			"3404a9aae62b9143e4c97a5601f48dbf963dc523b382200a703f7eff37ac6846",
+		},
+		{
+			HardenedKeyStart,
+			"4a75150cd41ae0dcb482c30374f87f443a40205cf3e0c85ccfc1c492949f21b4",
+			"a455b9727c66f330383c902fccf8f3d8d412eb3e24ae0ff7061acedc8a50d2fe",
+		},
+	} {
+		child, code, err := DeriveChildKey(parent, chain, v.index)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := hex.EncodeToString(child.D.FillBytes(make([]byte, 32))); got != v.key {
+			t.Errorf("index %#x: key %s, want %s", v.index, got, v.key)
+		}
+		if got := hex.EncodeToString(code); got != v.code {
+			t.Errorf("index %#x: chain code %s, want %s", v.index, got, v.code)
+		}
+		x, y := P256Sm2().ScalarBaseMult(child.D.Bytes())
+		if x.Cmp(child.X) != 0 || y.Cmp(child.Y) != 0 {
+			t.Errorf("index %#x: public key does not match D", v.index)
+		}
+	}
+
+	// Hardened: IL = HMAC-SM3(c, 0x00 || D || index || 0x01).
+	data := append([]byte{0}, d.Bytes()...)
+	data = append(data, 0x80, 0, 0, 5, 1)
+	il := new(big.Int).SetBytes(sm3.SM3HMAC(chain, data))
+	want := il.Add(il, d)
+	want.Mod(want, P256Sm2().Params().N)
+	child, _, err := DeriveChildKey(parent, chain, HardenedKeyStart+5)
+	if err != nil || child.D.Cmp(want) != 0 {
+		t.Errorf("hardened child is not D + IL: %v", err)
+	}
+
+	if _, _, err := DeriveChildKey(parent, chain[:16], 0); err == nil {
+		t.Error("short chain code accepted")
+	}
+}
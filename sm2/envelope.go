@@ -0,0 +1,209 @@
+package sm2
+
+import (
+	"bytes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/asn1"
+	"errors"
+	"io"
+
+	"github.com/tjfoc/gmsm/sm4"
+)
+
+const envelopeVersion = 1
+
+// sm2Envelope is the ASN.1 layout produced by SealEnvelope, modelled on the
+// GM/T 0010 digital envelope:
+//
+//	SM2Envelope ::= SEQUENCE {
+//	    version        INTEGER,      -- always 1
+//	    encryptedKey   OCTET STRING, -- SM2Cipher (GM/T 0009) of the SM4 key
+//	    nonce          OCTET STRING, -- 12 byte SM4-GCM nonce
+//	    encryptedData  OCTET STRING  -- SM4-GCM ciphertext followed by the 16 byte tag
+//	}
+type sm2Envelope struct {
+	Version       int
+	EncryptedKey  []byte
+	Nonce         []byte
+	EncryptedData []byte
+}
+
+// SealEnvelope encrypts plaintext for pub using a hybrid scheme: a random
+// SM4 key encrypts the payload with SM4-GCM and is itself encrypted with SM2.
+// Both parts are returned packed in a single DER encoded SM2Envelope.
+func SealEnvelope(pub *PublicKey, plaintext []byte) ([]byte, error) {
+	key := make([]byte, sm4.BlockSize)
+	if _, err := io.ReadFull(rand.Reader, key); err != nil {
+		return nil, err
+	}
+	aead, err := newEnvelopeAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+	encryptedKey, err := EncryptAsn1(pub, key, rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	return asn1.Marshal(sm2Envelope{
+		Version:       envelopeVersion,
+		EncryptedKey:  encryptedKey,
+		Nonce:         nonce,
+		EncryptedData: aead.Seal(nil, nonce, plaintext, nil),
+	})
+}
+
+// OpenEnvelope decrypts an envelope produced by SealEnvelope. The SM4-GCM
+// tag is verified before any plaintext is returned.
+func OpenEnvelope(priv *PrivateKey, envelope []byte) ([]byte, error) {
+	var env sm2Envelope
+	rest, err := asn1.Unmarshal(envelope, &env)
+	if err != nil {
+		return nil, err
+	}
+	if len(rest) != 0 {
+		return nil, errors.New("SM2: trailing data after envelope")
+	}
+	if env.Version != envelopeVersion {
+		return nil, errors.New("SM2: unsupported envelope version")
+	}
+	key, err := DecryptAsn1(priv, env.EncryptedKey)
+	if err != nil {
+		return nil, err
+	}
+	if len(key) != sm4.BlockSize {
+		return nil, errors.New("SM2: invalid envelope key size")
+	}
+	aead, err := newEnvelopeAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(env.Nonce) != aead.NonceSize() {
+		return nil, errors.New("SM2: invalid envelope nonce size")
+	}
+	plaintext, err := aead.Open(nil, env.Nonce, env.EncryptedData, nil)
+	if err != nil {
+		return nil, errors.New("SM2: envelope authentication failed")
+	}
+	return plaintext, nil
+}
+
+func newEnvelopeAEAD(key []byte) (cipher.AEAD, error) {
+	return sm4.NewAEADByName("sm4-gcm", key)
+}
+
+const multiEnvelopeVersion = 2
+
+// sm2MultiEnvelope is the ASN.1 layout produced by SealEnvelopeMulti. It
+// extends SM2Envelope with one wrapped key per recipient:
+//
+//	SM2MultiEnvelope ::= SEQUENCE {
+//	    version        INTEGER,      -- always 2
+//	    recipients     SEQUENCE OF RecipientInfo,
+//	    nonce          OCTET STRING,
+//	    encryptedData  OCTET STRING
+//	}
+//
+//	RecipientInfo ::= SEQUENCE {
+//	    fingerprint    OCTET STRING, -- PublicKey.Fingerprint of the recipient
+//	    encryptedKey   OCTET STRING  -- SM2Cipher of the SM4 key
+//	}
+type sm2MultiEnvelope struct {
+	Version       int
+	Recipients    []recipientInfo
+	Nonce         []byte
+	EncryptedData []byte
+}
+
+type recipientInfo struct {
+	Fingerprint  []byte
+	EncryptedKey []byte
+}
+
+// SealEnvelopeMulti is like SealEnvelope for several recipients: the payload
+// is encrypted once and the SM4 key is encrypted separately for each public
+// key in pubs. The fingerprints of the recipients are visible to anyone
+// holding the envelope.
+func SealEnvelopeMulti(pubs []*PublicKey, plaintext []byte) ([]byte, error) {
+	if len(pubs) == 0 {
+		return nil, errors.New("SM2: no envelope recipients")
+	}
+	key := make([]byte, sm4.BlockSize)
+	if _, err := io.ReadFull(rand.Reader, key); err != nil {
+		return nil, err
+	}
+	aead, err := newEnvelopeAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+	env := sm2MultiEnvelope{
+		Version:       multiEnvelopeVersion,
+		Recipients:    make([]recipientInfo, len(pubs)),
+		Nonce:         nonce,
+		EncryptedData: aead.Seal(nil, nonce, plaintext, nil),
+	}
+	for i, pub := range pubs {
+		encryptedKey, err := EncryptAsn1(pub, key, rand.Reader)
+		if err != nil {
+			return nil, err
+		}
+		fp := pub.Fingerprint()
+		env.Recipients[i] = recipientInfo{Fingerprint: fp[:], EncryptedKey: encryptedKey}
+	}
+	return asn1.Marshal(env)
+}
+
+// OpenEnvelopeMulti decrypts an envelope produced by SealEnvelopeMulti,
+// using the recipient entry whose fingerprint matches priv's public key.
+// It returns an error if priv is not among the recipients.
+func OpenEnvelopeMulti(priv *PrivateKey, envelope []byte) ([]byte, error) {
+	var env sm2MultiEnvelope
+	rest, err := asn1.Unmarshal(envelope, &env)
+	if err != nil {
+		return nil, err
+	}
+	if len(rest) != 0 {
+		return nil, errors.New("SM2: trailing data after envelope")
+	}
+	if env.Version != multiEnvelopeVersion {
+		return nil, errors.New("SM2: unsupported envelope version")
+	}
+	fp := priv.PublicKey.Fingerprint()
+	var encryptedKey []byte
+	for _, r := range env.Recipients {
+		if bytes.Equal(r.Fingerprint, fp[:]) {
+			encryptedKey = r.EncryptedKey
+			break
+		}
+	}
+	if encryptedKey == nil {
+		return nil, errors.New("SM2: no envelope recipient entry for this key")
+	}
+	key, err := DecryptAsn1(priv, encryptedKey)
+	if err != nil {
+		return nil, err
+	}
+	if len(key) != sm4.BlockSize {
+		return nil, errors.New("SM2: invalid envelope key size")
+	}
+	aead, err := newEnvelopeAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(env.Nonce) != aead.NonceSize() {
+		return nil, errors.New("SM2: invalid envelope nonce size")
+	}
+	plaintext, err := aead.Open(nil, env.Nonce, env.EncryptedData, nil)
+	if err != nil {
+		return nil, errors.New("SM2: envelope authentication failed")
+	}
+	return plaintext, nil
+}
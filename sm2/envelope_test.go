@@ -0,0 +1,102 @@
+package sm2
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/asn1"
+	"testing"
+)
+
+func TestEnvelope(t *testing.T) {
+	priv, err := GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	plaintext := bytes.Repeat([]byte("large payload "), 1024)
+	env, err := SealEnvelope(&priv.PublicKey, plaintext)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := OpenEnvelope(priv, env)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, plaintext) {
+		t.Fatal("envelope round trip mismatch")
+	}
+
+	other, err := GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := OpenEnvelope(other, env); err == nil {
+		t.Error("envelope opened with the wrong private key")
+	}
+
+	var parsed sm2Envelope
+	if _, err := asn1.Unmarshal(env, &parsed); err != nil {
+		t.Fatal(err)
+	}
+	parsed.EncryptedData[len(parsed.EncryptedData)-1] ^= 1
+	tampered, err := asn1.Marshal(parsed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := OpenEnvelope(priv, tampered); err == nil {
+		t.Error("envelope with a corrupted tag was accepted")
+	}
+}
+
+func TestEnvelopeMulti(t *testing.T) {
+	var privs []*PrivateKey
+	var pubs []*PublicKey
+	for i := 0; i < 3; i++ {
+		priv, err := GenerateKey(rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		privs = append(privs, priv)
+		pubs = append(pubs, &priv.PublicKey)
+	}
+	plaintext := []byte("one document, three recipients")
+	env, err := SealEnvelopeMulti(pubs, plaintext)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, priv := range privs {
+		got, err := OpenEnvelopeMulti(priv, env)
+		if err != nil || !bytes.Equal(got, plaintext) {
+			t.Errorf("recipient %d: %v", i, err)
+		}
+	}
+
+	outsider, err := GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := OpenEnvelopeMulti(outsider, env); err == nil {
+		t.Error("envelope opened by a key that is not a recipient")
+	}
+	if _, err := OpenEnvelope(privs[0], env); err == nil {
+		t.Error("multi-recipient envelope accepted by OpenEnvelope")
+	}
+	if _, err := SealEnvelopeMulti(nil, plaintext); err == nil {
+		t.Error("envelope without recipients sealed")
+	}
+
+	// A recipient entry copied under another fingerprint does not help an
+	// outsider, whose key cannot decrypt it.
+	var parsed sm2MultiEnvelope
+	if _, err := asn1.Unmarshal(env, &parsed); err != nil {
+		t.Fatal(err)
+	}
+	fp := outsider.PublicKey.Fingerprint()
+	parsed.Recipients[0].Fingerprint = fp[:]
+	forged, err := asn1.Marshal(parsed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := OpenEnvelopeMulti(outsider, forged); err == nil {
+		t.Error("forged recipient entry opened")
+	}
+}
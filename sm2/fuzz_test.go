@@ -0,0 +1,66 @@
+package sm2
+
+import (
+	"crypto/rand"
+	"testing"
+)
+
+// FuzzSM2Decrypt checks that the decryption functions reject malformed
+// ciphertexts with an error instead of panicking.
+func FuzzSM2Decrypt(f *testing.F) {
+	priv, err := GenerateKey(rand.Reader)
+	if err != nil {
+		f.Fatal(err)
+	}
+	msg := []byte("fuzz seed")
+	raw, err := Encrypt(&priv.PublicKey, msg, rand.Reader, C1C3C2)
+	if err != nil {
+		f.Fatal(err)
+	}
+	der, err := CipherMarshal(raw)
+	if err != nil {
+		f.Fatal(err)
+	}
+	f.Add(raw)
+	f.Add(der)
+	f.Add(raw[:96])
+	f.Add(der[:len(der)/2])
+	f.Add([]byte{})
+	f.Add([]byte{0x04})
+	f.Add([]byte{0x30, 0x00})
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		for _, mode := range []int{C1C3C2, C1C2C3} {
+			if pt, err := Decrypt(priv, data, mode); err != nil && pt != nil {
+				t.Errorf("mode %d: plaintext returned with error %v", mode, err)
+			}
+		}
+		if pt, err := DecryptAsn1(priv, data); err != nil && pt != nil {
+			t.Errorf("DecryptAsn1: plaintext returned with error %v", err)
+		}
+		if pt, err := DecryptData(priv, data); err != nil && pt != nil {
+			t.Errorf("DecryptData: plaintext returned with error %v", err)
+		}
+		CipherUnmarshal(data)
+	})
+}
+
+func TestDecryptShortCiphertext(t *testing.T) {
+	priv, err := GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, n := range []int{0, 1, 65, 96} {
+		if pt, err := Decrypt(priv, make([]byte, n), C1C3C2); err == nil || pt != nil {
+			t.Errorf("%d byte ciphertext accepted", n)
+		}
+	}
+	raw, err := Encrypt(&priv.PublicKey, []byte("message"), rand.Reader, C1C3C2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw[len(raw)-1] ^= 1
+	if pt, err := Decrypt(priv, raw, C1C3C2); err == nil || pt != nil {
+		t.Error("tampered ciphertext returned plaintext")
+	}
+}
@@ -0,0 +1,51 @@
+package sm2
+
+// Identity bundles a private key with its user ID and, optionally, the DER
+// encoded certificate issued for it, so callers do not have to pass the
+// three around separately. The certificate is kept as raw bytes because the
+// x509 package builds on sm2; parse it with x509.ParseCertificate.
+type Identity struct {
+	// Certificate is the DER encoded certificate of the key, if any.
+	Certificate []byte
+
+	priv   *PrivateKey
+	uid    []byte
+	signer *Signer
+}
+
+// NewIdentity returns an Identity for priv using uid, or the default user ID
+// when uid is empty.
+func NewIdentity(priv *PrivateKey, uid []byte) (*Identity, error) {
+	if len(uid) == 0 {
+		uid = default_uid
+	}
+	signer, err := NewSigner(priv, uid)
+	if err != nil {
+		return nil, err
+	}
+	id := &Identity{priv: priv, signer: signer}
+	id.uid = append(id.uid, uid...)
+	return id, nil
+}
+
+// Public returns the public key of the identity.
+func (id *Identity) Public() *PublicKey {
+	return &id.priv.PublicKey
+}
+
+// UID returns the user ID the identity signs with.
+func (id *Identity) UID() []byte {
+	return append([]byte(nil), id.uid...)
+}
+
+// Sign returns the ASN.1 encoded SM2 signature of data under the identity's
+// user ID.
+func (id *Identity) Sign(data []byte) ([]byte, error) {
+	return id.signer.Sign(data)
+}
+
+// Decrypt decrypts an ASN.1 encoded SM2 ciphertext addressed to the
+// identity, as produced by EncryptAsn1.
+func (id *Identity) Decrypt(ciphertext []byte) ([]byte, error) {
+	return id.priv.DecryptAsn1(ciphertext)
+}
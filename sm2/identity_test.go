@@ -0,0 +1,55 @@
+package sm2
+
+import (
+	"bytes"
+	"crypto/rand"
+	"testing"
+)
+
+func TestIdentity(t *testing.T) {
+	priv, err := GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	uid := []byte("bob@example.com")
+	id, err := NewIdentity(priv, uid)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	msg := []byte("signed by an identity")
+	sig, err := id.Sign(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, s, err := SignDataToSignDigit(sig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !Sm2Verify(id.Public(), msg, uid, r, s) {
+		t.Error("identity signature does not verify with its uid")
+	}
+	if Sm2Verify(id.Public(), msg, nil, r, s) {
+		t.Error("identity signature verifies with the default uid")
+	}
+
+	ct, err := EncryptAsn1(id.Public(), msg, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pt, err := id.Decrypt(ct)
+	if err != nil || !bytes.Equal(pt, msg) {
+		t.Errorf("identity decrypt failed: %v", err)
+	}
+
+	def, err := NewIdentity(priv, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(def.UID(), default_uid) {
+		t.Errorf("empty uid gave %q", def.UID())
+	}
+	if _, err := NewIdentity(&PrivateKey{}, uid); err == nil {
+		t.Error("invalid key accepted")
+	}
+}
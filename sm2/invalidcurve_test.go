@@ -0,0 +1,72 @@
+package sm2
+
+import (
+	"crypto/rand"
+	"math/big"
+	"testing"
+)
+
+// invalidCurvePoint returns a point on y^2 = x^3 + ax + b' for some b' != b.
+// The SM2 point formulas do not use b, so without validation such a point
+// would be multiplied on that other, possibly weak, curve.
+func invalidCurvePoint() (x, y *big.Int) {
+	params := P256Sm2().Params()
+	for {
+		x, _ = rand.Int(rand.Reader, params.P)
+		y, _ = rand.Int(rand.Reader, params.P)
+		if !P256Sm2().IsOnCurve(x, y) {
+			return x, y
+		}
+	}
+}
+
+func TestInvalidCurvePointsRejected(t *testing.T) {
+	priv, err := GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	x, y := invalidCurvePoint()
+	bad := &PublicKey{Curve: P256Sm2(), X: x, Y: y}
+	unreduced := &PublicKey{Curve: P256Sm2(), X: new(big.Int).Add(priv.X, P256Sm2().Params().P), Y: priv.Y}
+
+	for name, pub := range map[string]*PublicKey{"off curve": bad, "unreduced": unreduced} {
+		if _, err := Encrypt(pub, []byte("msg"), rand.Reader, C1C3C2); err == nil {
+			t.Errorf("%s: Encrypt accepted the public key", name)
+		}
+		r, s, _ := Sm2Sign(priv, []byte("msg"), nil, rand.Reader)
+		if Sm2Verify(pub, []byte("msg"), nil, r, s) {
+			t.Errorf("%s: Sm2Verify accepted the public key", name)
+		}
+
+		rpriv, _ := GenerateKey(rand.Reader)
+		peer, _ := GenerateKey(rand.Reader)
+		if _, _, _, err := KeyExchangeA(16, nil, nil, priv, pub, rpriv, &peer.PublicKey); err == nil {
+			t.Errorf("%s: KeyExchangeA accepted the static key", name)
+		}
+		if _, _, _, err := KeyExchangeB(16, nil, nil, priv, &peer.PublicKey, rpriv, pub); err == nil {
+			t.Errorf("%s: KeyExchangeB accepted the ephemeral key", name)
+		}
+	}
+
+	// A ciphertext whose C1 is off the curve must not be multiplied by D.
+	ct, err := Encrypt(&priv.PublicKey, []byte("msg"), rand.Reader, C1C3C2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	x.FillBytes(ct[1:33])
+	y.FillBytes(ct[33:65])
+	if _, err := Decrypt(priv, ct, C1C3C2); err != ErrInvalidC1 {
+		t.Errorf("Decrypt of an invalid C1: got %v, want ErrInvalidC1", err)
+	}
+	for i := 1; i < 65; i++ {
+		ct[i] = 0
+	}
+	if _, err := Decrypt(priv, ct, C1C3C2); err != ErrInvalidC1 {
+		t.Errorf("Decrypt of C1 at infinity: got %v, want ErrInvalidC1", err)
+	}
+	for _, n := range []int{0, 1, 96} {
+		if _, err := Decrypt(priv, ct[:n], C1C2C3); err == nil {
+			t.Errorf("Decrypt accepted a %d byte ciphertext", n)
+		}
+	}
+}
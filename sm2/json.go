@@ -0,0 +1,76 @@
+package sm2
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+)
+
+// MarshalJSON encodes pub as a JSON string holding the base64 (standard
+// encoding, padded) of its uncompressed point 0x04 || X || Y, as returned
+// by Marshal. Because PrivateKey embeds PublicKey, a *PrivateKey marshals
+// to its public key as well; D is never written.
+func (pub *PublicKey) MarshalJSON() ([]byte, error) {
+	if pub.X == nil || pub.Y == nil {
+		return nil, errors.New("SM2: cannot marshal an incomplete public key")
+	}
+	return json.Marshal(Marshal(pub))
+}
+
+// UnmarshalJSON decodes a public key written by MarshalJSON. It rejects
+// invalid base64, other point encodings and points not on the SM2 curve.
+// A JSON null leaves pub unchanged.
+func (pub *PublicKey) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		return nil
+	}
+	var point []byte
+	if err := json.Unmarshal(data, &point); err != nil {
+		return errors.New("SM2: public key is not a base64 JSON string")
+	}
+	key, err := Unmarshal(point)
+	if err != nil {
+		return err
+	}
+	*pub = *key
+	return nil
+}
+
+// Ciphertext is an ASN.1 encoded SM2 ciphertext, as returned by
+// EncryptAsn1, that marshals to JSON as a base64 string. UnmarshalJSON
+// checks that the value is a well formed GM/T 0009 ciphertext whose C1 is
+// on the curve, so handlers can reject garbage before decrypting.
+type Ciphertext []byte
+
+// MarshalJSON encodes c as a JSON base64 string.
+func (c Ciphertext) MarshalJSON() ([]byte, error) {
+	return json.Marshal([]byte(c))
+}
+
+// UnmarshalJSON decodes a base64 JSON string into c after checking its
+// structure. A JSON null leaves c unchanged.
+func (c *Ciphertext) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		return nil
+	}
+	var raw string
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return errors.New("SM2: ciphertext is not a JSON string")
+	}
+	ct, err := base64.StdEncoding.DecodeString(raw)
+	if err != nil {
+		return errors.New("SM2: ciphertext is not valid base64")
+	}
+	x, y, hash, _, err := parseCipherFields(ct)
+	if err != nil {
+		return err
+	}
+	if len(hash) != 32 {
+		return ErrCiphertextMalformed
+	}
+	if !isValidPoint(x, y) {
+		return ErrCiphertextNotOnCurve
+	}
+	*c = ct
+	return nil
+}
@@ -0,0 +1,101 @@
+package sm2
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestPublicKeyJSON(t *testing.T) {
+	priv, err := GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := json.Marshal(struct {
+		Key *PublicKey `json:"key"`
+	}{&priv.PublicKey})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"key":"` + base64.StdEncoding.EncodeToString(Marshal(&priv.PublicKey)) + `"}`
+	if string(data) != want {
+		t.Errorf("got %s, want %s", data, want)
+	}
+
+	var decoded struct {
+		Key *PublicKey `json:"key"`
+	}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	k := decoded.Key
+	if k.X.Cmp(priv.X) != 0 || k.Y.Cmp(priv.Y) != 0 || k.Curve != P256Sm2() {
+		t.Error("public key changed in a JSON round trip")
+	}
+
+	privJSON, err := json.Marshal(priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(privJSON), priv.D.String()) || !bytes.Contains([]byte(want), privJSON) {
+		t.Errorf("private key marshals to %s", privJSON)
+	}
+
+	offCurve := Marshal(&priv.PublicKey)
+	offCurve[64] ^= 1
+	for _, bad := range []string{
+		`"not base64!"`,
+		`"` + base64.StdEncoding.EncodeToString(offCurve) + `"`,
+		`"` + base64.StdEncoding.EncodeToString(Compress(&priv.PublicKey)) + `"`,
+		`{"x":1}`,
+		`""`,
+	} {
+		var pub PublicKey
+		if err := json.Unmarshal([]byte(bad), &pub); err == nil {
+			t.Errorf("%s accepted", bad)
+		}
+	}
+	if _, err := json.Marshal(&PublicKey{}); err == nil {
+		t.Error("empty public key marshaled")
+	}
+}
+
+func TestCiphertextJSON(t *testing.T) {
+	priv, err := GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ct, err := EncryptAsn1(&priv.PublicKey, []byte("json payload"), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := json.Marshal(Ciphertext(ct))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded Ciphertext
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(decoded, ct) {
+		t.Error("ciphertext changed in a JSON round trip")
+	}
+	if pt, err := DecryptAsn1(priv, decoded); err != nil || string(pt) != "json payload" {
+		t.Errorf("decoded ciphertext does not decrypt: %v", err)
+	}
+
+	for _, bad := range []string{
+		`"%%%"`,
+		`"` + base64.StdEncoding.EncodeToString([]byte("not asn1")) + `"`,
+		`"` + base64.StdEncoding.EncodeToString(ct[:len(ct)-1]) + `"`,
+		`42`,
+	} {
+		var c Ciphertext
+		if err := json.Unmarshal([]byte(bad), &c); err == nil {
+			t.Errorf("%s accepted", bad)
+		}
+	}
+}
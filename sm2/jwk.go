@@ -0,0 +1,124 @@
+package sm2
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+)
+
+// jwkCurve is the "crv" value used for SM2 keys in JWKs.
+const jwkCurve = "SM2P256"
+
+// jwk is an elliptic curve JSON Web Key (RFC 7517, RFC 7518 section 6.2).
+type jwk struct {
+	Kty string `json:"kty"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+	D   string `json:"d,omitempty"`
+}
+
+// ToJWK encodes pub as an EC JSON Web Key with "crv":"SM2P256" and the
+// coordinates as 32 byte base64url strings without padding.
+func ToJWK(pub *PublicKey) ([]byte, error) {
+	k, err := newJWK(pub)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(k)
+}
+
+// PrivateKeyToJWK is like ToJWK but also writes the private scalar as "d".
+func PrivateKeyToJWK(priv *PrivateKey) ([]byte, error) {
+	if priv.D == nil {
+		return nil, errors.New("SM2: cannot marshal an incomplete private key")
+	}
+	k, err := newJWK(&priv.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+	k.D = jwkInt(priv.D)
+	return json.Marshal(k)
+}
+
+// FromJWK parses a public key written by ToJWK. It rejects other key types
+// and curves, coordinates that are not 32 bytes and points not on the SM2
+// curve. A "d" member, if present, is ignored.
+func FromJWK(data []byte) (*PublicKey, error) {
+	var k jwk
+	if err := json.Unmarshal(data, &k); err != nil {
+		return nil, errors.New("SM2: malformed JWK")
+	}
+	return k.publicKey()
+}
+
+// PrivateKeyFromJWK parses a private key written by PrivateKeyToJWK and
+// checks that "d" is in range and matches the public point.
+func PrivateKeyFromJWK(data []byte) (*PrivateKey, error) {
+	var k jwk
+	if err := json.Unmarshal(data, &k); err != nil {
+		return nil, errors.New("SM2: malformed JWK")
+	}
+	pub, err := k.publicKey()
+	if err != nil {
+		return nil, err
+	}
+	if k.D == "" {
+		return nil, errors.New("SM2: JWK has no private key")
+	}
+	d, err := parseJWKInt(k.D)
+	if err != nil {
+		return nil, err
+	}
+	priv := &PrivateKey{PublicKey: *pub, D: d}
+	if err := priv.Validate(); err != nil {
+		return nil, err
+	}
+	return priv, nil
+}
+
+func newJWK(pub *PublicKey) (*jwk, error) {
+	if pub.X == nil || pub.Y == nil {
+		return nil, errors.New("SM2: cannot marshal an incomplete public key")
+	}
+	return &jwk{Kty: "EC", Crv: jwkCurve, X: jwkInt(pub.X), Y: jwkInt(pub.Y)}, nil
+}
+
+func (k *jwk) publicKey() (*PublicKey, error) {
+	if k.Kty != "EC" {
+		return nil, errors.New("SM2: JWK key type is not EC")
+	}
+	if k.Crv != jwkCurve {
+		return nil, errors.New("SM2: unsupported JWK curve " + k.Crv)
+	}
+	x, err := parseJWKInt(k.X)
+	if err != nil {
+		return nil, err
+	}
+	y, err := parseJWKInt(k.Y)
+	if err != nil {
+		return nil, err
+	}
+	if !isValidPoint(x, y) {
+		return nil, errors.New("SM2: point is not on curve")
+	}
+	return &PublicKey{Curve: P256Sm2(), X: x, Y: y}, nil
+}
+
+// jwkInt encodes x as a 32 byte big-endian base64url string.
+func jwkInt(x *big.Int) string {
+	var buf [32]byte
+	putFixedBytes(buf[:], x)
+	return base64.RawURLEncoding.EncodeToString(buf[:])
+}
+
+// parseJWKInt decodes a value written by jwkInt. RFC 7518 requires the
+// full field width, so shorter or longer values are rejected.
+func parseJWKInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil || len(b) != 32 {
+		return nil, errors.New("SM2: JWK coordinate is not a 32 byte base64url value")
+	}
+	return new(big.Int).SetBytes(b), nil
+}
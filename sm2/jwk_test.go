@@ -0,0 +1,88 @@
+package sm2
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"strings"
+	"testing"
+)
+
+func TestJWK(t *testing.T) {
+	priv, err := GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := ToJWK(&priv.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var fields map[string]string
+	if err := json.Unmarshal(data, &fields); err != nil {
+		t.Fatal(err)
+	}
+	point := Marshal(&priv.PublicKey)
+	if fields["kty"] != "EC" || fields["crv"] != "SM2P256" ||
+		fields["x"] != base64.RawURLEncoding.EncodeToString(point[1:33]) ||
+		fields["y"] != base64.RawURLEncoding.EncodeToString(point[33:]) || len(fields) != 4 {
+		t.Errorf("unexpected JWK %s", data)
+	}
+	pub, err := FromJWK(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pub.X.Cmp(priv.X) != 0 || pub.Y.Cmp(priv.Y) != 0 || pub.Curve != P256Sm2() {
+		t.Error("public key changed in a JWK round trip")
+	}
+	if _, err := PrivateKeyFromJWK(data); err == nil {
+		t.Error("public JWK parsed as a private key")
+	}
+
+	// d = 1 checks that the scalar is written at full width.
+	one := &PrivateKey{D: big.NewInt(1)}
+	one.Curve = P256Sm2()
+	one.X, one.Y = Generator()
+	data, err = PrivateKeyToJWK(one)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `"d":"` + base64.RawURLEncoding.EncodeToString(append(make([]byte, 31), 1)) + `"`; !strings.Contains(string(data), want) {
+		t.Errorf("got %s, want it to contain %s", data, want)
+	}
+	got, err := PrivateKeyFromJWK(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.D.Cmp(one.D) != 0 || got.X.Cmp(one.X) != 0 {
+		t.Error("private key changed in a JWK round trip")
+	}
+	if pub, err := FromJWK(data); err != nil || pub.X.Cmp(one.X) != 0 {
+		t.Errorf("FromJWK on a private JWK: %v", err)
+	}
+
+	x := base64.RawURLEncoding.EncodeToString(point[1:33])
+	y := base64.RawURLEncoding.EncodeToString(point[33:])
+	badY := append([]byte(nil), point[33:]...)
+	badY[31] ^= 1
+	for _, bad := range []string{
+		`{"kty":"EC","crv":"P-256","x":"` + x + `","y":"` + y + `"}`,
+		`{"kty":"OKP","crv":"SM2P256","x":"` + x + `","y":"` + y + `"}`,
+		`{"kty":"EC","crv":"SM2P256","x":"` + x + `","y":"` + base64.RawURLEncoding.EncodeToString(badY) + `"}`,
+		`{"kty":"EC","crv":"SM2P256","x":"` + x[1:] + `","y":"` + y + `"}`,
+		`{"kty":"EC","crv":"SM2P256","x":"` + x + `=","y":"` + y + `"}`,
+		`{"kty":"EC","crv":"SM2P256","x":"` + x + `"}`,
+		`[]`,
+	} {
+		if _, err := FromJWK([]byte(bad)); err == nil {
+			t.Errorf("%s accepted", bad)
+		}
+	}
+
+	// A d that does not match the public point.
+	mismatch := `{"kty":"EC","crv":"SM2P256","x":"` + x + `","y":"` + y + `","d":"` +
+		base64.RawURLEncoding.EncodeToString(append(make([]byte, 31), 1)) + `"}`
+	if _, err := PrivateKeyFromJWK([]byte(mismatch)); err != ErrPublicKeyMismatch {
+		t.Errorf("mismatched d: got %v, want ErrPublicKeyMismatch", err)
+	}
+}
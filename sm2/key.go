@@ -0,0 +1,34 @@
+package sm2
+
+import (
+	"errors"
+	"math/big"
+)
+
+// NewPrivateKey returns the private key with scalar d and computes its
+// public point, for importing keys from other systems or using fixed keys
+// in tests. d must be in [1, n-2]: GM/T 0003 also excludes n-1, for which
+// signing would need the inverse of 1+d = n. d is copied.
+func NewPrivateKey(d *big.Int) (*PrivateKey, error) {
+	if d == nil {
+		return nil, ErrPrivateKeyOutOfRange
+	}
+	n := P256Sm2().Params().N
+	if d.Sign() <= 0 || d.Cmp(new(big.Int).Sub(n, one)) >= 0 {
+		return nil, ErrPrivateKeyOutOfRange
+	}
+	priv := new(PrivateKey)
+	priv.PublicKey.Curve = P256Sm2()
+	priv.D = new(big.Int).Set(d)
+	priv.PublicKey.X, priv.PublicKey.Y = priv.Curve.ScalarBaseMult(d.Bytes())
+	return priv, nil
+}
+
+// NewPrivateKeyFromBytes is like NewPrivateKey for d given as a big-endian
+// integer of at most 32 bytes.
+func NewPrivateKeyFromBytes(d []byte) (*PrivateKey, error) {
+	if len(d) > 32 {
+		return nil, errors.New("SM2: private key longer than 32 bytes")
+	}
+	return NewPrivateKey(new(big.Int).SetBytes(d))
+}
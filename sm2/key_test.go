@@ -0,0 +1,66 @@
+package sm2
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"math/big"
+	"testing"
+)
+
+func TestNewPrivateKey(t *testing.T) {
+	// The example key pair of GM/T 0003.5 appendix A.
+	d, _ := hex.DecodeString("3945208f7b2144b13f36e38ac6d39f95889393692860b51a42fb81ef4df7c5b8")
+	wantX, _ := new(big.Int).SetString("09f9df311e5421a150dd7d161e4bc5c672179fad1833fc076bb08ff356f35020", 16)
+	wantY, _ := new(big.Int).SetString("ccea490ce26775a52dc6ea718cc1aa600aed05fbf35e084a6632f6072da9ad13", 16)
+	priv, err := NewPrivateKeyFromBytes(d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if priv.X.Cmp(wantX) != 0 || priv.Y.Cmp(wantY) != 0 {
+		t.Fatal("wrong public key")
+	}
+	if err := priv.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Keys from GenerateKey round trip through their scalar.
+	gen, err := GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	priv, err = NewPrivateKey(gen.D)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if priv.X.Cmp(gen.X) != 0 || priv.Y.Cmp(gen.Y) != 0 {
+		t.Error("public key differs from GenerateKey")
+	}
+	if priv.D == gen.D {
+		t.Error("d was not copied")
+	}
+}
+
+func TestNewPrivateKeyOutOfRange(t *testing.T) {
+	n := P256Sm2().Params().N
+	for _, d := range []*big.Int{
+		nil,
+		big.NewInt(0),
+		big.NewInt(-1),
+		new(big.Int).Sub(n, one),
+		n,
+		new(big.Int).Add(n, one),
+	} {
+		if _, err := NewPrivateKey(d); err != ErrPrivateKeyOutOfRange {
+			t.Errorf("d = %v: got %v", d, err)
+		}
+	}
+	if _, err := NewPrivateKeyFromBytes(make([]byte, 33)); err == nil {
+		t.Error("33 byte scalar accepted")
+	}
+	if _, err := NewPrivateKeyFromBytes(nil); err != ErrPrivateKeyOutOfRange {
+		t.Errorf("empty scalar: got %v", err)
+	}
+	if _, err := NewPrivateKeyFromBytes([]byte{1}); err != nil {
+		t.Errorf("d = 1: %v", err)
+	}
+}
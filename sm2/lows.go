@@ -0,0 +1,67 @@
+package sm2
+
+import (
+	"crypto/rand"
+	"errors"
+	"math/big"
+)
+
+// halfN is floor(n/2), the largest s accepted as low-S.
+var halfN = new(big.Int).Rsh(P256Sm2().Params().N, 1)
+
+// ErrHighS is returned by NormalizeS for a signature with s > n/2.
+//
+// Unlike ECDSA, SM2 verification uses t = r + s, so replacing s with n - s
+// does not yield another valid signature: SM2 signatures are not malleable
+// in s, and a high-S signature can only be made low-S by signing again.
+var ErrHighS = errors.New("SM2: high-S signature cannot be normalized without the private key")
+
+// maxLowSAttempts bounds the re-signing in SignDataLowS. Each attempt
+// yields a low-S signature with probability 1/2.
+const maxLowSAttempts = 64
+
+func isLowS(s *big.Int) bool {
+	return s.Cmp(halfN) <= 0
+}
+
+// NormalizeS re-encodes a low-S signature (s <= n/2) as DER and returns
+// ErrHighS for any other signature. See ErrHighS for why s is not
+// replaced with n - s.
+func NormalizeS(signature []byte) ([]byte, error) {
+	r, s, err := SignDataToSignDigit(signature)
+	if err != nil {
+		return nil, err
+	}
+	if r.Sign() <= 0 || s.Sign() <= 0 {
+		return nil, errors.New("SM2: invalid signature values")
+	}
+	if !isLowS(s) {
+		return nil, ErrHighS
+	}
+	return SignDigitToSignData(r, s)
+}
+
+// SignDataLowS is like SignData but only returns signatures with s <= n/2,
+// signing again with a fresh ephemeral key when needed.
+func SignDataLowS(priv *PrivateKey, data []byte) ([]byte, error) {
+	for i := 0; i < maxLowSAttempts; i++ {
+		r, s, err := Sm2Sign(priv, data, nil, rand.Reader)
+		if err != nil {
+			return nil, err
+		}
+		if isLowS(s) {
+			return SignDigitToSignData(r, s)
+		}
+	}
+	return nil, errors.New("SM2: failed to produce a low-S signature")
+}
+
+// VerifySignatureStrict is like VerifySignature but also rejects
+// signatures with s > n/2, for protocols requiring a canonical form.
+func VerifySignatureStrict(pub *PublicKey, data, signature []byte) bool {
+	_, s, err := SignDataToSignDigit(signature)
+	if err != nil || !isLowS(s) {
+		return false
+	}
+	return VerifySignature(pub, data, signature)
+}
@@ -0,0 +1,61 @@
+package sm2
+
+import (
+	"bytes"
+	"crypto/rand"
+	"math/big"
+	"testing"
+)
+
+func TestLowS(t *testing.T) {
+	priv, err := GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg := []byte("low-S message")
+	n := P256Sm2().Params().N
+
+	var high []byte
+	for i := 0; i < 8; i++ {
+		sig, err := SignDataLowS(priv, msg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, s, _ := SignDataToSignDigit(sig)
+		if s.Cmp(halfN) > 0 {
+			t.Fatal("SignDataLowS returned a high-S signature")
+		}
+		if !VerifySignatureStrict(&priv.PublicKey, msg, sig) {
+			t.Fatal("low-S signature rejected by VerifySignatureStrict")
+		}
+		if norm, err := NormalizeS(sig); err != nil || !bytes.Equal(norm, sig) {
+			t.Fatalf("NormalizeS changed a low-S signature: %v", err)
+		}
+	}
+	for high == nil {
+		sig, err := SignData(priv, msg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, s, _ := SignDataToSignDigit(sig); s.Cmp(halfN) > 0 {
+			high = sig
+		}
+	}
+
+	if !VerifySignature(&priv.PublicKey, msg, high) {
+		t.Fatal("high-S signature does not verify")
+	}
+	if VerifySignatureStrict(&priv.PublicKey, msg, high) {
+		t.Error("VerifySignatureStrict accepted a high-S signature")
+	}
+	if _, err := NormalizeS(high); err != ErrHighS {
+		t.Errorf("NormalizeS on high-S: got %v, want ErrHighS", err)
+	}
+
+	// Flipping s does not produce a second valid SM2 signature.
+	r, s, _ := SignDataToSignDigit(high)
+	flipped, _ := SignDigitToSignData(r, new(big.Int).Sub(n, s))
+	if VerifySignature(&priv.PublicKey, msg, flipped) {
+		t.Error("signature with s replaced by n - s verifies")
+	}
+}
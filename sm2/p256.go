@@ -85,6 +85,97 @@ func P256Sm2() elliptic.Curve {
 	return sm2P256
 }
 
+// Order returns a copy of n, the order of the SM2 base point.
+func Order() *big.Int {
+	return new(big.Int).Set(P256Sm2().Params().N)
+}
+
+// Generator returns copies of the coordinates of the SM2 base point G.
+func Generator() (x, y *big.Int) {
+	params := P256Sm2().Params()
+	return new(big.Int).Set(params.Gx), new(big.Int).Set(params.Gy)
+}
+
+// ScalarBaseMult returns k*G on the SM2 curve, where k is a big-endian
+// integer reduced modulo n. It uses the fixed comb table of G with
+// constant-time table lookups, but reducing k >= n and converting the result
+// to affine coordinates use math/big, so it is not guaranteed to be constant
+// time.
+func ScalarBaseMult(k []byte) (x, y *big.Int) {
+	return scalarMultChecked(nil, nil, k)
+}
+
+// ScalarMult returns k*(x1, y1) on the SM2 curve, where k is a big-endian
+// integer. (0, 0) stands for the point at infinity, in the arguments and
+// the result. It panics if (x1, y1) is any other point not on the curve,
+// like crypto/elliptic.
+//
+// ScalarMult is not constant time: it walks a wNAF representation of k,
+// doing work that depends on its digits, so it must not be used with
+// secret scalars where an attacker can measure timing.
+func ScalarMult(x1, y1 *big.Int, k []byte) (x, y *big.Int) {
+	if !isValidOrInfinity(x1, y1) {
+		panic("SM2: ScalarMult was called on an invalid point")
+	}
+	if x1.Sign() == 0 && y1.Sign() == 0 {
+		return new(big.Int), new(big.Int)
+	}
+	return scalarMultChecked(x1, y1, k)
+}
+
+// scalarMultChecked computes k*(x1, y1), or k*G if x1 is nil, returning
+// (0, 0) when the result is the point at infinity.
+func scalarMultChecked(x1, y1 *big.Int, k []byte) (x, y *big.Int) {
+	var X, Y, Z sm2P256FieldElement
+	if x1 == nil {
+		var scalar [32]byte
+		sm2P256GetScalar(&scalar, k)
+		sm2P256ScalarBaseMult(&X, &Y, &Z, &scalar)
+	} else {
+		var X1, Y1 sm2P256FieldElement
+		sm2P256FromBig(&X1, x1)
+		sm2P256FromBig(&Y1, y1)
+		sm2P256ScalarMult(&X, &Y, &Z, &X1, &Y1, WNafReversed(sm2GenrateWNaf(k)))
+	}
+	if sm2P256IsZero(&Z) {
+		return new(big.Int), new(big.Int)
+	}
+	return sm2P256ToAffine(&X, &Y, &Z)
+}
+
+// isValidOrInfinity reports whether (x, y) is on the curve or is (0, 0).
+func isValidOrInfinity(x, y *big.Int) bool {
+	if x == nil || y == nil {
+		return false
+	}
+	return x.Sign() == 0 && y.Sign() == 0 || isValidPoint(x, y)
+}
+
+// Add returns (x1, y1) + (x2, y2) on the SM2 curve, with (0, 0) standing
+// for the point at infinity. It panics if either argument is any other
+// point not on the curve. Add is not constant time.
+func Add(x1, y1, x2, y2 *big.Int) (x, y *big.Int) {
+	if !isValidOrInfinity(x1, y1) || !isValidOrInfinity(x2, y2) {
+		panic("SM2: Add was called on an invalid point")
+	}
+	var X1, Y1, Z1, X2, Y2, Z2, X3, Y3, Z3 sm2P256FieldElement
+	if x1.Sign() != 0 || y1.Sign() != 0 {
+		Z1 = sm2P256Factor[1]
+	}
+	if x2.Sign() != 0 || y2.Sign() != 0 {
+		Z2 = sm2P256Factor[1]
+	}
+	sm2P256FromBig(&X1, x1)
+	sm2P256FromBig(&Y1, y1)
+	sm2P256FromBig(&X2, x2)
+	sm2P256FromBig(&Y2, y2)
+	sm2P256PointAdd(&X1, &Y1, &Z1, &X2, &Y2, &Z2, &X3, &Y3, &Z3)
+	if sm2P256IsZero(&Z3) {
+		return new(big.Int), new(big.Int)
+	}
+	return sm2P256ToAffine(&X3, &Y3, &Z3)
+}
+
 func (curve sm2P256Curve) Params() *elliptic.CurveParams {
 	return sm2P256.CurveParams
 }
@@ -150,6 +241,54 @@ func (curve sm2P256Curve) ScalarMult(x1, y1 *big.Int, k []byte) (*big.Int, *big.
 	return sm2P256ToAffine(&X, &Y, &Z)
 }
 
+// scalarBaseMultInto computes k*G like ScalarBaseMult but stores the affine
+// coordinates in x and y, reusing their storage.
+func scalarBaseMultInto(x, y *big.Int, k []byte) {
+	var scalarReversed [32]byte
+	var X, Y, Z, xx, yy sm2P256FieldElement
+
+	sm2P256GetScalar(&scalarReversed, k)
+	sm2P256ScalarBaseMult(&X, &Y, &Z, &scalarReversed)
+	sm2P256PointToAffine(&xx, &yy, &X, &Y, &Z)
+	sm2P256ToBigInto(x, &xx)
+	sm2P256ToBigInto(y, &yy)
+}
+
+// scalarMultInto computes k*(x1, y1) like ScalarMult but stores the affine
+// coordinates in x and y, reusing their storage.
+func scalarMultInto(x, y, x1, y1 *big.Int, k []byte) {
+	var X, Y, Z, X1, Y1, xx, yy sm2P256FieldElement
+	sm2P256FromBig(&X1, x1)
+	sm2P256FromBig(&Y1, y1)
+	scalar := sm2GenrateWNaf(k)
+	scalarReversed := WNafReversed(scalar)
+	sm2P256ScalarMult(&X, &Y, &Z, &X1, &Y1, scalarReversed)
+	sm2P256PointToAffine(&xx, &yy, &X, &Y, &Z)
+	sm2P256ToBigInto(x, &xx)
+	sm2P256ToBigInto(y, &yy)
+}
+
+// addInto computes (x1, y1) + (x2, y2) like Add but stores the affine
+// coordinates in x and y, reusing their storage.
+func addInto(x, y, x1, y1, x2, y2 *big.Int) {
+	var X1, Y1, Z1, X2, Y2, Z2, X3, Y3, Z3, xx, yy sm2P256FieldElement
+
+	if x1.Sign() != 0 || y1.Sign() != 0 {
+		Z1 = sm2P256Factor[1]
+	}
+	if x2.Sign() != 0 || y2.Sign() != 0 {
+		Z2 = sm2P256Factor[1]
+	}
+	sm2P256FromBig(&X1, x1)
+	sm2P256FromBig(&Y1, y1)
+	sm2P256FromBig(&X2, x2)
+	sm2P256FromBig(&Y2, y2)
+	sm2P256PointAdd(&X1, &Y1, &Z1, &X2, &Y2, &Z2, &X3, &Y3, &Z3)
+	sm2P256PointToAffine(&xx, &yy, &X3, &Y3, &Z3)
+	sm2P256ToBigInto(x, &xx)
+	sm2P256ToBigInto(y, &yy)
+}
+
 func (curve sm2P256Curve) ScalarBaseMult(k []byte) (*big.Int, *big.Int) {
 	var scalarReversed [32]byte
 	var X, Y, Z sm2P256FieldElement
@@ -347,6 +486,13 @@ func sm2P256GetBit(scalar *[32]uint8, bit uint) uint32 {
 // little-endian number. Note that the value of scalar must be less than the
 // order of the group.
 func sm2P256ScalarBaseMult(xOut, yOut, zOut *sm2P256FieldElement, scalar *[32]uint8) {
+	sm2P256ScalarMultComb(xOut, yOut, zOut, scalar, sm2P256Precomputed[:])
+}
+
+// sm2P256ScalarMultComb sets {xOut,yOut,zOut} = scalar*P, where table holds
+// the multiples of P in the layout of sm2P256Precomputed, which is the table
+// for G. scalar is little-endian and must be less than the group order.
+func sm2P256ScalarMultComb(xOut, yOut, zOut *sm2P256FieldElement, scalar *[32]uint8, table []uint32) {
 	nIsInfinityMask := ^uint32(0)
 	var px, py, tx, ty, tz sm2P256FieldElement
 	var pIsNoninfiniteMask, mask, tableOffset uint32
@@ -375,7 +521,7 @@ func sm2P256ScalarBaseMult(xOut, yOut, zOut *sm2P256FieldElement, scalar *[32]ui
 			bit3 := sm2P256GetBit(scalar, 223-i+j)
 			index := bit0 | (bit1 << 1) | (bit2 << 2) | (bit3 << 3)
 
-			sm2P256SelectAffinePoint(&px, &py, sm2P256Precomputed[tableOffset:], index)
+			sm2P256SelectAffinePoint(&px, &py, table[tableOffset:], index)
 			tableOffset += 30 * 9
 
 			// Since scalar is less than the order of the group, we know that
@@ -443,14 +589,14 @@ func sm2P256Scalar(b *sm2P256FieldElement, a int) {
 func sm2P256PointAdd(x1, y1, z1, x2, y2, z2, x3, y3, z3 *sm2P256FieldElement) {
 	var u1, u2, z22, z12, z23, z13, s1, s2, h, h2, r, r2, tm sm2P256FieldElement
 
-	if sm2P256ToBig(z1).Sign() == 0 {
+	if sm2P256IsZero(z1) {
 		sm2P256Dup(x3, x2)
 		sm2P256Dup(y3, y2)
 		sm2P256Dup(z3, z2)
 		return
 	}
 
-	if sm2P256ToBig(z2).Sign() == 0 {
+	if sm2P256IsZero(z2) {
 		sm2P256Dup(x3, x1)
 		sm2P256Dup(y3, y1)
 		sm2P256Dup(z3, z1)
@@ -469,9 +615,10 @@ func sm2P256PointAdd(x1, y1, z1, x2, y2, z2, x3, y3, z3 *sm2P256FieldElement) {
 	sm2P256Mul(&s1, y1, &z23) // s1 = y1 * z2 ^ 3
 	sm2P256Mul(&s2, y2, &z13) // s2 = y2 * z1 ^ 3
 
-	if sm2P256ToBig(&u1).Cmp(sm2P256ToBig(&u2)) == 0 &&
-		sm2P256ToBig(&s1).Cmp(sm2P256ToBig(&s2)) == 0 {
-		sm2P256PointDouble(x1, y1, z1, x1, y1, z1)
+	if sm2P256Equal(&u1, &u2) && sm2P256Equal(&s1, &s2) {
+		// The addition formulas fail for equal points.
+		sm2P256PointDouble(x3, y3, z3, x1, y1, z1)
+		return
 	}
 
 	sm2P256Sub(&h, &u2, &u1) // h = u2 - u1
@@ -500,19 +647,16 @@ func sm2P256PointAdd(x1, y1, z1, x2, y2, z2, x3, y3, z3 *sm2P256FieldElement) {
 // (x3, y3, z3) = (x1, y1, z1)- (x2, y2, z2)
 func sm2P256PointSub(x1, y1, z1, x2, y2, z2, x3, y3, z3 *sm2P256FieldElement) {
 	var u1, u2, z22, z12, z23, z13, s1, s2, h, h2, r, r2, tm sm2P256FieldElement
-	y:=sm2P256ToBig(y2)
-	zero:=new(big.Int).SetInt64(0)
-	y.Sub(zero,y)
-	sm2P256FromBig(y2,y)
+	sm2P256Negate(y2)
 
-	if sm2P256ToBig(z1).Sign() == 0 {
+	if sm2P256IsZero(z1) {
 		sm2P256Dup(x3, x2)
 		sm2P256Dup(y3, y2)
 		sm2P256Dup(z3, z2)
 		return
 	}
 
-	if sm2P256ToBig(z2).Sign() == 0 {
+	if sm2P256IsZero(z2) {
 		sm2P256Dup(x3, x1)
 		sm2P256Dup(y3, y1)
 		sm2P256Dup(z3, z1)
@@ -531,9 +675,10 @@ func sm2P256PointSub(x1, y1, z1, x2, y2, z2, x3, y3, z3 *sm2P256FieldElement) {
 	sm2P256Mul(&s1, y1, &z23) // s1 = y1 * z2 ^ 3
 	sm2P256Mul(&s2, y2, &z13) // s2 = y2 * z1 ^ 3
 
-	if sm2P256ToBig(&u1).Cmp(sm2P256ToBig(&u2)) == 0 &&
-		sm2P256ToBig(&s1).Cmp(sm2P256ToBig(&s2)) == 0 {
-		sm2P256PointDouble(x1, y1, z1, x1, y1, z1)
+	if sm2P256Equal(&u1, &u2) && sm2P256Equal(&s1, &s2) {
+		// The addition formulas fail for equal points.
+		sm2P256PointDouble(x3, y3, z3, x1, y1, z1)
+		return
 	}
 
 	sm2P256Sub(&h, &u2, &u1) // h = u2 - u1
@@ -1014,10 +1159,30 @@ func sm2P256Dup(b, a *sm2P256FieldElement) {
 	*b = *a
 }
 
+// sm2P256Scratch holds the big.Int buffers used to convert between field
+// elements and big.Int. Conversions happen several times per point
+// addition, so the buffers are pooled instead of allocated per call.
+type sm2P256Scratch struct {
+	v, small, prod, q, a, b big.Int
+}
+
+var sm2P256ScratchPool = sync.Pool{
+	New: func() interface{} {
+		return new(sm2P256Scratch)
+	},
+}
+
 // X = a * R mod P
 func sm2P256FromBig(X *sm2P256FieldElement, a *big.Int) {
-	x := new(big.Int).Lsh(a, 257)
-	x.Mod(x, sm2P256.P)
+	sc := sm2P256ScratchPool.Get().(*sm2P256Scratch)
+	defer sm2P256ScratchPool.Put(sc)
+
+	sc.prod.Lsh(a, 257)
+	sc.q.QuoRem(&sc.prod, sm2P256.P, &sc.v)
+	x := &sc.v
+	if x.Sign() < 0 {
+		x.Add(x, sm2P256.P)
+	}
 	for i := 0; i < 9; i++ {
 		if bits := x.Bits(); len(bits) > 0 {
 			X[i] = uint32(bits[0]) & bottom29Bits
@@ -1041,21 +1206,60 @@ func sm2P256FromBig(X *sm2P256FieldElement, a *big.Int) {
 // X = r * R mod P
 // r = X * R' mod P
 func sm2P256ToBig(X *sm2P256FieldElement) *big.Int {
-	r, tm := new(big.Int), new(big.Int)
-	r.SetInt64(int64(X[8]))
+	return sm2P256ToBigInto(new(big.Int), X)
+}
+
+// sm2P256ToBigInto is sm2P256ToBig writing the result into r.
+func sm2P256ToBigInto(r *big.Int, X *sm2P256FieldElement) *big.Int {
+	sc := sm2P256ScratchPool.Get().(*sm2P256Scratch)
+	defer sm2P256ScratchPool.Put(sc)
+	return sc.toBig(r, X)
+}
+
+// sm2P256Unit is the raw field element 1, so that multiplying by it leaves
+// the Montgomery domain.
+var sm2P256Unit = sm2P256FieldElement{1}
+
+// toBig leaves the Montgomery domain with a field multiplication and
+// reduces the result with subtractions, avoiding a big.Int division.
+func (sc *sm2P256Scratch) toBig(r *big.Int, X *sm2P256FieldElement) *big.Int {
+	var t sm2P256FieldElement
+	sm2P256Mul(&t, X, &sm2P256Unit) // t = X * R' mod P
+	r.SetInt64(int64(t[8]))
 	for i := 7; i >= 0; i-- {
 		if (i & 1) == 0 {
 			r.Lsh(r, 29)
 		} else {
 			r.Lsh(r, 28)
 		}
-		tm.SetInt64(int64(X[i]))
-		r.Add(r, tm)
+		sc.small.SetInt64(int64(t[i]))
+		r.Add(r, &sc.small)
+	}
+	for r.Cmp(sm2P256.P) >= 0 {
+		r.Sub(r, sm2P256.P)
 	}
-	r.Mul(r, sm2P256.RInverse)
-	r.Mod(r, sm2P256.P)
 	return r
 }
+
+// sm2P256IsZero reports whether a is zero mod P.
+func sm2P256IsZero(a *sm2P256FieldElement) bool {
+	sc := sm2P256ScratchPool.Get().(*sm2P256Scratch)
+	defer sm2P256ScratchPool.Put(sc)
+	return sc.toBig(&sc.a, a).Sign() == 0
+}
+
+// sm2P256Equal reports whether a and b are equal mod P.
+func sm2P256Equal(a, b *sm2P256FieldElement) bool {
+	sc := sm2P256ScratchPool.Get().(*sm2P256Scratch)
+	defer sm2P256ScratchPool.Put(sc)
+	return sc.toBig(&sc.a, a).Cmp(sc.toBig(&sc.b, b)) == 0
+}
+
+// sm2P256Negate sets a to -a mod P.
+func sm2P256Negate(a *sm2P256FieldElement) {
+	var zero sm2P256FieldElement
+	sm2P256Sub(a, &zero, a)
+}
 func WNafReversed(wnaf []int8) []int8 {
 	wnafRev := make([]int8, len(wnaf), len(wnaf))
 	for i, v := range wnaf {
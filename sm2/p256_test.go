@@ -0,0 +1,74 @@
+package sm2
+
+import (
+	"crypto/rand"
+	"math/big"
+	"testing"
+)
+
+func TestGroupOperations(t *testing.T) {
+	c := P256Sm2()
+	gx, gy := Generator()
+	isInfinity := func(x, y *big.Int) bool { return x.Sign() == 0 && y.Sign() == 0 }
+
+	dx, dy := c.Double(gx, gy)
+	if x, y := Add(gx, gy, gx, gy); x.Cmp(dx) != 0 || y.Cmp(dy) != 0 {
+		t.Error("G + G != 2G")
+	}
+	if x, y := c.Add(gx, gy, gx, gy); x.Cmp(dx) != 0 || y.Cmp(dy) != 0 {
+		t.Error("curve Add: G + G != 2G")
+	}
+	negY := new(big.Int).Sub(c.Params().P, gy)
+	if x, y := Add(gx, gy, gx, negY); !isInfinity(x, y) {
+		t.Error("G + (-G) is not the point at infinity")
+	}
+	if x, y := Add(new(big.Int), new(big.Int), gx, gy); x.Cmp(gx) != 0 || y.Cmp(gy) != 0 {
+		t.Error("O + G != G")
+	}
+
+	for i := 0; i < 10; i++ {
+		a, _ := rand.Int(rand.Reader, Order())
+		b, _ := rand.Int(rand.Reader, Order())
+		ax, ay := ScalarBaseMult(a.Bytes())
+		if x, y := ScalarMult(gx, gy, a.Bytes()); x.Cmp(ax) != 0 || y.Cmp(ay) != 0 {
+			t.Fatal("ScalarMult(G, a) != ScalarBaseMult(a)")
+		}
+		bx, by := ScalarBaseMult(b.Bytes())
+		sum := new(big.Int).Add(a, b)
+		sx, sy := ScalarBaseMult(sum.Bytes())
+		if x, y := Add(ax, ay, bx, by); x.Cmp(sx) != 0 || y.Cmp(sy) != 0 {
+			t.Fatal("aG + bG != (a+b)G")
+		}
+		// b*(a*G) == a*(b*G)
+		x1, y1 := ScalarMult(ax, ay, b.Bytes())
+		x2, y2 := ScalarMult(bx, by, a.Bytes())
+		if x1.Cmp(x2) != 0 || y1.Cmp(y2) != 0 {
+			t.Fatal("b(aG) != a(bG)")
+		}
+	}
+
+	for _, k := range [][]byte{nil, {0}, Order().Bytes()} {
+		if x, y := ScalarMult(gx, gy, k); !isInfinity(x, y) {
+			t.Errorf("ScalarMult(G, %x) is not the point at infinity", k)
+		}
+		if x, y := ScalarBaseMult(k); !isInfinity(x, y) {
+			t.Errorf("ScalarBaseMult(%x) is not the point at infinity", k)
+		}
+	}
+	if x, y := ScalarMult(new(big.Int), new(big.Int), []byte{5}); !isInfinity(x, y) {
+		t.Error("ScalarMult(O, 5) is not the point at infinity")
+	}
+
+	expectPanic := func(name string, f func()) {
+		defer func() {
+			if recover() == nil {
+				t.Errorf("%s did not panic on an invalid point", name)
+			}
+		}()
+		f()
+	}
+	offY := new(big.Int).Add(gy, big.NewInt(1))
+	expectPanic("ScalarMult", func() { ScalarMult(gx, offY, []byte{1}) })
+	expectPanic("Add", func() { Add(gx, gy, gx, offY) })
+	expectPanic("Add", func() { Add(nil, nil, gx, gy) })
+}
@@ -6,23 +6,48 @@ import (
 	"errors"
 	"io"
 	"math/big"
+	"runtime"
 	"sync"
+	"sync/atomic"
+
+	"github.com/tjfoc/gmsm/sm3"
 )
 
 // KeyPool is a sync.Pool for *PrivateKey instances to reduce allocations
 var keyPool = sync.Pool{
 	New: func() interface{} {
+		poolNews.Add(1)
 		return &PrivateKey{}
 	},
 }
 
-// GenerateKeyWithPool generates a new SM2 private key using the pool
+// poolGets and poolNews count keys taken from keyPool and how many of them
+// had to be allocated because the pool was empty.
+var poolGets, poolNews atomic.Int64
+
+// PoolStats returns how many keys GenerateKeyWithPool has taken from the
+// pool and how many of those were newly allocated rather than reused.
+// gets - news is the number of pool hits.
+func PoolStats() (gets, news int64) {
+	return poolGets.Load(), poolNews.Load()
+}
+
+var nMinusTwo = new(big.Int).Sub(P256Sm2().Params().N, two)
+
+// GenerateKeyWithPool generates a new SM2 private key using the pool.
+// ReturnKey wipes the D of a key handed back to the pool, and the storage of
+// that big.Int is reused here for the next key's D instead of allocating a
+// new one. X and Y are allocated fresh for every key, so a PublicKey copied
+// from an earlier pooled key is never changed. The point multiplication
+// itself still allocates, so the saving is a handful of allocations per key
+// (see BenchmarkSM2KeyGenPooledVsPlain).
 func GenerateKeyWithPool(random io.Reader) (*PrivateKey, error) {
 	if random == nil {
 		random = rand.Reader
 	}
 	
 	priv := keyPool.Get().(*PrivateKey)
+	poolGets.Add(1)
 	c := P256Sm2()
 	params := c.Params()
 	b := make([]byte, params.BitSize/8+8)
@@ -31,25 +56,41 @@ func GenerateKeyWithPool(random io.Reader) (*PrivateKey, error) {
 		keyPool.Put(priv)
 		return nil, err
 	}
+	if priv.D == nil {
+		priv.D = new(big.Int)
+	}
+	priv.PublicKey.X, priv.PublicKey.Y = new(big.Int), new(big.Int)
 
-	k := new(big.Int).SetBytes(b)
-	n := new(big.Int).Sub(params.N, two)
-	k.Mod(k, n)
+	k := priv.D.SetBytes(b)
+	k.Mod(k, nMinusTwo)
 	k.Add(k, one)
+	var kb [32]byte
+	k.FillBytes(kb[:])
 	
 	priv.PublicKey.Curve = c
-	priv.D = k
-	priv.PublicKey.X, priv.PublicKey.Y = c.ScalarBaseMult(k.Bytes())
+	scalarBaseMultInto(priv.PublicKey.X, priv.PublicKey.Y, kb[:])
+	for i := range b {
+		b[i] = 0
+	}
 	
 	return priv, nil
 }
 
-// ReturnKey returns a private key to the pool
+// ReturnKey wipes a private key and returns it to the pool. The key must not
+// be used after it has been returned. D is zeroed in place and its storage is
+// reused by a later GenerateKeyWithPool call, so any *big.Int taken from
+// priv.D becomes invalid. The public point is only detached from priv, so
+// copies of priv.PublicKey and its X and Y stay valid.
 func ReturnKey(priv *PrivateKey) {
 	if priv != nil {
-		priv.D = nil
-		priv.PublicKey.X = nil
-		priv.PublicKey.Y = nil
+		if priv.D != nil {
+			d := priv.D.Bits()
+			for i := range d {
+				d[i] = 0
+			}
+			priv.D.SetInt64(0)
+		}
+		priv.PublicKey.X, priv.PublicKey.Y = nil, nil
 		keyPool.Put(priv)
 	}
 }
@@ -57,7 +98,13 @@ func ReturnKey(priv *PrivateKey) {
 // SignData signs data with the provided private key and returns the signature
 // This is a convenience function that handles the entire signing process
 func SignData(priv *PrivateKey, data []byte) ([]byte, error) {
-	return priv.Sign(rand.Reader, data, nil)
+	return SignDataWithRand(priv, data, rand.Reader)
+}
+
+// SignDataWithRand is like SignData but draws the ephemeral key from random,
+// or from rand.Reader if random is nil
+func SignDataWithRand(priv *PrivateKey, data []byte, random io.Reader) ([]byte, error) {
+	return priv.Sign(random, data, nil)
 }
 
 // VerifySignature verifies a signature against data and public key
@@ -66,27 +113,175 @@ func VerifySignature(pub *PublicKey, data, signature []byte) bool {
 	return pub.Verify(data, signature)
 }
 
+// MessageDigest returns the 32 byte value e = SM3(ZA || data) that Sign and
+// Verify compute for uid, or for the default uid if uid is empty
+func MessageDigest(pub *PublicKey, data, uid []byte) ([]byte, error) {
+	if len(uid) == 0 {
+		uid = default_uid
+	}
+	za, err := ZA(pub, uid)
+	if err != nil {
+		return nil, err
+	}
+	h := sm3.New()
+	h.Write(za)
+	h.Write(data)
+	return h.Sum(nil), nil
+}
+
+// SignDigest signs a digest computed by MessageDigest and returns the
+// ASN.1 encoded signature. The 32 byte digest is used directly as the value
+// e of GM/T 0003.2; the ZA || message hashing step is skipped, so digest must
+// already include ZA or the signature will not verify with Verify
+func SignDigest(priv *PrivateKey, digest []byte) ([]byte, error) {
+	if len(digest) != sm3.Size {
+		return nil, errors.New("SM2: invalid digest length")
+	}
+	r, s, err := signWithE(priv, new(big.Int).SetBytes(digest), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	return SignDigitToSignData(r, s)
+}
+
+// VerifyDigest verifies an ASN.1 encoded signature over a digest computed
+// by MessageDigest. Like SignDigest it takes digest as e without mixing in
+// ZA, and rejects digests that are not exactly 32 bytes
+func VerifyDigest(pub *PublicKey, digest, signature []byte) bool {
+	if len(digest) != sm3.Size {
+		return false
+	}
+	r, s, err := SignDataToSignDigit(signature)
+	if err != nil {
+		return false
+	}
+	return Verify(pub, digest, r, s)
+}
+
 // EncryptData encrypts data with the provided public key
 // This is a convenience function that handles the entire encryption process
 func EncryptData(pub *PublicKey, data []byte) ([]byte, error) {
-	return pub.EncryptAsn1(data, rand.Reader)
+	return EncryptDataWithRand(pub, data, rand.Reader)
+}
+
+// EncryptDataWithRand is like EncryptData but draws the ephemeral key from
+// random, or from rand.Reader if random is nil
+func EncryptDataWithRand(pub *PublicKey, data []byte, random io.Reader) ([]byte, error) {
+	return pub.EncryptAsn1(data, random)
+}
+
+// Encoding selects the ciphertext format of EncryptDataMode.
+type Encoding int
+
+const (
+	// EncodingASN1 is the GM/T 0009 ASN.1 ciphertext of EncryptAsn1, the
+	// format of EncryptData.
+	EncodingASN1 Encoding = iota
+	// EncodingRaw is the raw 0x04 || C1 || C3 || C2 ciphertext of Encrypt
+	// with mode C1C3C2.
+	EncodingRaw
+)
+
+// EncryptDataMode is like EncryptData but returns the ciphertext in the
+// given encoding. DecryptData accepts both.
+func EncryptDataMode(pub *PublicKey, data []byte, encoding Encoding) ([]byte, error) {
+	switch encoding {
+	case EncodingASN1:
+		return pub.EncryptAsn1(data, rand.Reader)
+	case EncodingRaw:
+		return Encrypt(pub, data, rand.Reader, C1C3C2)
+	}
+	return nil, errors.New("SM2: unknown ciphertext encoding")
 }
 
 // DecryptData decrypts data with the provided private key
 // This is a convenience function that handles the entire decryption process
+//
+// The encoding is detected from the leading byte: 0x30 (an ASN.1 SEQUENCE)
+// for EncodingASN1 and 0x04 (an uncompressed C1) for EncodingRaw. Raw
+// ciphertexts are taken to be in C1C3C2 order.
 func DecryptData(priv *PrivateKey, encryptedData []byte) ([]byte, error) {
-	return priv.DecryptAsn1(encryptedData)
+	if len(encryptedData) == 0 {
+		return nil, errors.New("SM2: ciphertext too short")
+	}
+	switch encryptedData[0] {
+	case 0x30:
+		return priv.DecryptAsn1(encryptedData)
+	case 0x04:
+		return Decrypt(priv, encryptedData, C1C3C2)
+	}
+	return nil, errors.New("SM2: unrecognized ciphertext encoding")
 }
 
 // NewKeyPair generates a new key pair and returns both private and public keys
 func NewKeyPair() (*PrivateKey, *PublicKey, error) {
-	priv, err := GenerateKey(rand.Reader)
+	return NewKeyPairWithRand(rand.Reader)
+}
+
+// NewKeyPairWithRand is like NewKeyPair but reads the private key from
+// random, or from rand.Reader if random is nil
+func NewKeyPairWithRand(random io.Reader) (*PrivateKey, *PublicKey, error) {
+	priv, err := GenerateKey(random)
 	if err != nil {
 		return nil, nil, err
 	}
 	return priv, &priv.PublicKey, nil
 }
 
+// BatchGenerateKeys generates n key pairs on workers goroutines, or on
+// GOMAXPROCS goroutines if workers <= 0. Each key is read independently
+// from rand.Reader, which is safe for concurrent use. If any generation
+// fails, the error is returned and no keys are.
+func BatchGenerateKeys(n, workers int) ([]*PrivateKey, error) {
+	return batchGenerateKeys(n, workers, rand.Reader)
+}
+
+// batchGenerateKeys is BatchGenerateKeys with random as the source, which
+// must be safe for concurrent use.
+func batchGenerateKeys(n, workers int, random io.Reader) ([]*PrivateKey, error) {
+	if n < 0 {
+		return nil, errors.New("SM2: negative key count")
+	}
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	if workers > n {
+		workers = n
+	}
+	keys := make([]*PrivateKey, n)
+	var (
+		next     atomic.Int64
+		failed   atomic.Bool
+		firstErr error
+		once     sync.Once
+		wg       sync.WaitGroup
+	)
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for !failed.Load() {
+				i := int(next.Add(1) - 1)
+				if i >= n {
+					return
+				}
+				key, err := GenerateKey(random)
+				if err != nil {
+					once.Do(func() { firstErr = err })
+					failed.Store(true)
+					return
+				}
+				keys[i] = key
+			}
+		}()
+	}
+	wg.Wait()
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	return keys, nil
+}
+
 // Hash computes a SHA256 hash of the data for use in SM2 operations
 // This is provided as a convenience function for data hashing
 func Hash(data []byte) []byte {
@@ -97,9 +292,15 @@ func Hash(data []byte) []byte {
 // BatchSign signs multiple messages with the same private key
 // This is more efficient than signing each message individually
 func BatchSign(priv *PrivateKey, messages [][]byte) ([][]byte, error) {
+	return BatchSignWithRand(priv, messages, rand.Reader)
+}
+
+// BatchSignWithRand is like BatchSign but draws the ephemeral keys from
+// random, or from rand.Reader if random is nil
+func BatchSignWithRand(priv *PrivateKey, messages [][]byte, random io.Reader) ([][]byte, error) {
 	signatures := make([][]byte, len(messages))
 	for i, msg := range messages {
-		sig, err := priv.Sign(rand.Reader, msg, nil)
+		sig, err := priv.Sign(random, msg, nil)
 		if err != nil {
 			return nil, err
 		}
@@ -120,4 +321,49 @@ func BatchVerify(pub *PublicKey, messages [][]byte, signatures [][]byte) ([]bool
 		results[i] = pub.Verify(messages[i], signatures[i])
 	}
 	return results, nil
-}
\ No newline at end of file
+}
+
+// BatchVerifyAll reports whether every signature is valid for its message.
+// It stops at the first invalid signature and returns false with its index;
+// the index is -1 when all signatures verify or on error.
+func BatchVerifyAll(pub *PublicKey, messages, signatures [][]byte) (bool, int, error) {
+	if len(messages) != len(signatures) {
+		return false, -1, errors.New("messages and signatures count mismatch")
+	}
+
+	for i := range messages {
+		if !pub.Verify(messages[i], signatures[i]) {
+			return false, i, nil
+		}
+	}
+	return true, -1, nil
+}
+
+// BatchReport summarizes the outcome of VerifyBatchReport.
+type BatchReport struct {
+	Total, Valid, Invalid int
+	// FailedIndexes lists the indexes of the invalid signatures in
+	// increasing order.
+	FailedIndexes []int
+	// Results holds the validity of each signature, as from BatchVerify.
+	Results []bool
+}
+
+// VerifyBatchReport verifies every signature like BatchVerify and returns
+// the results together with their counts and the failed indexes.
+func VerifyBatchReport(pub *PublicKey, messages, signatures [][]byte) (*BatchReport, error) {
+	results, err := BatchVerify(pub, messages, signatures)
+	if err != nil {
+		return nil, err
+	}
+	report := &BatchReport{Total: len(results), Results: results}
+	for i, ok := range results {
+		if ok {
+			report.Valid++
+		} else {
+			report.Invalid++
+			report.FailedIndexes = append(report.FailedIndexes, i)
+		}
+	}
+	return report, nil
+}
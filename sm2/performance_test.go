@@ -0,0 +1,362 @@
+package sm2
+
+import (
+	"bytes"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math/big"
+	"testing"
+	"testing/iotest"
+
+	"github.com/tjfoc/gmsm/sm3"
+)
+
+func TestGenerateKeyWithPoolReuse(t *testing.T) {
+	for i := 0; i < 4; i++ {
+		priv, err := GenerateKeyWithPool(rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		x, y := P256Sm2().ScalarBaseMult(priv.D.Bytes())
+		if x.Cmp(priv.X) != 0 || y.Cmp(priv.Y) != 0 {
+			t.Fatal("pooled key has a public point that does not match D")
+		}
+		msg := []byte("pooled key")
+		sig, err := priv.Sign(rand.Reader, msg, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !priv.PublicKey.Verify(msg, sig) {
+			t.Fatal("signature with a pooled key does not verify")
+		}
+		d := priv.D
+		pub := priv.PublicKey
+		x, y = new(big.Int).Set(pub.X), new(big.Int).Set(pub.Y)
+		ReturnKey(priv)
+		if d.Sign() != 0 {
+			t.Fatal("ReturnKey did not wipe D")
+		}
+		next, err := GenerateKeyWithPool(rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if pub.X.Cmp(x) != 0 || pub.Y.Cmp(y) != 0 {
+			t.Fatal("a copied public key changed after the key was pooled")
+		}
+		if !pub.Verify(msg, sig) {
+			t.Fatal("a copied public key no longer verifies")
+		}
+		ReturnKey(next)
+	}
+}
+
+func BenchmarkSM2KeyGenPooledVsPlain(b *testing.B) {
+	b.Run("Plain", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if _, err := GenerateKey(rand.Reader); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("Pooled", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			priv, err := GenerateKeyWithPool(rand.Reader)
+			if err != nil {
+				b.Fatal(err)
+			}
+			ReturnKey(priv)
+		}
+	})
+}
+
+func TestBatchGenerateKeys(t *testing.T) {
+	for _, workers := range []int{0, 1, 4, 100} {
+		keys, err := BatchGenerateKeys(40, workers)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(keys) != 40 {
+			t.Fatalf("got %d keys, want 40", len(keys))
+		}
+		seen := make(map[string]bool)
+		for i, key := range keys {
+			if err := key.Validate(); err != nil {
+				t.Fatalf("workers %d, key %d: %v", workers, i, err)
+			}
+			if seen[key.D.String()] {
+				t.Fatalf("workers %d: duplicate key %d", workers, i)
+			}
+			seen[key.D.String()] = true
+		}
+	}
+	if keys, err := BatchGenerateKeys(0, 4); err != nil || len(keys) != 0 {
+		t.Errorf("n = 0: got %d keys, %v", len(keys), err)
+	}
+	if _, err := BatchGenerateKeys(-1, 4); err == nil {
+		t.Error("negative count accepted")
+	}
+	readErr := errors.New("entropy source failed")
+	if keys, err := batchGenerateKeys(10, 3, iotest.ErrReader(readErr)); err != readErr || keys != nil {
+		t.Errorf("failing reader: got %d keys, %v", len(keys), err)
+	}
+}
+
+func BenchmarkBatchGenerateKeys(b *testing.B) {
+	for _, workers := range []int{1, 0} {
+		name := "Serial"
+		if workers == 0 {
+			name = "GOMAXPROCS"
+		}
+		b.Run(name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if _, err := BatchGenerateKeys(100, workers); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func TestBatchVerifyAll(t *testing.T) {
+	priv, err := GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	messages := [][]byte{[]byte("one"), []byte("two"), []byte("three"), []byte("four")}
+	signatures, err := BatchSign(priv, messages)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ok, idx, err := BatchVerifyAll(&priv.PublicKey, messages, signatures)
+	if err != nil || !ok || idx != -1 {
+		t.Errorf("valid batch: got %v, %d, %v", ok, idx, err)
+	}
+
+	signatures[1], signatures[3] = signatures[3], signatures[1]
+	ok, idx, err = BatchVerifyAll(&priv.PublicKey, messages, signatures)
+	if err != nil || ok || idx != 1 {
+		t.Errorf("invalid batch: got %v, %d, %v; want false, 1", ok, idx, err)
+	}
+
+	if _, _, err := BatchVerifyAll(&priv.PublicKey, messages, signatures[:2]); err == nil {
+		t.Error("count mismatch accepted")
+	}
+}
+
+func TestVerifyBatchReport(t *testing.T) {
+	priv, err := GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	messages := [][]byte{[]byte("one"), []byte("two"), []byte("three"), []byte("four"), []byte("five")}
+	signatures, err := BatchSign(priv, messages)
+	if err != nil {
+		t.Fatal(err)
+	}
+	report, err := VerifyBatchReport(&priv.PublicKey, messages, signatures)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Total != 5 || report.Valid != 5 || report.Invalid != 0 || len(report.FailedIndexes) != 0 {
+		t.Errorf("valid batch: got %+v", report)
+	}
+
+	signatures[1], signatures[3] = signatures[3], signatures[1]
+	signatures[4] = []byte("garbage")
+	report, err = VerifyBatchReport(&priv.PublicKey, messages, signatures)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Total != 5 || report.Valid != 2 || report.Invalid != 3 {
+		t.Errorf("invalid batch: got %+v", report)
+	}
+	if want := []int{1, 3, 4}; fmt.Sprint(report.FailedIndexes) != fmt.Sprint(want) {
+		t.Errorf("FailedIndexes = %v, want %v", report.FailedIndexes, want)
+	}
+	if want := []bool{true, false, true, false, false}; fmt.Sprint(report.Results) != fmt.Sprint(want) {
+		t.Errorf("Results = %v, want %v", report.Results, want)
+	}
+
+	if _, err := VerifyBatchReport(&priv.PublicKey, messages, signatures[:2]); err == nil {
+		t.Error("count mismatch accepted")
+	}
+}
+
+// countingReader is a deterministic stream: SM3(seed || counter) blocks.
+type countingReader struct {
+	seed    []byte
+	counter byte
+	buf     []byte
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	for len(r.buf) < len(p) {
+		sum := sm3.Sm3Sum(append(append([]byte(nil), r.seed...), r.counter))
+		r.counter++
+		r.buf = append(r.buf, sum...)
+	}
+	n := copy(p, r.buf)
+	r.buf = r.buf[n:]
+	return n, nil
+}
+
+func TestConvenienceWithRand(t *testing.T) {
+	run := func() (priv *PrivateKey, sig, ct []byte, batch [][]byte) {
+		random := &countingReader{seed: []byte("fixed seed")}
+		priv, pub, err := NewKeyPairWithRand(random)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if sig, err = SignDataWithRand(priv, []byte("msg"), random); err != nil {
+			t.Fatal(err)
+		}
+		if ct, err = EncryptDataWithRand(pub, []byte("msg"), random); err != nil {
+			t.Fatal(err)
+		}
+		if batch, err = BatchSignWithRand(priv, [][]byte{[]byte("a"), []byte("b")}, random); err != nil {
+			t.Fatal(err)
+		}
+		return priv, sig, ct, batch
+	}
+	priv1, sig1, ct1, batch1 := run()
+	priv2, sig2, ct2, batch2 := run()
+	if priv1.D.Cmp(priv2.D) != 0 {
+		t.Error("NewKeyPairWithRand is not reproducible")
+	}
+	if !bytes.Equal(sig1, sig2) || !bytes.Equal(ct1, ct2) {
+		t.Error("SignDataWithRand/EncryptDataWithRand are not reproducible")
+	}
+	for i := range batch1 {
+		if !bytes.Equal(batch1[i], batch2[i]) {
+			t.Error("BatchSignWithRand is not reproducible")
+		}
+	}
+	if !VerifySignature(&priv1.PublicKey, []byte("msg"), sig1) {
+		t.Error("deterministic signature does not verify")
+	}
+	if pt, err := DecryptData(priv1, ct1); err != nil || string(pt) != "msg" {
+		t.Error("deterministic ciphertext does not decrypt")
+	}
+
+	priv, pub, err := NewKeyPairWithRand(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := SignDataWithRand(priv, []byte("msg"), nil); err != nil {
+		t.Errorf("nil reader: %v", err)
+	}
+	if _, err := EncryptDataWithRand(pub, []byte("msg"), nil); err != nil {
+		t.Errorf("nil reader: %v", err)
+	}
+}
+
+func TestEncryptDataMode(t *testing.T) {
+	priv, pub, err := NewKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg := []byte("convenience layer")
+	asn1CT, err := EncryptDataMode(pub, msg, EncodingASN1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rawCT, err := EncryptDataMode(pub, msg, EncodingRaw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if asn1CT[0] != 0x30 || rawCT[0] != 0x04 || len(rawCT) != 97+len(msg) {
+		t.Errorf("unexpected encodings %x and %x", asn1CT[:1], rawCT[:1])
+	}
+	if pt, err := Decrypt(priv, rawCT, C1C3C2); err != nil || !bytes.Equal(pt, msg) {
+		t.Errorf("raw ciphertext is not C1C3C2: %v", err)
+	}
+	for _, ct := range [][]byte{asn1CT, rawCT} {
+		if pt, err := DecryptData(priv, ct); err != nil || !bytes.Equal(pt, msg) {
+			t.Errorf("DecryptData(%x...): got %q, %v", ct[:1], pt, err)
+		}
+	}
+	if _, err := EncryptDataMode(pub, msg, Encoding(2)); err == nil {
+		t.Error("unknown encoding accepted")
+	}
+	bad := append([]byte{0x02}, rawCT[1:]...)
+	if _, err := DecryptData(priv, bad); err == nil {
+		t.Error("unknown leading byte accepted")
+	}
+	if _, err := DecryptData(priv, nil); err == nil {
+		t.Error("empty ciphertext accepted")
+	}
+}
+
+func TestPoolStats(t *testing.T) {
+	gets0, news0 := PoolStats()
+	const n = 10
+	for i := 0; i < n; i++ {
+		priv, err := GenerateKeyWithPool(rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ReturnKey(priv)
+	}
+	gets, news := PoolStats()
+	if gets-gets0 != n {
+		t.Errorf("gets grew by %d, want %d", gets-gets0, n)
+	}
+	// The pool may drop entries at any GC, so only bound the misses.
+	if news < news0 || news-news0 > n {
+		t.Errorf("news grew by %d, want between 0 and %d", news-news0, n)
+	}
+	if news > gets {
+		t.Errorf("more allocations (%d) than gets (%d)", news, gets)
+	}
+}
+
+func TestSignDigest(t *testing.T) {
+	priv, err := GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := []byte("precomputed digest")
+
+	digest, err := MessageDigest(&priv.PublicKey, data, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sig, err := SignDigest(priv, digest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !VerifySignature(&priv.PublicKey, data, sig) {
+		t.Error("SignDigest signature does not verify with VerifySignature")
+	}
+	if !VerifyDigest(&priv.PublicKey, digest, sig) {
+		t.Error("SignDigest signature does not verify with VerifyDigest")
+	}
+
+	uid := []byte("alice@example.com")
+	digest, err = MessageDigest(&priv.PublicKey, data, uid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sig, err = SignDigest(priv, digest); err != nil {
+		t.Fatal(err)
+	}
+	r, s, _ := SignDataToSignDigit(sig)
+	if !Sm2Verify(&priv.PublicKey, data, uid, r, s) {
+		t.Error("SignDigest signature with uid does not verify with Sm2Verify")
+	}
+	if VerifySignature(&priv.PublicKey, data, sig) {
+		t.Error("signature for a custom uid verifies with the default uid")
+	}
+	if _, err := SignDigest(priv, digest[:31]); err == nil {
+		t.Error("short digest accepted")
+	}
+	if VerifyDigest(&priv.PublicKey, append(digest, 0), sig) {
+		t.Error("VerifyDigest accepted a 33 byte digest")
+	}
+	if VerifyDigest(&priv.PublicKey, data, sig) {
+		t.Error("VerifyDigest accepted the message in place of its digest")
+	}
+}
@@ -0,0 +1,162 @@
+package sm2
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/asn1"
+	"encoding/binary"
+	"errors"
+	"math/big"
+
+	"github.com/tjfoc/gmsm/sm3"
+)
+
+// ringDomain separates ring signature challenges from other SM3 uses.
+var ringDomain = []byte("SM2 ring signature")
+
+// ringSignature is the encoding returned by RingSign:
+//
+//	RingSignature ::= SEQUENCE {
+//	    c0 INTEGER,              -- challenge of ring member 0
+//	    s  SEQUENCE OF INTEGER } -- one response per ring member
+type ringSignature struct {
+	C0 *big.Int
+	S  []*big.Int
+}
+
+// RingSign signs message on behalf of ring, which must contain the public
+// key of priv, such that RingVerify accepts the signature without learning
+// which member signed. It implements the non-linkable Schnorr ring
+// signature of Abe, Ohkubo and Suzuki over the SM2 curve with SM3 as the
+// hash: two signatures by the same member cannot be linked.
+//
+// The signature binds the ring in order, so the verifier needs the same
+// keys in the same order. Its size grows by about 35 bytes per member.
+func RingSign(priv *PrivateKey, ring []*PublicKey, message []byte) ([]byte, error) {
+	if priv == nil || priv.D == nil || priv.X == nil || priv.Y == nil || priv.D.Sign() <= 0 {
+		return nil, errors.New("SM2: invalid private key")
+	}
+	digest, err := ringDigest(ring, message)
+	if err != nil {
+		return nil, err
+	}
+	n := len(ring)
+	signer := -1
+	for i, pub := range ring {
+		if pub.X.Cmp(priv.X) == 0 && pub.Y.Cmp(priv.Y) == 0 {
+			signer = i
+			break
+		}
+	}
+	if signer < 0 {
+		return nil, errors.New("SM2: signer's public key is not in the ring")
+	}
+	if x, y := ScalarBaseMult(priv.D.Bytes()); x.Cmp(priv.X) != 0 || y.Cmp(priv.Y) != 0 {
+		return nil, ErrPublicKeyMismatch
+	}
+
+	N := sm2P256.N
+	c := make([]*big.Int, n)
+	s := make([]*big.Int, n)
+	alpha, err := randFieldElement(P256Sm2(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	// Close the ring starting after the signer: each challenge is derived
+	// from the commitment of the previous member.
+	x, y := ScalarBaseMult(alpha.Bytes())
+	for k := 1; k < n; k++ {
+		i := (signer + k) % n
+		c[i] = ringChallenge(digest, x, y)
+		if s[i], err = randFieldElement(P256Sm2(), rand.Reader); err != nil {
+			return nil, err
+		}
+		if x, y, err = ringCommitment(ring[i], s[i], c[i]); err != nil {
+			return nil, err
+		}
+	}
+	c[signer] = ringChallenge(digest, x, y)
+	// s = alpha - c*d, so s*G + c*P = alpha*G.
+	s[signer] = new(big.Int).Mul(c[signer], priv.D)
+	s[signer].Sub(alpha, s[signer])
+	s[signer].Mod(s[signer], N)
+	return asn1.Marshal(ringSignature{C0: c[0], S: s})
+}
+
+// RingVerify reports whether sig is a valid RingSign signature of message
+// by some member of ring.
+func RingVerify(ring []*PublicKey, message, sig []byte) bool {
+	var rs ringSignature
+	rest, err := asn1.Unmarshal(sig, &rs)
+	if err != nil || len(rest) != 0 || len(rs.S) != len(ring) {
+		return false
+	}
+	if der, err := asn1.Marshal(rs); err != nil || !bytes.Equal(der, sig) {
+		return false
+	}
+	digest, err := ringDigest(ring, message)
+	if err != nil {
+		return false
+	}
+	N := sm2P256.N
+	inRange := func(v *big.Int) bool { return v.Sign() >= 0 && v.Cmp(N) < 0 }
+	if !inRange(rs.C0) {
+		return false
+	}
+	c := rs.C0
+	for i, pub := range ring {
+		if !inRange(rs.S[i]) {
+			return false
+		}
+		x, y, err := ringCommitment(pub, rs.S[i], c)
+		if err != nil {
+			return false
+		}
+		c = ringChallenge(digest, x, y)
+	}
+	return c.Cmp(rs.C0) == 0
+}
+
+// ringDigest hashes the domain, the ring and the message, which every
+// challenge commits to.
+func ringDigest(ring []*PublicKey, message []byte) ([]byte, error) {
+	if len(ring) == 0 {
+		return nil, errors.New("SM2: empty ring")
+	}
+	h := sm3.New()
+	h.Write(ringDomain)
+	var count [4]byte
+	binary.BigEndian.PutUint32(count[:], uint32(len(ring)))
+	h.Write(count[:])
+	for _, pub := range ring {
+		if pub == nil || !isValidPoint(pub.X, pub.Y) {
+			return nil, errors.New("SM2: ring contains an invalid public key")
+		}
+		h.Write(Marshal(pub))
+	}
+	h.Write(message)
+	return h.Sum(nil), nil
+}
+
+// ringChallenge returns SM3(digest || x || y) mod n.
+func ringChallenge(digest []byte, x, y *big.Int) *big.Int {
+	var buf [64]byte
+	putFixedBytes(buf[:32], x)
+	putFixedBytes(buf[32:], y)
+	h := sm3.New()
+	h.Write(digest)
+	h.Write(buf[:])
+	c := new(big.Int).SetBytes(h.Sum(nil))
+	return c.Mod(c, sm2P256.N)
+}
+
+// ringCommitment returns s*G + c*pub, failing at the point at infinity.
+func ringCommitment(pub *PublicKey, s, c *big.Int) (x, y *big.Int, err error) {
+	x1, y1 := ScalarBaseMult(s.Bytes())
+	x2, y2 := ScalarMult(pub.X, pub.Y, c.Bytes())
+	x, y = Add(x1, y1, x2, y2)
+	if x.Sign() == 0 && y.Sign() == 0 {
+		return nil, nil, errors.New("SM2: ring commitment is the point at infinity")
+	}
+	return x, y, nil
+}
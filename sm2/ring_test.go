@@ -0,0 +1,94 @@
+package sm2
+
+import (
+	"crypto/rand"
+	"encoding/asn1"
+	"math/big"
+	"testing"
+)
+
+func TestRingSignature(t *testing.T) {
+	privs := make([]*PrivateKey, 5)
+	ring := make([]*PublicKey, len(privs))
+	for i := range privs {
+		priv, err := GenerateKey(rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		privs[i], ring[i] = priv, &priv.PublicKey
+	}
+	msg := []byte("ballot: option B")
+
+	for i, priv := range privs {
+		sig, err := RingSign(priv, ring, msg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !RingVerify(ring, msg, sig) {
+			t.Errorf("member %d: valid ring signature rejected", i)
+		}
+		if RingVerify(ring, []byte("ballot: option A"), sig) {
+			t.Errorf("member %d: signature verified for another message", i)
+		}
+		reordered := append([]*PublicKey{ring[1], ring[0]}, ring[2:]...)
+		if RingVerify(reordered, msg, sig) {
+			t.Errorf("member %d: signature verified for a reordered ring", i)
+		}
+		if RingVerify(ring[:4], msg, sig) {
+			t.Errorf("member %d: signature verified for a smaller ring", i)
+		}
+
+		var rs ringSignature
+		if _, err := asn1.Unmarshal(sig, &rs); err != nil {
+			t.Fatal(err)
+		}
+		for j := range rs.S {
+			rs.S[j].Add(rs.S[j], big.NewInt(1))
+			tampered, _ := asn1.Marshal(rs)
+			if RingVerify(ring, msg, tampered) {
+				t.Errorf("member %d: signature with s[%d] modified accepted", i, j)
+			}
+			rs.S[j].Sub(rs.S[j], big.NewInt(1))
+		}
+		rs.C0.Add(rs.C0, big.NewInt(1))
+		if tampered, _ := asn1.Marshal(rs); RingVerify(ring, msg, tampered) {
+			t.Errorf("member %d: signature with c0 modified accepted", i)
+		}
+	}
+
+	outsider, err := GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := RingSign(outsider, ring, msg); err == nil {
+		t.Error("signer outside the ring accepted")
+	}
+	sig, err := RingSign(privs[2], ring, msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	swapped := append([]*PublicKey(nil), ring...)
+	swapped[0] = &outsider.PublicKey
+	if RingVerify(swapped, msg, sig) {
+		t.Error("signature verified for a ring with a different member")
+	}
+	if RingVerify(ring, msg, sig[:len(sig)-1]) || RingVerify(ring, msg, append(sig, 0)) {
+		t.Error("malformed signature accepted")
+	}
+	if _, err := RingSign(privs[0], nil, msg); err == nil {
+		t.Error("empty ring accepted")
+	}
+	invalid := append([]*PublicKey(nil), ring...)
+	invalid[4] = &PublicKey{Curve: P256Sm2(), X: big.NewInt(1), Y: big.NewInt(1)}
+	if _, err := RingSign(privs[0], invalid, msg); err == nil {
+		t.Error("ring with an invalid key accepted")
+	}
+
+	single, err := RingSign(privs[0], ring[:1], msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !RingVerify(ring[:1], msg, single) {
+		t.Error("single member ring signature rejected")
+	}
+}
@@ -0,0 +1,93 @@
+package sm2
+
+import (
+	"crypto"
+	"crypto/rand"
+	"errors"
+	"math/big"
+
+	"github.com/tjfoc/gmsm/sm3"
+)
+
+var (
+	_ crypto.Signer    = (*PrivateKey)(nil)
+	_ crypto.Decrypter = (*PrivateKey)(nil)
+)
+
+// SignerOpts carries the user ID hashed into ZA through crypto.Signer, for
+// callers of PrivateKey.Sign that cannot pass it directly. An empty UID
+// selects the default user ID.
+type SignerOpts struct {
+	UID []byte
+}
+
+// HashFunc returns sm3.Hash, the digest PrivateKey.Sign always uses. Its
+// Size method panics because SM3 is not registered with the crypto package;
+// the digest length is sm3.Size.
+func (*SignerOpts) HashFunc() crypto.Hash {
+	return sm3.Hash
+}
+
+// DecrypterOpts selects the ciphertext order, C1C3C2 or C1C2C3, for
+// PrivateKey.Decrypt.
+type DecrypterOpts struct {
+	Mode int
+}
+
+var errSignerKeyDestroyed = errors.New("SM2: signer key has been destroyed or modified")
+
+// Signer signs many messages under one private key and user ID. The ZA
+// value, which only depends on the public key and uid, is computed once by
+// NewSigner so each Sign only hashes ZA || M.
+//
+// If the private key is destroyed (e.g. handed back via ReturnKey) or its
+// public point changes, Sign returns an error. Sign never modifies the
+// Signer, so it may be called from several goroutines at once, as long as
+// the private key itself is not modified concurrently.
+type Signer struct {
+	priv *PrivateKey
+	x, y *big.Int
+	za   []byte
+}
+
+// NewSigner returns a Signer for priv using uid, or the default user ID when
+// uid is empty.
+func NewSigner(priv *PrivateKey, uid []byte) (*Signer, error) {
+	if priv == nil || priv.D == nil || priv.X == nil || priv.Y == nil || priv.D.Sign() <= 0 {
+		return nil, errors.New("SM2: invalid private key")
+	}
+	if len(uid) == 0 {
+		uid = default_uid
+	}
+	za, err := ZA(&priv.PublicKey, uid)
+	if err != nil {
+		return nil, err
+	}
+	return &Signer{
+		priv: priv,
+		x:    new(big.Int).Set(priv.X),
+		y:    new(big.Int).Set(priv.Y),
+		za:   za,
+	}, nil
+}
+
+// Sign returns the ASN.1 encoded SM2 signature of data.
+func (s *Signer) Sign(data []byte) ([]byte, error) {
+	if !s.valid() {
+		return nil, errSignerKeyDestroyed
+	}
+	h := sm3.New()
+	h.Write(s.za)
+	h.Write(data)
+	r, ss, err := signWithE(s.priv, new(big.Int).SetBytes(h.Sum(nil)), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	return SignDigitToSignData(r, ss)
+}
+
+func (s *Signer) valid() bool {
+	p := s.priv
+	return s.za != nil && p.D != nil && p.X != nil && p.Y != nil && p.D.Sign() > 0 &&
+		p.X.Cmp(s.x) == 0 && p.Y.Cmp(s.y) == 0
+}
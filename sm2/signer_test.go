@@ -0,0 +1,142 @@
+package sm2
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rand"
+	"sync"
+	"testing"
+
+	"github.com/tjfoc/gmsm/sm3"
+)
+
+func TestSigner(t *testing.T) {
+	priv, err := GenerateKeyWithPool(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	uid := []byte("alice@example.com")
+	signer, err := NewSigner(priv, uid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, msg := range [][]byte{[]byte(""), []byte("message one"), make([]byte, 1000)} {
+		sig, err := signer.Sign(msg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		r, s, err := SignDataToSignDigit(sig)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !Sm2Verify(&priv.PublicKey, msg, uid, r, s) {
+			t.Errorf("signature over %q does not verify", msg)
+		}
+	}
+
+	ReturnKey(priv)
+	if _, err := signer.Sign([]byte("after destroy")); err == nil {
+		t.Error("signer kept working after the key was destroyed")
+	}
+
+	// Rejecting a destroyed key does not write to the shared Signer, so
+	// concurrent callers are safe (checked under -race).
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := signer.Sign([]byte("concurrent")); err != errSignerKeyDestroyed {
+				t.Errorf("got %v, want errSignerKeyDestroyed", err)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func BenchmarkSignerCachedZA(b *testing.B) {
+	priv, _ := GenerateKey(rand.Reader)
+	uid := []byte("alice@example.com")
+	msg := []byte("benchmark message")
+	signer, _ := NewSigner(priv, uid)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := signer.Sign(msg); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkSm2SignWithID(b *testing.B) {
+	priv, _ := GenerateKey(rand.Reader)
+	uid := []byte("alice@example.com")
+	msg := []byte("benchmark message")
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		r, s, err := Sm2Sign(priv, msg, uid, rand.Reader)
+		if err != nil {
+			b.Fatal(err)
+		}
+		if _, err := SignDigitToSignData(r, s); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestCryptoSignerDecrypter(t *testing.T) {
+	priv, err := GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var signer crypto.Signer = priv
+	if pub, ok := signer.Public().(*PublicKey); !ok || pub != &priv.PublicKey {
+		t.Error("Public does not return the embedded public key")
+	}
+	msg := []byte("crypto.Signer message")
+	uid := []byte("alice@example.com")
+
+	sig, err := signer.Sign(rand.Reader, msg, sm3.Hash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !priv.PublicKey.Verify(msg, sig) {
+		t.Error("signature with default uid does not verify")
+	}
+
+	opts := &SignerOpts{UID: uid}
+	if opts.HashFunc() != sm3.Hash {
+		t.Error("SignerOpts.HashFunc is not sm3.Hash")
+	}
+	if sig, err = signer.Sign(rand.Reader, msg, opts); err != nil {
+		t.Fatal(err)
+	}
+	r, s, err := SignDataToSignDigit(sig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !Sm2Verify(&priv.PublicKey, msg, uid, r, s) {
+		t.Error("signature with SignerOpts uid does not verify with that uid")
+	}
+	if priv.PublicKey.Verify(msg, sig) {
+		t.Error("signature with SignerOpts uid verifies with the default uid")
+	}
+
+	var decrypter crypto.Decrypter = priv
+	for _, mode := range []int{C1C3C2, C1C2C3} {
+		ct, err := Encrypt(&priv.PublicKey, msg, rand.Reader, mode)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pt, err := decrypter.Decrypt(nil, ct, &DecrypterOpts{Mode: mode})
+		if err != nil || !bytes.Equal(pt, msg) {
+			t.Errorf("mode %d: got %q, %v", mode, pt, err)
+		}
+		if mode == C1C3C2 {
+			if pt, err := decrypter.Decrypt(nil, ct, nil); err != nil || !bytes.Equal(pt, msg) {
+				t.Errorf("nil opts: got %q, %v", pt, err)
+			}
+		}
+	}
+}
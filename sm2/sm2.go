@@ -68,8 +68,17 @@ var one = new(big.Int).SetInt64(1)
 var two = new(big.Int).SetInt64(2)
 
 // sign format = 30 + len(z) + 02 + len(r) + r + 02 + len(s) + s, z being what follows its size, ie 02+len(r)+r+02+len(s)+s
+//
+// Sign implements crypto.Signer. msg is the message itself, not a digest:
+// it is always hashed with SM3 over ZA || msg, so sm3.Hash is the value
+// that describes it. ZA uses the default uid unless signer is a
+// *SignerOpts with a UID; other options are ignored.
 func (priv *PrivateKey) Sign(random io.Reader, msg []byte, signer crypto.SignerOpts) ([]byte, error) {
-	r, s, err := Sm2Sign(priv, msg, nil, random)
+	var uid []byte
+	if opts, ok := signer.(*SignerOpts); ok && opts != nil {
+		uid = opts.UID
+	}
+	r, s, err := Sm2Sign(priv, msg, uid, random)
 	if err != nil {
 		return nil, err
 	}
@@ -142,7 +151,11 @@ func Sm2Sign(priv *PrivateKey, msg, uid []byte, random io.Reader) (r, s *big.Int
 	if err != nil {
 		return nil, nil, err
 	}
-	e := new(big.Int).SetBytes(digest)
+	return signWithE(priv, new(big.Int).SetBytes(digest), random)
+}
+
+// signWithE computes an SM2 signature (r, s) over the already hashed value e.
+func signWithE(priv *PrivateKey, e *big.Int, random io.Reader) (r, s *big.Int, err error) {
 	c := priv.PublicKey.Curve
 	N := c.Params().N
 	if N.Sign() == 0 {
@@ -199,19 +212,7 @@ func Sm2Verify(pub *PublicKey, msg, uid []byte, r, s *big.Int) bool {
 	if err != nil {
 		return false
 	}
-	t := new(big.Int).Add(r, s)
-	t.Mod(t, N)
-	if t.Sign() == 0 {
-		return false
-	}
-	var x *big.Int
-	x1, y1 := c.ScalarBaseMult(s.Bytes())
-	x2, y2 := c.ScalarMult(pub.X, pub.Y, t.Bytes())
-	x, _ = c.Add(x1, y1, x2, y2)
-
-	x.Add(x, e)
-	x.Mod(x, N)
-	return x.Cmp(r) == 0
+	return verifyWithE(pub, e, r, s)
 }
 
 /*
@@ -234,23 +235,13 @@ func Verify(pub *PublicKey, hash []byte, r, s *big.Int) bool {
 	}
 
 	// 调整算法细节以实现SM2
-	t := new(big.Int).Add(r, s)
-	t.Mod(t, N)
-	if t.Sign() == 0 {
-		return false
-	}
-
-	var x *big.Int
-	x1, y1 := c.ScalarBaseMult(s.Bytes())
-	x2, y2 := c.ScalarMult(pub.X, pub.Y, t.Bytes())
-	x, _ = c.Add(x1, y1, x2, y2)
-
-	e := new(big.Int).SetBytes(hash)
-	x.Add(x, e)
-	x.Mod(x, N)
-	return x.Cmp(r) == 0
+	return verifyWithE(pub, new(big.Int).SetBytes(hash), r, s)
 }
 
+// maxEncryptAttempts bounds the ephemeral keys Encrypt tries before giving
+// up on an all-zero KDF output.
+const maxEncryptAttempts = 64
+
 /*
  * sm2密文结构如下:
  *  x
@@ -261,35 +252,51 @@ func Verify(pub *PublicKey, hash []byte, r, s *big.Int) bool {
 func Encrypt(pub *PublicKey, data []byte, random io.Reader, mode int) ([]byte, error) {
 	length := len(data)
 	curve := pub.Curve
-	k, err := randFieldElement(curve, random)
-	if err != nil {
-		return nil, err
+	if !isValidPoint(pub.X, pub.Y) {
+		return nil, ErrPointNotOnCurve
 	}
-	
 	// 预分配缓冲区避免重复分配
 	buf := make([]byte, 96+length)
-	
-	x1, y1 := curve.ScalarBaseMult(k.Bytes())
-	x2, y2 := curve.ScalarMult(pub.X, pub.Y, k.Bytes())
-	
-	// 直接写入固定大小缓冲区
-	putFixedBytes(buf[0:32], x1)
-	putFixedBytes(buf[32:64], y1)
-	putFixedBytes(buf[64:96], x2)
-	
+	var y2Buf [32]byte
+	var key []byte
+	for attempt := 0; ; attempt++ {
+		// A working random source gives an all-zero t with probability
+		// at most 1/256 per attempt, so only a broken one reaches the cap.
+		if attempt == maxEncryptAttempts {
+			return nil, errors.New("SM2: KDF output was zero for every ephemeral key")
+		}
+		k, err := randFieldElement(curve, random)
+		if err != nil {
+			return nil, err
+		}
+		x1, y1 := curve.ScalarBaseMult(k.Bytes())
+		x2, y2 := curve.ScalarMult(pub.X, pub.Y, k.Bytes())
+
+		// 直接写入固定大小缓冲区
+		putFixedBytes(buf[0:32], x1)
+		putFixedBytes(buf[32:64], y1)
+		putFixedBytes(buf[64:96], x2)
+		putFixedBytes(y2Buf[:], y2)
+
+		// 计算密钥. GM/T 0003 picks a new k when t is all zero, which is
+		// only likely for very short plaintexts; an empty one always fails.
+		var ok bool
+		key, ok = kdf(length, buf[64:96], y2Buf[:])
+		if ok {
+			break
+		}
+		if length == 0 {
+			return nil, errors.New("kdf failed")
+		}
+	}
+
 	// 计算哈希
 	hashInput := make([]byte, 32+length+32)
 	copy(hashInput[0:32], buf[64:96])
 	copy(hashInput[32:32+length], data)
-	putFixedBytes(hashInput[32+length:], y2)
+	copy(hashInput[32+length:], y2Buf[:])
 	hash := sm3.Sm3Sum(hashInput)
-	
-	// 计算密钥
-	key, ok := kdf(length, buf[64:96], y2.Bytes())
-	if !ok {
-		return nil, errors.New("kdf failed")
-	}
-	
+
 	// 异或加密
 	for i := 0; i < length; i++ {
 		buf[96+i] = data[i] ^ key[i]
@@ -334,6 +341,9 @@ func putFixedBytes(dst []byte, x *big.Int) {
 }
 
 func Decrypt(priv *PrivateKey, data []byte, mode int) ([]byte, error) {
+	if len(data) < 1+96 {
+		return nil, errors.New("SM2: ciphertext too short")
+	}
 	switch mode {
 	case C1C3C2:
 		data = data[1:]
@@ -357,6 +367,12 @@ func Decrypt(priv *PrivateKey, data []byte, mode int) ([]byte, error) {
 	curve := priv.Curve
 	x := new(big.Int).SetBytes(data[:32])
 	y := new(big.Int).SetBytes(data[32:64])
+	// Multiplying a point off the curve by D would leak information about
+	// D through the result (invalid-curve attack). The SM2 cofactor is 1,
+	// so every other point on the curve has order n.
+	if !isValidPoint(x, y) {
+		return nil, ErrInvalidC1
+	}
 	x2, y2 := curve.ScalarMult(x, y, priv.D.Bytes())
 	x2Buf := x2.Bytes()
 	y2Buf := y2.Bytes()
@@ -379,7 +395,7 @@ func Decrypt(priv *PrivateKey, data []byte, mode int) ([]byte, error) {
 	tm = append(tm, y2Buf...)
 	h := sm3.Sm3Sum(tm)
 	if bytes.Compare(h, data[64:96]) != 0 {
-		return c, errors.New("Decrypt: failed to decrypt")
+		return nil, errors.New("Decrypt: failed to decrypt")
 	}
 	return c, nil
 }
@@ -400,10 +416,14 @@ func keyExchange(klen int, ida, idb []byte, pri *PrivateKey, pub *PublicKey, rpr
 	x2rb := new(big.Int).Mul(x2hat, rpri.D)
 	tbt := new(big.Int).Add(pri.D, x2rb)
 	tb := new(big.Int).Mod(tbt, N)
-	if !curve.IsOnCurve(rpub.X, rpub.Y) {
+	if !isValidPoint(rpub.X, rpub.Y) {
 		err = errors.New("Ra not on curve")
 		return
 	}
+	if !isValidPoint(pub.X, pub.Y) {
+		err = ErrPointNotOnCurve
+		return
+	}
 	x1hat := keXHat(rpub.X)
 	ramx1, ramy1 := curve.ScalarMult(rpub.X, rpub.Y, x1hat.Bytes())
 	vxt, vyt := curve.Add(pub.X, pub.Y, ramx1, ramy1)
@@ -526,6 +546,9 @@ func DecryptAsn1(pub *PrivateKey, data []byte) ([]byte, error) {
 *  CipherText
  */
 func CipherMarshal(data []byte) ([]byte, error) {
+	if len(data) < 1+96 {
+		return nil, errors.New("SM2: ciphertext too short")
+	}
 	data = data[1:]
 	x := new(big.Int).SetBytes(data[:32])
 	y := new(big.Int).SetBytes(data[32:64])
@@ -543,16 +566,15 @@ func CipherUnmarshal(data []byte) ([]byte, error) {
 	if err != nil {
 		return nil, err
 	}
+	if cipher.XCoordinate.Sign() < 0 || cipher.YCoordinate.Sign() < 0 ||
+		cipher.XCoordinate.BitLen() > 256 || cipher.YCoordinate.BitLen() > 256 ||
+		len(cipher.HASH) != sm3.Size {
+		return nil, errors.New("SM2: malformed ASN.1 ciphertext")
+	}
 	x := cipher.XCoordinate.Bytes()
 	y := cipher.YCoordinate.Bytes()
 	hash := cipher.HASH
-	if err != nil {
-		return nil, err
-	}
 	cipherText := cipher.CipherText
-	if err != nil {
-		return nil, err
-	}
 	if n := len(x); n < 32 {
 		x = append(zeroByteSlice()[:32-n], x...)
 	}
@@ -695,7 +717,12 @@ func getLastBit(a *big.Int) uint {
 	return a.Bit(0)
 }
 
-// crypto.Decrypter
-func (priv *PrivateKey) Decrypt(_ io.Reader, msg []byte, _ crypto.DecrypterOpts) (plaintext []byte, err error) {
-	return Decrypt(priv, msg, C1C3C2)
+// Decrypt implements crypto.Decrypter. msg is a raw ciphertext in C1C3C2
+// order, or in the order given by opts if it is a *DecrypterOpts.
+func (priv *PrivateKey) Decrypt(_ io.Reader, msg []byte, opts crypto.DecrypterOpts) (plaintext []byte, err error) {
+	mode := C1C3C2
+	if o, ok := opts.(*DecrypterOpts); ok && o != nil {
+		mode = o.Mode
+	}
+	return Decrypt(priv, msg, mode)
 }
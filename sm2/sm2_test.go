@@ -169,3 +169,38 @@ func TestKEB2(t *testing.T) {
 		t.Error("hash verfication failed")
 	}
 }
+
+func TestEncryptEdgeCases(t *testing.T) {
+	priv, _, err := NewKeyPairWithRand(&countingReader{seed: []byte("encrypt retry")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// With this key and a 1 byte plaintext, the k drawn from seed "92"
+	// makes the KDF output zero so Encrypt must pick another k, and the one
+	// from seed "228" gives a y2 with a leading zero byte, which must still
+	// be hashed as 32 bytes.
+	for _, seed := range []string{"92", "228"} {
+		ct, err := Encrypt(&priv.PublicKey, []byte{0x5a}, &countingReader{seed: []byte(seed)}, C1C3C2)
+		if err != nil {
+			t.Errorf("seed %s: %v", seed, err)
+			continue
+		}
+		if pt, err := Decrypt(priv, ct, C1C3C2); err != nil || !bytes.Equal(pt, []byte{0x5a}) {
+			t.Errorf("seed %s: round trip failed: %v", seed, err)
+		}
+	}
+
+	// A reader that keeps returning that first k must not loop forever.
+	k := make([]byte, 40)
+	(&countingReader{seed: []byte("92")}).Read(k)
+	if _, err := Encrypt(&priv.PublicKey, []byte{0x5a}, replayReader(k), C1C3C2); err == nil {
+		t.Error("Encrypt succeeded with an all-zero KDF output")
+	}
+}
+
+// replayReader returns the same bytes on every Read.
+type replayReader []byte
+
+func (r replayReader) Read(p []byte) (int, error) {
+	return copy(p, r), nil
+}
@@ -0,0 +1,205 @@
+package sm2
+
+import (
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/asn1"
+	"encoding/binary"
+	"errors"
+	"hash"
+	"io"
+
+	"github.com/tjfoc/gmsm/sm3"
+	"github.com/tjfoc/gmsm/sm4"
+)
+
+const streamVersion = 1
+
+var errStreamFinalized = errors.New("SM2: stream already finalized")
+
+// streamHeader is the ASN.1 header returned by EncryptStream.Finalize:
+//
+//	SM2StreamHeader ::= SEQUENCE {
+//	    version       INTEGER,      -- always 1
+//	    encryptedKey  OCTET STRING, -- SM2Cipher of the SM4 key || HMAC key
+//	    iv            OCTET STRING, -- 16 byte SM4-CTR IV
+//	    mac           OCTET STRING  -- HMAC-SM3 of iv || ciphertext || length
+//	}
+type streamHeader struct {
+	Version      int
+	EncryptedKey []byte
+	IV           []byte
+	MAC          []byte
+}
+
+// EncryptStream encrypts a payload of any size for one SM2 public key
+// without buffering it, like SealEnvelope in pieces. The payload is
+// encrypted with SM4-CTR under a random session key and authenticated
+// with HMAC-SM3; the session key is encrypted with SM2 once.
+type EncryptStream struct {
+	w         io.Writer
+	stream    cipher.Stream
+	mac       hash.Hash
+	iv        []byte
+	wrapped   []byte
+	n         uint64
+	finalized bool
+	err       error // first write error, after which the stream is unusable
+}
+
+// NewEncryptStream returns an EncryptStream for pub that writes the
+// ciphertext to w. The header needed for decryption is returned by
+// Finalize, after all ciphertext has been written.
+func NewEncryptStream(pub *PublicKey, w io.Writer) (*EncryptStream, error) {
+	keys := make([]byte, sm4.BlockSize+sm3.Size)
+	if _, err := io.ReadFull(rand.Reader, keys); err != nil {
+		return nil, err
+	}
+	iv := make([]byte, sm4.BlockSize)
+	if _, err := io.ReadFull(rand.Reader, iv); err != nil {
+		return nil, err
+	}
+	wrapped, err := EncryptAsn1(pub, keys, rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	stream, mac, err := newStreamCipher(keys, iv)
+	if err != nil {
+		return nil, err
+	}
+	return &EncryptStream{w: w, stream: stream, mac: mac, iv: iv, wrapped: wrapped}, nil
+}
+
+func newStreamCipher(keys, iv []byte) (cipher.Stream, hash.Hash, error) {
+	block, err := sm4.NewCipher(keys[:sm4.BlockSize])
+	if err != nil {
+		return nil, nil, err
+	}
+	mac := sm3.NewHMAC(keys[sm4.BlockSize:])
+	mac.Write(iv)
+	return cipher.NewCTR(block, iv), mac, nil
+}
+
+// Write encrypts p and writes the ciphertext to the underlying writer.
+//
+// If the underlying writer fails or writes less than len(p), the keystream
+// has already moved past the bytes that were lost, so nothing written later
+// could be decrypted. The stream then keeps that error and returns it from
+// every later Write and from Finalize.
+func (s *EncryptStream) Write(p []byte) (int, error) {
+	if s.finalized {
+		return 0, errStreamFinalized
+	}
+	if s.err != nil {
+		return 0, s.err
+	}
+	buf := make([]byte, len(p))
+	s.stream.XORKeyStream(buf, p)
+	n, err := s.w.Write(buf)
+	if err == nil && n < len(p) {
+		err = io.ErrShortWrite
+	}
+	s.mac.Write(buf[:n])
+	s.n += uint64(n)
+	s.err = err
+	return n, err
+}
+
+// Finalize ends the stream and returns the DER encoded header, which holds
+// the encrypted session key and the MAC of the whole ciphertext and must
+// be passed to NewDecryptStream.
+func (s *EncryptStream) Finalize() ([]byte, error) {
+	if s.finalized {
+		return nil, errStreamFinalized
+	}
+	if s.err != nil {
+		return nil, s.err
+	}
+	s.finalized = true
+	return asn1.Marshal(streamHeader{
+		Version:      streamVersion,
+		EncryptedKey: s.wrapped,
+		IV:           s.iv,
+		MAC:          streamMAC(s.mac, s.n),
+	})
+}
+
+func streamMAC(mac hash.Hash, n uint64) []byte {
+	var l [8]byte
+	binary.BigEndian.PutUint64(l[:], n)
+	mac.Write(l[:])
+	return mac.Sum(nil)
+}
+
+// DecryptStream reverses EncryptStream.
+type DecryptStream struct {
+	w         io.Writer
+	stream    cipher.Stream
+	mac       hash.Hash
+	tag       []byte
+	n         uint64
+	finalized bool
+}
+
+// NewDecryptStream returns a DecryptStream that decrypts ciphertext from
+// the EncryptStream that produced header and writes the plaintext to w.
+//
+// Plaintext is written before the MAC can be checked. It must be treated
+// as untrusted, and discarded if Finalize reports an error.
+func NewDecryptStream(priv *PrivateKey, header []byte, w io.Writer) (*DecryptStream, error) {
+	var h streamHeader
+	rest, err := asn1.Unmarshal(header, &h)
+	if err != nil {
+		return nil, err
+	}
+	if len(rest) != 0 {
+		return nil, errors.New("SM2: trailing data after stream header")
+	}
+	if h.Version != streamVersion {
+		return nil, errors.New("SM2: unsupported stream version")
+	}
+	if len(h.IV) != sm4.BlockSize || len(h.MAC) != sm3.Size {
+		return nil, errors.New("SM2: malformed stream header")
+	}
+	keys, err := DecryptAsn1(priv, h.EncryptedKey)
+	if err != nil {
+		return nil, err
+	}
+	if len(keys) != sm4.BlockSize+sm3.Size {
+		return nil, errors.New("SM2: invalid stream key size")
+	}
+	stream, mac, err := newStreamCipher(keys, h.IV)
+	if err != nil {
+		return nil, err
+	}
+	return &DecryptStream{w: w, stream: stream, mac: mac, tag: h.MAC}, nil
+}
+
+// Write decrypts p and writes the plaintext to the underlying writer.
+func (s *DecryptStream) Write(p []byte) (int, error) {
+	if s.finalized {
+		return 0, errStreamFinalized
+	}
+	s.mac.Write(p)
+	s.n += uint64(len(p))
+	buf := make([]byte, len(p))
+	s.stream.XORKeyStream(buf, p)
+	if _, err := s.w.Write(buf); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Finalize checks the MAC of all ciphertext written. A nil error means the
+// whole plaintext was authentic and complete.
+func (s *DecryptStream) Finalize() error {
+	if s.finalized {
+		return errStreamFinalized
+	}
+	s.finalized = true
+	if subtle.ConstantTimeCompare(streamMAC(s.mac, s.n), s.tag) != 1 {
+		return errors.New("SM2: stream authentication failed")
+	}
+	return nil
+}
@@ -0,0 +1,132 @@
+package sm2
+
+import (
+	"bytes"
+	"crypto/rand"
+	"io"
+	"testing"
+)
+
+func TestStream(t *testing.T) {
+	priv, err := GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	payload := make([]byte, 100000)
+	if _, err := io.ReadFull(rand.Reader, payload); err != nil {
+		t.Fatal(err)
+	}
+
+	var ct bytes.Buffer
+	enc, err := NewEncryptStream(&priv.PublicKey, &ct)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for off, n := 0, 1; off < len(payload); off, n = off+n, n*3+1 {
+		end := off + n
+		if end > len(payload) {
+			end = len(payload)
+		}
+		if _, err := enc.Write(payload[off:end]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	header, err := enc.Finalize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := enc.Write([]byte("late")); err == nil {
+		t.Error("Write after Finalize accepted")
+	}
+	if bytes.Contains(ct.Bytes(), payload[:64]) {
+		t.Fatal("ciphertext contains the plaintext")
+	}
+
+	open := func(priv *PrivateKey, header, ct []byte) ([]byte, error) {
+		var pt bytes.Buffer
+		dec, err := NewDecryptStream(priv, header, &pt)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := io.CopyBuffer(dec, bytes.NewReader(ct), make([]byte, 777)); err != nil {
+			return nil, err
+		}
+		return pt.Bytes(), dec.Finalize()
+	}
+	got, err := open(priv, header, ct.Bytes())
+	if err != nil || !bytes.Equal(got, payload) {
+		t.Fatalf("stream round trip failed: %v", err)
+	}
+
+	tampered := append([]byte(nil), ct.Bytes()...)
+	tampered[5000] ^= 1
+	if _, err := open(priv, header, tampered); err == nil {
+		t.Error("tampered ciphertext authenticated")
+	}
+	if _, err := open(priv, header, ct.Bytes()[:len(payload)-1]); err == nil {
+		t.Error("truncated ciphertext authenticated")
+	}
+	other, err := GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := open(other, header, ct.Bytes()); err == nil {
+		t.Error("stream opened with the wrong key")
+	}
+	if _, err := NewDecryptStream(priv, header[:len(header)-1], io.Discard); err == nil {
+		t.Error("truncated header accepted")
+	}
+
+	// An empty stream still authenticates.
+	ct.Reset()
+	enc, err = NewEncryptStream(&priv.PublicKey, &ct)
+	if err != nil {
+		t.Fatal(err)
+	}
+	header, err = enc.Finalize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, err := open(priv, header, nil); err != nil || len(got) != 0 {
+		t.Errorf("empty stream: %v", err)
+	}
+}
+
+// shortWriter accepts at most limit bytes in total.
+type shortWriter struct {
+	buf   bytes.Buffer
+	limit int
+}
+
+func (w *shortWriter) Write(p []byte) (int, error) {
+	if n := w.limit - w.buf.Len(); len(p) > n {
+		w.buf.Write(p[:n])
+		return n, nil
+	}
+	return w.buf.Write(p)
+}
+
+func TestStreamShortWrite(t *testing.T) {
+	priv, err := GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := &shortWriter{limit: 10}
+	enc, err := NewEncryptStream(&priv.PublicKey, w)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n, err := enc.Write(make([]byte, 16)); n != 10 || err != io.ErrShortWrite {
+		t.Fatalf("got (%d, %v), want (10, io.ErrShortWrite)", n, err)
+	}
+	w.limit = 1000
+	if _, err := enc.Write([]byte("more")); err != io.ErrShortWrite {
+		t.Errorf("Write after a short write: got %v", err)
+	}
+	if _, err := enc.Finalize(); err != io.ErrShortWrite {
+		t.Errorf("Finalize after a short write: got %v", err)
+	}
+	if w.buf.Len() != 10 {
+		t.Errorf("%d bytes reached the writer, want 10", w.buf.Len())
+	}
+}
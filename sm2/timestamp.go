@@ -0,0 +1,105 @@
+package sm2
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/asn1"
+	"errors"
+	"math/big"
+	"time"
+
+	"github.com/tjfoc/gmsm/sm3"
+)
+
+// ErrTimestampInFuture is returned by VerifyWithTimestampSkew for a signing
+// time later than the verifier's clock plus the allowed skew.
+var ErrTimestampInFuture = errors.New("SM2: signing time is in the future")
+
+// timestampedContent is the structure actually signed by SignWithTimestamp:
+//
+//	TimestampedContent ::= SEQUENCE {
+//	    digest      OCTET STRING,    -- SM3(data)
+//	    signingTime GeneralizedTime }
+type timestampedContent struct {
+	Digest      []byte
+	SigningTime time.Time `asn1:"generalized"`
+}
+
+// timestampedSignature is the encoding returned by SignWithTimestamp:
+//
+//	TimestampedSignature ::= SEQUENCE {
+//	    signingTime GeneralizedTime,
+//	    r           INTEGER,
+//	    s           INTEGER }
+type timestampedSignature struct {
+	SigningTime time.Time `asn1:"generalized"`
+	R, S        *big.Int
+}
+
+func marshalTimestampedContent(data []byte, ts time.Time) ([]byte, error) {
+	return asn1.Marshal(timestampedContent{
+		Digest:      sm3.Sm3Sum(data),
+		SigningTime: ts,
+	})
+}
+
+// SignWithTimestamp signs data together with ts, so that neither can be
+// changed without invalidating the signature. The SM3 digest of data and
+// ts are encoded as a TimestampedContent and signed with uid (the default
+// uid if empty). ts is stored in UTC with one second precision.
+func SignWithTimestamp(priv *PrivateKey, data, uid []byte, ts time.Time) ([]byte, error) {
+	ts = ts.UTC().Truncate(time.Second)
+	content, err := marshalTimestampedContent(data, ts)
+	if err != nil {
+		return nil, err
+	}
+	r, s, err := Sm2Sign(priv, content, uid, rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	return asn1.Marshal(timestampedSignature{SigningTime: ts, R: r, S: s})
+}
+
+// VerifyWithTimestamp verifies a signature produced by SignWithTimestamp
+// and returns the signed timestamp. The timestamp is only as trustworthy as
+// the signer; it is not checked against the current time.
+func VerifyWithTimestamp(pub *PublicKey, data, uid, sig []byte) (time.Time, error) {
+	var ts timestampedSignature
+	rest, err := asn1.Unmarshal(sig, &ts)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if len(rest) != 0 {
+		return time.Time{}, errors.New("SM2: trailing data after timestamped signature")
+	}
+	if der, err := asn1.Marshal(ts); err != nil || !bytes.Equal(der, sig) {
+		return time.Time{}, errors.New("SM2: timestamped signature is not DER encoded")
+	}
+	content, err := marshalTimestampedContent(data, ts.SigningTime)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if !Sm2Verify(pub, content, uid, ts.R, ts.S) {
+		return time.Time{}, errors.New("SM2: timestamped signature verification failed")
+	}
+	return ts.SigningTime, nil
+}
+
+// VerifyWithTimestampSkew is like VerifyWithTimestamp but also rejects, with
+// ErrTimestampInFuture, signatures whose signing time is more than maxSkew
+// ahead of the local clock. Old timestamps are accepted; callers that need
+// a freshness window should compare the returned time themselves.
+func VerifyWithTimestampSkew(pub *PublicKey, data, uid, sig []byte, maxSkew time.Duration) (time.Time, error) {
+	return verifyWithTimestampAt(pub, data, uid, sig, maxSkew, time.Now())
+}
+
+func verifyWithTimestampAt(pub *PublicKey, data, uid, sig []byte, maxSkew time.Duration, now time.Time) (time.Time, error) {
+	ts, err := VerifyWithTimestamp(pub, data, uid, sig)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if ts.After(now.Add(maxSkew)) {
+		return time.Time{}, ErrTimestampInFuture
+	}
+	return ts, nil
+}
@@ -0,0 +1,92 @@
+package sm2
+
+import (
+	"crypto/rand"
+	"encoding/asn1"
+	"testing"
+	"time"
+)
+
+func TestSignWithTimestamp(t *testing.T) {
+	priv, err := GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := []byte("timestamped message")
+	uid := []byte("alice@example.com")
+	ts := time.Date(2024, 5, 17, 9, 30, 15, 500, time.FixedZone("CST", 8*3600))
+
+	sig, err := SignWithTimestamp(priv, data, uid, ts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := VerifyWithTimestamp(&priv.PublicKey, data, uid, sig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !got.Equal(ts.Truncate(time.Second)) {
+		t.Errorf("got timestamp %v, want %v", got, ts)
+	}
+
+	if _, err := VerifyWithTimestamp(&priv.PublicKey, []byte("other message"), uid, sig); err == nil {
+		t.Error("signature verified for different data")
+	}
+	if _, err := VerifyWithTimestamp(&priv.PublicKey, data, []byte("bob@example.com"), sig); err == nil {
+		t.Error("signature verified for a different uid")
+	}
+
+	var parsed timestampedSignature
+	if _, err := asn1.Unmarshal(sig, &parsed); err != nil {
+		t.Fatal(err)
+	}
+	parsed.SigningTime = parsed.SigningTime.Add(time.Second)
+	altered, err := asn1.Marshal(parsed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := VerifyWithTimestamp(&priv.PublicKey, data, uid, altered); err == nil {
+		t.Error("signature verified with an altered timestamp")
+	}
+}
+
+func TestVerifyWithTimestampSkew(t *testing.T) {
+	priv, err := GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := []byte("audit record")
+	now := time.Date(2024, 5, 17, 9, 30, 0, 0, time.UTC)
+	for _, tc := range []struct {
+		offset time.Duration
+		ok     bool
+	}{
+		{-24 * time.Hour, true},
+		{0, true},
+		{30 * time.Second, true},
+		{31 * time.Second, false},
+		{time.Hour, false},
+	} {
+		sig, err := SignWithTimestamp(priv, data, nil, now.Add(tc.offset))
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = verifyWithTimestampAt(&priv.PublicKey, data, nil, sig, 30*time.Second, now)
+		if tc.ok && err != nil {
+			t.Errorf("offset %v: %v", tc.offset, err)
+		}
+		if !tc.ok && err != ErrTimestampInFuture {
+			t.Errorf("offset %v: got %v, want ErrTimestampInFuture", tc.offset, err)
+		}
+	}
+
+	sig, err := SignWithTimestamp(priv, data, nil, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := VerifyWithTimestampSkew(&priv.PublicKey, data, nil, sig, time.Minute); err != nil {
+		t.Error(err)
+	}
+	if _, err := VerifyWithTimestampSkew(&priv.PublicKey, []byte("other"), nil, sig, time.Minute); err == nil || err == ErrTimestampInFuture {
+		t.Errorf("different data: got %v", err)
+	}
+}
@@ -0,0 +1,131 @@
+package sm2
+
+import (
+	"crypto/rand"
+	"errors"
+	"io"
+	"math/big"
+)
+
+// Two-party signing splits w = (1 + d)^-1 mod n multiplicatively between
+// two parties, w = share1 * share2 mod n, so that neither holds d. Since an
+// SM2 signature is
+//
+//	s = (1 + d)^-1 * (k + r) - r mod n
+//
+// the parties can compute it jointly:
+//
+//  1. Party 1 picks k1 and sends Q1 = k1*G and the message digest e
+//     (PartialSign1).
+//  2. Party 2 picks k2, k3, computes (x1, y1) = k3*Q1 + k2*G,
+//     r = e + x1 mod n, s2 = share2*k3 and s3 = share2*(r + k2), and sends
+//     r, s2, s3 back (PartialSign2).
+//  3. Party 1 computes s = share1*k1*s2 + share1*s3 - r (CombineSig).
+//
+// With k = k1*k3 + k2 this is exactly a normal SM2 signature, so it is
+// verified by VerifySignature and cannot be told apart from one produced
+// by Sign. Both parties must keep their share and nonces secret, and party
+// 2 should check that the digest it is asked to sign belongs to a message
+// it approves.
+
+// TwoPartyMessage1 is sent by party 1 to party 2 in PartialSign1.
+type TwoPartyMessage1 struct {
+	Q1X, Q1Y *big.Int
+	Digest   []byte
+}
+
+// TwoPartyMessage2 is sent by party 2 to party 1 in PartialSign2.
+type TwoPartyMessage2 struct {
+	R, S2, S3 *big.Int
+}
+
+var errTwoPartyRetry = errors.New("SM2: degenerate two-party signature, restart the protocol")
+
+// SplitKey splits priv into two multiplicative shares of (1 + d)^-1 for
+// two-party signing. The caller must hand each share to one party and then
+// destroy priv. It returns nil shares if the random source fails.
+func SplitKey(priv *PrivateKey) (share1, share2 *big.Int) {
+	n := priv.Curve.Params().N
+	w := new(big.Int).Add(priv.D, one)
+	if w.ModInverse(w, n) == nil {
+		return nil, nil
+	}
+	share1, err := randFieldElement(priv.Curve, rand.Reader)
+	if err != nil {
+		return nil, nil
+	}
+	share2 = new(big.Int).ModInverse(share1, n)
+	share2.Mul(share2, w)
+	share2.Mod(share2, n)
+	return share1, share2
+}
+
+// PartialSign1 starts a two-party signature over data for pub, using the
+// default uid. It returns the nonce k1, which party 1 keeps for CombineSig,
+// and the message for party 2. random may be nil to use rand.Reader.
+func PartialSign1(pub *PublicKey, data []byte, random io.Reader) (k1 *big.Int, msg *TwoPartyMessage1, err error) {
+	e, err := MessageDigest(pub, data, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	if k1, err = randFieldElement(pub.Curve, random); err != nil {
+		return nil, nil, err
+	}
+	x, y := pub.Curve.ScalarBaseMult(k1.Bytes())
+	return k1, &TwoPartyMessage1{Q1X: x, Q1Y: y, Digest: e}, nil
+}
+
+// PartialSign2 is party 2's step: it contributes share2 and fresh nonces
+// to the signature started by msg. random may be nil to use rand.Reader.
+func PartialSign2(share2 *big.Int, msg *TwoPartyMessage1, random io.Reader) (*TwoPartyMessage2, error) {
+	c := P256Sm2()
+	n := c.Params().N
+	if msg == nil || len(msg.Digest) != 32 || !isValidPoint(msg.Q1X, msg.Q1Y) {
+		return nil, errors.New("SM2: invalid two-party message")
+	}
+	e := new(big.Int).SetBytes(msg.Digest)
+	for {
+		k2, err := randFieldElement(c, random)
+		if err != nil {
+			return nil, err
+		}
+		k3, err := randFieldElement(c, random)
+		if err != nil {
+			return nil, err
+		}
+		x2, y2 := c.ScalarBaseMult(k2.Bytes())
+		x3, y3 := c.ScalarMult(msg.Q1X, msg.Q1Y, k3.Bytes())
+		x1, _ := c.Add(x2, y2, x3, y3)
+		r := x1.Add(x1, e)
+		r.Mod(r, n)
+		if r.Sign() == 0 {
+			continue
+		}
+		s2 := new(big.Int).Mul(share2, k3)
+		s2.Mod(s2, n)
+		s3 := new(big.Int).Add(r, k2)
+		s3.Mul(s3, share2)
+		s3.Mod(s3, n)
+		return &TwoPartyMessage2{R: r, S2: s2, S3: s3}, nil
+	}
+}
+
+// CombineSig is party 1's final step. It returns the ASN.1 encoded
+// signature, or an error asking for a restart in the rare case that the
+// result is degenerate.
+func CombineSig(share1, k1 *big.Int, msg *TwoPartyMessage2) ([]byte, error) {
+	n := P256Sm2().Params().N
+	if msg == nil || msg.R == nil || msg.S2 == nil || msg.S3 == nil {
+		return nil, errors.New("SM2: invalid two-party message")
+	}
+	s := new(big.Int).Mul(share1, k1)
+	s.Mul(s, msg.S2)
+	t := new(big.Int).Mul(share1, msg.S3)
+	s.Add(s, t)
+	s.Sub(s, msg.R)
+	s.Mod(s, n)
+	if s.Sign() == 0 || t.Add(s, msg.R).Cmp(n) == 0 {
+		return nil, errTwoPartyRetry
+	}
+	return SignDigitToSignData(msg.R, s)
+}
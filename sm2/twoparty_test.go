@@ -0,0 +1,59 @@
+package sm2
+
+import (
+	"crypto/rand"
+	"math/big"
+	"testing"
+)
+
+func TestTwoPartySign(t *testing.T) {
+	priv, err := GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	share1, share2 := SplitKey(priv)
+	if share1 == nil || share2 == nil {
+		t.Fatal("SplitKey failed")
+	}
+	n := P256Sm2().Params().N
+	w := new(big.Int).Mul(share1, share2)
+	w.Mod(w, n)
+	w.ModInverse(w, n)
+	w.Sub(w, one)
+	if w.Cmp(priv.D) != 0 {
+		t.Fatal("shares do not recombine to the key")
+	}
+	pub := &priv.PublicKey
+
+	for i := 0; i < 8; i++ {
+		data := []byte{'m', byte(i)}
+		k1, msg1, err := PartialSign1(pub, data, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		msg2, err := PartialSign2(share2, msg1, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sig, err := CombineSig(share1, k1, msg2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !VerifySignature(pub, data, sig) {
+			t.Fatalf("two-party signature %d does not verify", i)
+		}
+		if VerifySignature(pub, []byte("other"), sig) {
+			t.Fatal("two-party signature verifies for other data")
+		}
+	}
+
+	// A wrong share yields a signature that does not verify.
+	k1, msg1, _ := PartialSign1(pub, []byte("m"), nil)
+	msg2, _ := PartialSign2(new(big.Int).Add(share2, one), msg1, nil)
+	if sig, err := CombineSig(share1, k1, msg2); err == nil && VerifySignature(pub, []byte("m"), sig) {
+		t.Error("signature with a wrong share verifies")
+	}
+	if _, err := PartialSign2(share2, &TwoPartyMessage1{Q1X: one, Q1Y: one, Digest: msg1.Digest}, nil); err == nil {
+		t.Error("PartialSign2 accepted an invalid point")
+	}
+}
@@ -2,7 +2,11 @@ package sm2
 
 import (
 	"encoding/asn1"
+	"encoding/hex"
+	"errors"
 	"math/big"
+
+	"github.com/tjfoc/gmsm/sm3"
 )
 
 func Decompress(a []byte) *PublicKey {
@@ -41,6 +45,65 @@ func Compress(a *PublicKey) []byte {
 	return buf
 }
 
+// Marshal encodes pub in the uncompressed form 0x04 || X || Y with 32 byte
+// big-endian coordinates, the encoding used by crypto/elliptic.Marshal.
+func Marshal(pub *PublicKey) []byte {
+	buf := make([]byte, 65)
+	buf[0] = 0x04
+	putFixedBytes(buf[1:33], pub.X)
+	putFixedBytes(buf[33:65], pub.Y)
+	return buf
+}
+
+// Unmarshal parses a point encoded by Marshal. It rejects other encodings,
+// the point at infinity and points that are not on the SM2 curve.
+func Unmarshal(data []byte) (*PublicKey, error) {
+	if len(data) != 65 || data[0] != 0x04 {
+		return nil, errors.New("SM2: invalid uncompressed point encoding")
+	}
+	x := new(big.Int).SetBytes(data[1:33])
+	y := new(big.Int).SetBytes(data[33:65])
+	if !isValidPoint(x, y) {
+		return nil, errors.New("SM2: point is not on curve")
+	}
+	return &PublicKey{
+		Curve: P256Sm2(),
+		X:     x,
+		Y:     y,
+	}, nil
+}
+
+// Fingerprint returns the SM3 hash of the uncompressed encoding of pub as
+// produced by Marshal. It only depends on the coordinates, so it is stable
+// across the ways a key can be parsed or constructed.
+func (pub *PublicKey) Fingerprint() [32]byte {
+	var fp [32]byte
+	copy(fp[:], sm3.Sm3Sum(Marshal(pub)))
+	return fp
+}
+
+// FingerprintHex returns Fingerprint as a lowercase hex string.
+func (pub *PublicKey) FingerprintHex() string {
+	fp := pub.Fingerprint()
+	return hex.EncodeToString(fp[:])
+}
+
+// isValidPoint reports whether (x, y) is a finite point on the SM2 curve
+// with both coordinates reduced modulo p.
+func isValidPoint(x, y *big.Int) bool {
+	if x == nil || y == nil {
+		return false
+	}
+	p := P256Sm2().Params().P
+	if x.Sign() < 0 || y.Sign() < 0 || x.Cmp(p) >= 0 || y.Cmp(p) >= 0 {
+		return false
+	}
+	if x.Sign() == 0 && y.Sign() == 0 {
+		return false
+	}
+	return P256Sm2().IsOnCurve(x, y)
+}
+
 type sm2Signature struct {
 	R, S *big.Int
 }
@@ -0,0 +1,106 @@
+package sm2
+
+import (
+	"bytes"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/hex"
+	"math/big"
+	"testing"
+
+	"github.com/tjfoc/gmsm/sm3"
+)
+
+func TestMarshalUnmarshal(t *testing.T) {
+	priv, err := GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := Marshal(&priv.PublicKey)
+	if !bytes.Equal(data, elliptic.Marshal(P256Sm2(), priv.X, priv.Y)) {
+		t.Error("Marshal differs from elliptic.Marshal")
+	}
+	pub, err := Unmarshal(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pub.X.Cmp(priv.X) != 0 || pub.Y.Cmp(priv.Y) != 0 {
+		t.Error("Unmarshal returned a different point")
+	}
+
+	offCurve := append([]byte{}, data...)
+	offCurve[64] ^= 1
+	if _, err := Unmarshal(offCurve); err == nil {
+		t.Error("off-curve point accepted")
+	}
+	infinity := make([]byte, 65)
+	infinity[0] = 0x04
+	if _, err := Unmarshal(infinity); err == nil {
+		t.Error("point at infinity accepted")
+	}
+	if _, err := Unmarshal(data[:64]); err == nil {
+		t.Error("truncated point accepted")
+	}
+}
+
+func TestFingerprint(t *testing.T) {
+	priv, err := GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pub := &priv.PublicKey
+	fp := pub.Fingerprint()
+	if !bytes.Equal(fp[:], sm3.Sm3Sum(Marshal(pub))) {
+		t.Error("fingerprint is not SM3 over the uncompressed point")
+	}
+	if got := pub.FingerprintHex(); got != hex.EncodeToString(fp[:]) {
+		t.Errorf("FingerprintHex = %s", got)
+	}
+
+	// The same point reached through other encodings and big.Int values
+	// with different backing storage must give the same fingerprint.
+	parsed, err := Unmarshal(Marshal(pub))
+	if err != nil {
+		t.Fatal(err)
+	}
+	copied := &PublicKey{Curve: P256Sm2(), X: new(big.Int).Set(pub.X), Y: new(big.Int).Set(pub.Y)}
+	for name, k := range map[string]*PublicKey{
+		"Unmarshal":  parsed,
+		"Decompress": Decompress(Compress(pub)),
+		"copy":       copied,
+	} {
+		if k.Fingerprint() != fp {
+			t.Errorf("%s: fingerprint differs", name)
+		}
+	}
+
+	other, err := GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if other.PublicKey.Fingerprint() == fp {
+		t.Error("different keys share a fingerprint")
+	}
+}
+
+func TestOrderGenerator(t *testing.T) {
+	// GM/T 0003.5 recommended curve parameters.
+	n, _ := new(big.Int).SetString("FFFFFFFEFFFFFFFFFFFFFFFFFFFFFFFF7203DF6B21C6052B53BBF40939D54123", 16)
+	gx, _ := new(big.Int).SetString("32C4AE2C1F1981195F9904466A39C9948FE30BBFF2660BE1715A4589334C74C7", 16)
+	gy, _ := new(big.Int).SetString("BC3736A2F4F6779C59BDCEE36B692153D0A9877CC62A474002DF32E52139F0A0", 16)
+	if Order().Cmp(n) != 0 {
+		t.Errorf("Order() = %x", Order())
+	}
+	x, y := Generator()
+	if x.Cmp(gx) != 0 || y.Cmp(gy) != 0 {
+		t.Errorf("Generator() = (%x, %x)", x, y)
+	}
+
+	Order().SetInt64(1)
+	x.SetInt64(1)
+	y.SetInt64(1)
+	params := P256Sm2().Params()
+	if params.N.Cmp(n) != 0 || params.Gx.Cmp(gx) != 0 || params.Gy.Cmp(gy) != 0 {
+		t.Error("modifying the returned values changed the curve parameters")
+	}
+}
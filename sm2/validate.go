@@ -0,0 +1,58 @@
+package sm2
+
+import (
+	"errors"
+	"math/big"
+)
+
+// Errors returned by PublicKey.Validate and PrivateKey.Validate, one per
+// failed check.
+var (
+	ErrUnsupportedCurve     = errors.New("SM2: key does not use the SM2 curve")
+	ErrPointAtInfinity      = errors.New("SM2: public key is the point at infinity")
+	ErrPointNotOnCurve      = errors.New("SM2: public key is not on the curve")
+	ErrPointWrongOrder      = errors.New("SM2: public key does not have order n")
+	ErrPrivateKeyOutOfRange = errors.New("SM2: private key is not in [1, n-2]")
+	ErrPublicKeyMismatch    = errors.New("SM2: public key does not match the private key")
+)
+
+// Validate checks that pub is a usable SM2 public key: a point on the SM2
+// curve other than the identity, with [n]P equal to the identity. The
+// cofactor of the SM2 curve is 1, so the last check cannot fail for a point
+// on the curve, but it is asserted anyway as GM/T 0003 requires.
+func (pub *PublicKey) Validate() error {
+	if pub.Curve != nil && pub.Curve != P256Sm2() {
+		return ErrUnsupportedCurve
+	}
+	if pub.X == nil || pub.Y == nil || (pub.X.Sign() == 0 && pub.Y.Sign() == 0) {
+		return ErrPointAtInfinity
+	}
+	if !isValidPoint(pub.X, pub.Y) {
+		return ErrPointNotOnCurve
+	}
+	// The generic CurveParams arithmetic represents the identity as (0, 0),
+	// which the optimised implementation cannot return.
+	params := P256Sm2().Params()
+	if x, y := params.ScalarMult(pub.X, pub.Y, params.N.Bytes()); x.Sign() != 0 || y.Sign() != 0 {
+		return ErrPointWrongOrder
+	}
+	return nil
+}
+
+// Validate checks the public key as PublicKey.Validate does, that D is in
+// [1, n-2] (GM/T 0003 excludes n-1 because signing uses (1+D)^-1), and that
+// the public key equals [D]G.
+func (priv *PrivateKey) Validate() error {
+	if err := priv.PublicKey.Validate(); err != nil {
+		return err
+	}
+	n := P256Sm2().Params().N
+	if priv.D == nil || priv.D.Sign() <= 0 || priv.D.Cmp(new(big.Int).Sub(n, one)) >= 0 {
+		return ErrPrivateKeyOutOfRange
+	}
+	x, y := P256Sm2().ScalarBaseMult(priv.D.Bytes())
+	if x.Cmp(priv.X) != 0 || y.Cmp(priv.Y) != 0 {
+		return ErrPublicKeyMismatch
+	}
+	return nil
+}
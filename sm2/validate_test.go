@@ -0,0 +1,49 @@
+package sm2
+
+import (
+	"crypto/elliptic"
+	"crypto/rand"
+	"math/big"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	priv, err := GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := priv.Validate(); err != nil {
+		t.Fatalf("valid key rejected: %v", err)
+	}
+	if err := priv.PublicKey.Validate(); err != nil {
+		t.Fatalf("valid public key rejected: %v", err)
+	}
+
+	c := P256Sm2()
+	n := c.Params().N
+	other, _ := GenerateKey(rand.Reader)
+	withPub := func(x, y *big.Int) *PublicKey { return &PublicKey{Curve: c, X: x, Y: y} }
+	withD := func(d *big.Int) *PrivateKey { return &PrivateKey{PublicKey: priv.PublicKey, D: d} }
+
+	for _, tc := range []struct {
+		name string
+		err  error
+		got  error
+	}{
+		{"infinity", ErrPointAtInfinity, withPub(new(big.Int), new(big.Int)).Validate()},
+		{"nil coordinates", ErrPointAtInfinity, withPub(nil, nil).Validate()},
+		{"off curve", ErrPointNotOnCurve, withPub(priv.X, new(big.Int).Add(priv.Y, one)).Validate()},
+		{"unreduced", ErrPointNotOnCurve, withPub(new(big.Int).Add(priv.X, c.Params().P), priv.Y).Validate()},
+		{"other curve", ErrUnsupportedCurve, (&PublicKey{Curve: elliptic.P256(), X: priv.X, Y: priv.Y}).Validate()},
+		{"zero D", ErrPrivateKeyOutOfRange, withD(new(big.Int)).Validate()},
+		{"nil D", ErrPrivateKeyOutOfRange, withD(nil).Validate()},
+		{"D = n-1", ErrPrivateKeyOutOfRange, withD(new(big.Int).Sub(n, one)).Validate()},
+		{"D = n", ErrPrivateKeyOutOfRange, withD(new(big.Int).Set(n)).Validate()},
+		{"mismatch", ErrPublicKeyMismatch, withD(other.D).Validate()},
+		{"bad public", ErrPointNotOnCurve, (&PrivateKey{PublicKey: *withPub(priv.X, priv.X), D: priv.D}).Validate()},
+	} {
+		if tc.got != tc.err {
+			t.Errorf("%s: got %v, want %v", tc.name, tc.got, tc.err)
+		}
+	}
+}
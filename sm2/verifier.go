@@ -0,0 +1,143 @@
+package sm2
+
+import (
+	"math/big"
+
+	"github.com/tjfoc/gmsm/sm3"
+	"golang.org/x/crypto/cryptobyte"
+	cbasn1 "golang.org/x/crypto/cryptobyte/asn1"
+)
+
+// sm2P256CombTable holds the multiples of a point in the layout of
+// sm2P256Precomputed, for sm2P256ScalarMultComb.
+type sm2P256CombTable [2 * 15 * 2 * 9]uint32
+
+// Verifier verifies many signatures under one public key and user ID. Like
+// Signer it computes ZA once, and it also precomputes a table of multiples
+// of the public point, so the t*P multiplication of each verification costs
+// about as much as the s*G one instead of several times more.
+type Verifier struct {
+	pub   *PublicKey
+	za    []byte
+	table *sm2P256CombTable
+}
+
+// NewVerifier returns a Verifier for pub using uid, or the default user ID
+// when uid is empty. If pub is not a valid SM2 public key, or uid is too
+// long, the Verifier rejects every signature.
+func NewVerifier(pub *PublicKey, uid []byte) *Verifier {
+	v := &Verifier{pub: pub}
+	if pub == nil || !isValidPoint(pub.X, pub.Y) {
+		return v
+	}
+	if _, ok := pub.Curve.(sm2P256Curve); !ok {
+		return v
+	}
+	if len(uid) == 0 {
+		uid = default_uid
+	}
+	za, err := ZA(pub, uid)
+	if err != nil {
+		return v
+	}
+	v.za = za
+	v.table = newCombTable(pub.X, pub.Y)
+	return v
+}
+
+// newCombTable computes the table of (x, y). Entry i of the first half is
+// sum(2^(64k) * (x, y)) over the bits k set in i, and the second half is
+// 2^32 times the first, matching the bits sm2P256ScalarMultComb reads.
+func newCombTable(x, y *big.Int) *sm2P256CombTable {
+	// d[m] = 2^(32m) * (x, y) in Jacobian coordinates.
+	var d [8][3]sm2P256FieldElement
+	sm2P256FromBig(&d[0][0], x)
+	sm2P256FromBig(&d[0][1], y)
+	d[0][2] = sm2P256Factor[1]
+	for m := 1; m < 8; m++ {
+		d[m] = d[m-1]
+		for i := 0; i < 32; i++ {
+			sm2P256PointDouble(&d[m][0], &d[m][1], &d[m][2], &d[m][0], &d[m][1], &d[m][2])
+		}
+	}
+
+	table := new(sm2P256CombTable)
+	for j := 0; j < 2; j++ {
+		var entries [16][3]sm2P256FieldElement
+		for i := 1; i < 16; i++ {
+			// Add the point of the lowest set bit to the entry without it.
+			// The multiples are distinct and non-zero, so no special case
+			// of the addition formulas is hit.
+			k := 0
+			for i>>k&1 == 0 {
+				k++
+			}
+			q := d[2*k+j]
+			if rest := i &^ (1 << k); rest == 0 {
+				entries[i] = q
+			} else {
+				e := &entries[rest]
+				sm2P256PointAdd(&e[0], &e[1], &e[2], &q[0], &q[1], &q[2], &entries[i][0], &entries[i][1], &entries[i][2])
+			}
+			var ax, ay sm2P256FieldElement
+			sm2P256PointToAffine(&ax, &ay, &entries[i][0], &entries[i][1], &entries[i][2])
+			off := (j*15 + i - 1) * 18
+			copy(table[off:off+9], ax[:])
+			copy(table[off+9:off+18], ay[:])
+		}
+	}
+	return table
+}
+
+// Verify reports whether sig is a valid ASN.1 SM2 signature of data under
+// the Verifier's key and user ID.
+func (v *Verifier) Verify(data, sig []byte) bool {
+	if v.table == nil {
+		return false
+	}
+	var (
+		r, s  = new(big.Int), new(big.Int)
+		inner cryptobyte.String
+	)
+	input := cryptobyte.String(sig)
+	if !input.ReadASN1(&inner, cbasn1.SEQUENCE) ||
+		!input.Empty() ||
+		!inner.ReadASN1Integer(r) ||
+		!inner.ReadASN1Integer(s) ||
+		!inner.Empty() {
+		return false
+	}
+	N := sm2P256.N
+	if r.Sign() <= 0 || s.Sign() <= 0 || r.Cmp(N) >= 0 || s.Cmp(N) >= 0 {
+		return false
+	}
+	t := new(big.Int).Add(r, s)
+	if t.Cmp(N) >= 0 {
+		t.Sub(t, N)
+	}
+	if t.Sign() == 0 {
+		return false
+	}
+
+	var k [32]byte
+	var x1, y1, z1, x2, y2, z2, x3, y3, z3 sm2P256FieldElement
+	sm2P256GetScalar(&k, s.Bytes())
+	sm2P256ScalarBaseMult(&x1, &y1, &z1, &k)
+	k = [32]byte{}
+	sm2P256GetScalar(&k, t.Bytes())
+	sm2P256ScalarMultComb(&x2, &y2, &z2, &k, v.table[:])
+	sm2P256PointAdd(&x1, &y1, &z1, &x2, &y2, &z2, &x3, &y3, &z3)
+	if sm2P256IsZero(&z3) {
+		return false
+	}
+	var ax, ay sm2P256FieldElement
+	sm2P256PointToAffine(&ax, &ay, &x3, &y3, &z3)
+
+	h := sm3.New()
+	h.Write(v.za)
+	h.Write(data)
+	x := sm2P256ToBig(&ax)
+	x.Add(x, new(big.Int).SetBytes(h.Sum(nil)))
+	x.Mod(x, N)
+	return x.Cmp(r) == 0
+}
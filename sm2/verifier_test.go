@@ -0,0 +1,117 @@
+package sm2
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"testing"
+)
+
+func TestCombTableOfGenerator(t *testing.T) {
+	gx, gy := Generator()
+	table := newCombTable(gx, gy)
+	for _, k := range []string{"01", "02", "deadbeef", "fffffffeffffffffffffffffffffffff7203df6b21c6052b53bbf40939d54122"} {
+		var b [32]byte
+		kb, _ := hex.DecodeString(k)
+		sm2P256GetScalar(&b, kb)
+		var x1, y1, z1, x2, y2, z2 sm2P256FieldElement
+		sm2P256ScalarBaseMult(&x1, &y1, &z1, &b)
+		sm2P256ScalarMultComb(&x2, &y2, &z2, &b, table[:])
+		ax, ay := sm2P256ToAffine(&x1, &y1, &z1)
+		bx, by := sm2P256ToAffine(&x2, &y2, &z2)
+		if ax.Cmp(bx) != 0 || ay.Cmp(by) != 0 {
+			t.Errorf("k = %s: comb table of G disagrees with sm2P256Precomputed", k)
+		}
+	}
+}
+
+func TestVerifier(t *testing.T) {
+	priv, err := GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	uid := []byte("alice@example.com")
+	v := NewVerifier(&priv.PublicKey, uid)
+	def := NewVerifier(&priv.PublicKey, nil)
+	for i := 0; i < 50; i++ {
+		msg := []byte(fmt.Sprintf("message %d", i))
+		r, s, err := Sm2Sign(priv, msg, uid, rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sig, _ := SignDigitToSignData(r, s)
+		if !v.Verify(msg, sig) {
+			t.Fatalf("message %d: valid signature rejected", i)
+		}
+		if v.Verify([]byte("other"), sig) {
+			t.Fatalf("message %d: signature verified for other data", i)
+		}
+		if def.Verify(msg, sig) {
+			t.Fatalf("message %d: signature verified with the default uid", i)
+		}
+		s.Add(s, one)
+		bad, _ := SignDigitToSignData(r, s)
+		if v.Verify(msg, bad) != Sm2Verify(&priv.PublicKey, msg, uid, r, s) {
+			t.Fatalf("message %d: Verifier and Sm2Verify disagree on a modified signature", i)
+		}
+	}
+
+	msg := []byte("default uid")
+	sig, err := priv.Sign(rand.Reader, msg, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !def.Verify(msg, sig) {
+		t.Error("default uid signature rejected")
+	}
+	if def.Verify(msg, sig[:len(sig)-1]) || def.Verify(msg, append(sig, 0)) {
+		t.Error("malformed signature accepted")
+	}
+	n, _ := SignDigitToSignData(Order(), one)
+	if def.Verify(msg, n) {
+		t.Error("r = n accepted")
+	}
+
+	offCurve := priv.PublicKey
+	offCurve.Y = new(big.Int).Add(priv.Y, one)
+	if NewVerifier(&offCurve, nil).Verify(msg, sig) {
+		t.Error("Verifier for an invalid key accepted a signature")
+	}
+}
+
+func BenchmarkVerifier(b *testing.B) {
+	priv, err := GenerateKey(rand.Reader)
+	if err != nil {
+		b.Fatal(err)
+	}
+	const n = 1000
+	msgs := make([][]byte, n)
+	sigs := make([][]byte, n)
+	for i := range msgs {
+		msgs[i] = []byte(fmt.Sprintf("message %d", i))
+		if sigs[i], err = priv.Sign(rand.Reader, msgs[i], nil); err != nil {
+			b.Fatal(err)
+		}
+	}
+	b.Run("VerifySignature", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if !VerifySignature(&priv.PublicKey, msgs[i%n], sigs[i%n]) {
+				b.Fatal("verification failed")
+			}
+		}
+	})
+	b.Run("Verifier", func(b *testing.B) {
+		v := NewVerifier(&priv.PublicKey, nil)
+		for i := 0; i < b.N; i++ {
+			if !v.Verify(msgs[i%n], sigs[i%n]) {
+				b.Fatal("verification failed")
+			}
+		}
+	})
+	b.Run("NewVerifier", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			NewVerifier(&priv.PublicKey, nil)
+		}
+	})
+}
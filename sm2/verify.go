@@ -0,0 +1,130 @@
+package sm2
+
+import (
+	"errors"
+	"math/big"
+	"sync"
+
+	"golang.org/x/crypto/cryptobyte"
+	cbasn1 "golang.org/x/crypto/cryptobyte/asn1"
+)
+
+// verifyScratch holds the big.Int buffers of one signature verification.
+type verifyScratch struct {
+	t, x1, y1, x2, y2 big.Int
+	k                 [32]byte
+}
+
+var verifyPool = sync.Pool{
+	New: func() interface{} {
+		return new(verifyScratch)
+	},
+}
+
+// Errors returned by VerifyDetailed for signatures that are structurally
+// invalid, as opposed to well-formed signatures that do not match.
+var (
+	ErrSignatureMalformed       = errors.New("SM2: malformed ASN.1 signature")
+	ErrSignatureOutOfRange      = errors.New("SM2: signature r or s not in [1, n-1], or r + s = n")
+	ErrSignaturePointAtInfinity = errors.New("SM2: signature verification reached the point at infinity")
+)
+
+// verifyWithE checks the signature (r, s) against the hashed message e. r
+// and s must already be in [1, n-1]. On the SM2 curve all intermediate
+// values live in pooled buffers, so a verification allocates very little.
+func verifyWithE(pub *PublicKey, e, r, s *big.Int) bool {
+	ok, _ := verifyWithEDetailed(pub, e, r, s)
+	return ok
+}
+
+// verifyWithEDetailed is verifyWithE, additionally reporting why a
+// signature that is not a plain mismatch was rejected.
+func verifyWithEDetailed(pub *PublicKey, e, r, s *big.Int) (bool, error) {
+	c := pub.Curve
+	N := c.Params().N
+	// Reject public keys off the curve before any scalar multiplication.
+	if !isValidPoint(pub.X, pub.Y) {
+		return false, ErrPointNotOnCurve
+	}
+
+	sc := verifyPool.Get().(*verifyScratch)
+	defer verifyPool.Put(sc)
+
+	// r, s < N, so a single subtraction reduces t.
+	t := sc.t.Add(r, s)
+	if t.Cmp(N) >= 0 {
+		t.Sub(t, N)
+	}
+	if t.Sign() == 0 {
+		return false, ErrSignatureOutOfRange
+	}
+
+	var x, y *big.Int
+	if _, ok := c.(sm2P256Curve); ok {
+		s.FillBytes(sc.k[:])
+		scalarBaseMultInto(&sc.x1, &sc.y1, sc.k[:])
+		t.FillBytes(sc.k[:])
+		scalarMultInto(&sc.x2, &sc.y2, pub.X, pub.Y, sc.k[:])
+		addInto(&sc.x1, &sc.y1, &sc.x1, &sc.y1, &sc.x2, &sc.y2)
+		x, y = &sc.x1, &sc.y1
+	} else {
+		x1, y1 := c.ScalarBaseMult(s.Bytes())
+		x2, y2 := c.ScalarMult(pub.X, pub.Y, t.Bytes())
+		x, y = c.Add(x1, y1, x2, y2)
+	}
+	if x.Sign() == 0 && y.Sign() == 0 {
+		return false, ErrSignaturePointAtInfinity
+	}
+
+	x.Add(x, e)
+	x.Mod(x, N)
+	return x.Cmp(r) == 0, nil
+}
+
+// VerifyDetailed verifies an ASN.1 signature over data with the default
+// user ID, like VerifySignature, but explains rejections: a well-formed
+// signature that does not match data and pub gives (false, nil), while
+// malformed encodings, out of range values, an invalid public key or a
+// computation reaching the point at infinity give (false, err).
+func VerifyDetailed(pub *PublicKey, data, sig []byte) (bool, error) {
+	var (
+		r, s  = &big.Int{}, &big.Int{}
+		inner cryptobyte.String
+	)
+	input := cryptobyte.String(sig)
+	if !input.ReadASN1(&inner, cbasn1.SEQUENCE) ||
+		!input.Empty() ||
+		!inner.ReadASN1Integer(r) ||
+		!inner.ReadASN1Integer(s) ||
+		!inner.Empty() {
+		return false, ErrSignatureMalformed
+	}
+	N := pub.Curve.Params().N
+	if r.Sign() <= 0 || s.Sign() <= 0 || r.Cmp(N) >= 0 || s.Cmp(N) >= 0 {
+		return false, ErrSignatureOutOfRange
+	}
+	e, err := MessageDigest(pub, data, nil)
+	if err != nil {
+		return false, err
+	}
+	return verifyWithEDetailed(pub, new(big.Int).SetBytes(e), r, s)
+}
+
+// VerifyRaw verifies a 64 byte r || s signature, as produced by many
+// hardware tokens, over data with the default user ID. It returns false
+// for any other signature length.
+func VerifyRaw(pub *PublicKey, data, rawSig []byte) bool {
+	return VerifyRawWithID(pub, data, nil, rawSig)
+}
+
+// VerifyRawWithID is like VerifyRaw for user ID uid, or the default user ID
+// if uid is empty.
+func VerifyRawWithID(pub *PublicKey, data, uid, rawSig []byte) bool {
+	if len(rawSig) != 64 {
+		return false
+	}
+	var r, s big.Int
+	r.SetBytes(rawSig[:32])
+	s.SetBytes(rawSig[32:])
+	return Sm2Verify(pub, data, uid, &r, &s)
+}
@@ -0,0 +1,170 @@
+package sm2
+
+import (
+	"crypto/rand"
+	"math/big"
+	"testing"
+)
+
+// TestVerifyMatchesGeneric checks the pooled fast path against the generic
+// curve arithmetic of elliptic.CurveParams over many random signatures.
+func TestVerifyMatchesGeneric(t *testing.T) {
+	priv, err := GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	generic := priv.PublicKey
+	generic.Curve = P256Sm2().Params()
+
+	msg := make([]byte, 32)
+	for i := 0; i < 32; i++ {
+		rand.Read(msg)
+		r, s, err := Sm2Sign(priv, msg, nil, rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !Sm2Verify(&priv.PublicKey, msg, nil, r, s) {
+			t.Fatalf("signature %d does not verify", i)
+		}
+		if !Sm2Verify(&generic, msg, nil, r, s) {
+			t.Fatalf("signature %d does not verify on the generic curve", i)
+		}
+		bad := new(big.Int).Add(s, one)
+		if Sm2Verify(&priv.PublicKey, msg, nil, r, bad) != Sm2Verify(&generic, msg, nil, r, bad) {
+			t.Fatalf("signature %d: fast and generic paths disagree on a bad s", i)
+		}
+		msg[0] ^= 1
+		if Sm2Verify(&priv.PublicKey, msg, nil, r, s) {
+			t.Fatalf("signature %d verifies for a modified message", i)
+		}
+	}
+}
+
+func TestScalarMultMatchesGeneric(t *testing.T) {
+	c := P256Sm2()
+	params := c.Params()
+	var k [32]byte
+	for i := 0; i < 32; i++ {
+		rand.Read(k[:])
+		px, py := params.ScalarBaseMult(k[:])
+		rand.Read(k[:])
+		x1, y1 := c.ScalarMult(px, py, k[:])
+		x2, y2 := params.ScalarMult(px, py, k[:])
+		if x1.Cmp(x2) != 0 || y1.Cmp(y2) != 0 {
+			t.Fatalf("ScalarMult mismatch for k = %x", k)
+		}
+	}
+}
+
+func BenchmarkSM2Verify(b *testing.B) {
+	priv, err := GenerateKey(rand.Reader)
+	if err != nil {
+		b.Fatal(err)
+	}
+	msg := []byte("benchmark verify")
+	sig, err := priv.Sign(rand.Reader, msg, nil)
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if !priv.PublicKey.Verify(msg, sig) {
+			b.Fatal("verify failed")
+		}
+	}
+}
+
+func TestVerifyRaw(t *testing.T) {
+	priv, err := GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg := []byte("fixed width signature")
+	uid := []byte("alice@example.com")
+	raw := func(uid []byte) []byte {
+		r, s, err := Sm2Sign(priv, msg, uid, rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sig := make([]byte, 64)
+		r.FillBytes(sig[:32])
+		s.FillBytes(sig[32:])
+		return sig
+	}
+
+	sig := raw(nil)
+	if !VerifyRaw(&priv.PublicKey, msg, sig) {
+		t.Error("valid raw signature rejected")
+	}
+	if VerifyRaw(&priv.PublicKey, []byte("other"), sig) {
+		t.Error("raw signature accepted for another message")
+	}
+	if VerifyRaw(&priv.PublicKey, msg, sig[:63]) || VerifyRaw(&priv.PublicKey, msg, append(sig, 0)) {
+		t.Error("raw signature of the wrong length accepted")
+	}
+	if VerifyRaw(&priv.PublicKey, msg, make([]byte, 64)) {
+		t.Error("all-zero raw signature accepted")
+	}
+
+	sig = raw(uid)
+	if !VerifyRawWithID(&priv.PublicKey, msg, uid, sig) {
+		t.Error("valid raw signature with uid rejected")
+	}
+	if VerifyRaw(&priv.PublicKey, msg, sig) {
+		t.Error("raw signature for a custom uid verifies with the default uid")
+	}
+}
+
+func TestVerifyDetailed(t *testing.T) {
+	priv, err := GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pub := &priv.PublicKey
+	msg := []byte("detailed verification")
+	sig, err := SignDataWithRand(priv, msg, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := VerifyDetailed(pub, msg, sig); !ok || err != nil {
+		t.Errorf("valid signature: got %v, %v", ok, err)
+	}
+	if ok, err := VerifyDetailed(pub, []byte("other"), sig); ok || err != nil {
+		t.Errorf("mismatch: got %v, %v; want false, nil", ok, err)
+	}
+
+	N := P256Sm2().Params().N
+	encode := func(r, s *big.Int) []byte {
+		b, err := SignDigitToSignData(r, s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return b
+	}
+	// With r = 1 and s = -d/(1+d), s*G + (r+s)*P is the point at infinity.
+	s := new(big.Int).Add(priv.D, big.NewInt(1))
+	s.ModInverse(s, N)
+	s.Mul(s, priv.D)
+	s.Neg(s)
+	s.Mod(s, N)
+	offCurve := &PublicKey{Curve: pub.Curve, X: pub.X, Y: new(big.Int).Add(pub.Y, big.NewInt(1))}
+	for _, c := range []struct {
+		name string
+		pub  *PublicKey
+		sig  []byte
+		want error
+	}{
+		{"garbage", pub, []byte("not a signature"), ErrSignatureMalformed},
+		{"trailing data", pub, append(append([]byte(nil), sig...), 0), ErrSignatureMalformed},
+		{"r = 0", pub, encode(big.NewInt(0), big.NewInt(1)), ErrSignatureOutOfRange},
+		{"s = n", pub, encode(big.NewInt(1), N), ErrSignatureOutOfRange},
+		{"r + s = n", pub, encode(big.NewInt(1), new(big.Int).Sub(N, big.NewInt(1))), ErrSignatureOutOfRange},
+		{"infinity", pub, encode(big.NewInt(1), s), ErrSignaturePointAtInfinity},
+		{"public key off the curve", offCurve, sig, ErrPointNotOnCurve},
+	} {
+		if ok, err := VerifyDetailed(c.pub, msg, c.sig); ok || err != c.want {
+			t.Errorf("%s: got %v, %v; want false, %v", c.name, ok, err, c.want)
+		}
+	}
+}
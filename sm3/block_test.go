@@ -0,0 +1,48 @@
+package sm3
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestBlockMatchesGeneric(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 100; i++ {
+		msg := make([]byte, 64*rng.Intn(20))
+		rng.Read(msg)
+		var got, want [8]uint32
+		for j := range got {
+			got[j] = rng.Uint32()
+		}
+		want = got
+		block(&got, msg)
+		blockGeneric(&want, msg)
+		if got != want {
+			t.Fatalf("%d blocks: got %08x, want %08x", len(msg)/64, got, want)
+		}
+	}
+	// Trailing partial blocks are ignored.
+	var got, want [8]uint32
+	block(&got, make([]byte, 100))
+	blockGeneric(&want, make([]byte, 64))
+	if got != want {
+		t.Error("partial block was hashed")
+	}
+}
+
+func BenchmarkBlock(b *testing.B) {
+	msg := make([]byte, 8192)
+	var dig [8]uint32
+	b.Run("Generic", func(b *testing.B) {
+		b.SetBytes(int64(len(msg)))
+		for i := 0; i < b.N; i++ {
+			blockGeneric(&dig, msg)
+		}
+	})
+	b.Run("Dispatch", func(b *testing.B) {
+		b.SetBytes(int64(len(msg)))
+		for i := 0; i < b.N; i++ {
+			block(&dig, msg)
+		}
+	})
+}
@@ -0,0 +1,76 @@
+package sm3
+
+import (
+	"database/sql/driver"
+	"encoding/hex"
+	"errors"
+	"fmt"
+)
+
+// Size is the length in bytes of an SM3 digest.
+const Size = 32
+
+// Digest is an SM3 digest, e.g. Digest(Sum(data)). It prints as lowercase
+// hex and is stored through database/sql as its raw 32 bytes; convert it to
+// HexDigest to store the hex form instead.
+type Digest [Size]byte
+
+// HexDigest is a Digest that is stored through database/sql as a 64
+// character hex string.
+type HexDigest Digest
+
+// String returns the digest in lowercase hex.
+func (d Digest) String() string {
+	return hex.EncodeToString(d[:])
+}
+
+// Value implements driver.Valuer, returning the raw 32 bytes.
+func (d Digest) Value() (driver.Value, error) {
+	return d[:], nil
+}
+
+// Scan implements sql.Scanner. It accepts the raw 32 bytes as well as the
+// hex form given as string or []byte.
+func (d *Digest) Scan(src interface{}) error {
+	var b []byte
+	switch v := src.(type) {
+	case []byte:
+		b = v
+	case string:
+		b = []byte(v)
+	case nil:
+		return errors.New("SM3: cannot scan NULL into Digest")
+	default:
+		return fmt.Errorf("SM3: cannot scan %T into Digest", src)
+	}
+	switch len(b) {
+	case Size:
+		if _, ok := src.(string); !ok {
+			copy(d[:], b)
+			return nil
+		}
+	case 2 * Size:
+		var tmp Digest
+		if _, err := hex.Decode(tmp[:], b); err != nil {
+			return errors.New("SM3: invalid hex digest: " + err.Error())
+		}
+		*d = tmp
+		return nil
+	}
+	return fmt.Errorf("SM3: cannot scan %d bytes into Digest", len(b))
+}
+
+// String returns the digest in lowercase hex.
+func (d HexDigest) String() string {
+	return Digest(d).String()
+}
+
+// Value implements driver.Valuer, returning the hex string.
+func (d HexDigest) Value() (driver.Value, error) {
+	return Digest(d).String(), nil
+}
+
+// Scan implements sql.Scanner and accepts the same forms as Digest.Scan.
+func (d *HexDigest) Scan(src interface{}) error {
+	return (*Digest)(d).Scan(src)
+}
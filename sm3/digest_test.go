@@ -0,0 +1,58 @@
+package sm3
+
+import (
+	"bytes"
+	"database/sql"
+	"database/sql/driver"
+	"testing"
+)
+
+var (
+	_ sql.Scanner   = (*Digest)(nil)
+	_ driver.Valuer = Digest{}
+	_ sql.Scanner   = (*HexDigest)(nil)
+	_ driver.Valuer = HexDigest{}
+)
+
+func TestDigestSQL(t *testing.T) {
+	d := Digest(Sum([]byte("abc")))
+	const hexAbc = "66c7f0f462eeedd9d1f2d46bdc10e4e24167c4875cf2f7a2297da02b8f4ba8e0"
+	if d.String() != hexAbc {
+		t.Errorf("String() = %s", d)
+	}
+
+	v, err := d.Value()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if raw, ok := v.([]byte); !ok || !bytes.Equal(raw, d[:]) {
+		t.Errorf("Value() = %#v, want raw bytes", v)
+	}
+	hv, err := HexDigest(d).Value()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hv != hexAbc {
+		t.Errorf("HexDigest.Value() = %#v", hv)
+	}
+
+	for _, src := range []interface{}{d[:], hexAbc, []byte(hexAbc), v, hv} {
+		var got Digest
+		if err := got.Scan(src); err != nil {
+			t.Errorf("Scan(%#v): %v", src, err)
+		} else if got != d {
+			t.Errorf("Scan(%#v) = %s", src, got)
+		}
+		var gotHex HexDigest
+		if err := gotHex.Scan(src); err != nil || Digest(gotHex) != d {
+			t.Errorf("HexDigest.Scan(%#v) = %s, %v", src, gotHex, err)
+		}
+	}
+
+	for _, src := range []interface{}{nil, 42, "abc", hexAbc[:62] + "zz", d[:31], string(d[:])} {
+		var got Digest
+		if err := got.Scan(src); err == nil {
+			t.Errorf("Scan(%#v) accepted", src)
+		}
+	}
+}
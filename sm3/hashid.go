@@ -0,0 +1,30 @@
+package sm3
+
+import (
+	"crypto"
+	"hash"
+)
+
+// Hash identifies SM3 as a crypto.Hash, so it can be passed as
+// crypto.SignerOpts or stored where generic code expects a crypto.Hash.
+//
+// The value 0x534d33 is "SM3" in ASCII. The standard library assigns its
+// identifiers sequentially from 1 and has about twenty, so this value will
+// not collide with a future one. Because
+// crypto.RegisterHash only accepts those predefined values, SM3 cannot be
+// registered with it: Hash.Available reports false, Hash.New and Hash.Size
+// panic, and Hash.String reports an unknown hash value. Generic code that
+// checks len(digest) == opts.HashFunc().Size() therefore panics for SM3;
+// use NewHash to construct hashers from a crypto.Hash that may be SM3, and
+// the constant Size for the digest length.
+const Hash crypto.Hash = 0x534d33
+
+// NewHash returns a new hash.Hash for h, handling Hash as well as the hash
+// functions registered with the crypto package. Like crypto.Hash.New it
+// panics if h is unavailable.
+func NewHash(h crypto.Hash) hash.Hash {
+	if h == Hash {
+		return New()
+	}
+	return h.New()
+}
@@ -0,0 +1,62 @@
+package sm3
+
+// HKDFExtract is HKDF-Extract (RFC 5869) with HMAC-SM3. A nil salt is
+// treated as Size zero bytes.
+func HKDFExtract(salt, ikm []byte) []byte {
+	if salt == nil {
+		salt = make([]byte, Size)
+	}
+	return SM3HMAC(salt, ikm)
+}
+
+// HKDFExpand is HKDF-Expand (RFC 5869) with HMAC-SM3. It panics if length
+// is negative or larger than 255*Size.
+func HKDFExpand(prk, info []byte, length int) []byte {
+	if length < 0 || length > 255*Size {
+		panic("SM3: invalid HKDF length")
+	}
+	out := make([]byte, 0, length+Size)
+	h := NewHMAC(prk)
+	var t []byte
+	for ctr := byte(1); len(out) < length; ctr++ {
+		h.Reset()
+		h.Write(t)
+		h.Write(info)
+		h.Write([]byte{ctr})
+		t = h.Sum(t[:0])
+		out = append(out, t...)
+	}
+	return out[:length]
+}
+
+// HKDF derives length bytes from secret with HKDF (RFC 5869) over HMAC-SM3:
+// HKDFExpand(HKDFExtract(salt, secret), info, length). Like HKDFExpand it
+// panics if length is negative or larger than 255*Size.
+func HKDF(secret, salt, info []byte, length int) []byte {
+	return HKDFExpand(HKDFExtract(salt, secret), info, length)
+}
+
+// TLSKeySchedule is HKDF-Expand-Label (RFC 8446, section 7.1) with
+// HMAC-SM3, as used by the TLS_SM4_GCM_SM3 suite of RFC 8998:
+//
+//	HKDF-Expand(secret, uint16(length) || "tls13 " + label || context, length)
+//
+// where label and context are each prefixed by a one byte length. It
+// panics if length does not fit HKDF-Expand or label or context exceed
+// their one byte length prefix.
+func TLSKeySchedule(secret, label, context []byte, length int) []byte {
+	const prefix = "tls13 "
+	if len(prefix)+len(label) > 255 || len(context) > 255 {
+		panic("SM3: TLS label or context too long")
+	}
+	if length < 0 || length > 0xffff {
+		panic("SM3: invalid HKDF length")
+	}
+	info := make([]byte, 0, 4+len(prefix)+len(label)+len(context))
+	info = append(info, byte(length>>8), byte(length), byte(len(prefix)+len(label)))
+	info = append(info, prefix...)
+	info = append(info, label...)
+	info = append(info, byte(len(context)))
+	info = append(info, context...)
+	return HKDFExpand(secret, info, length)
+}
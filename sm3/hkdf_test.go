@@ -0,0 +1,67 @@
+package sm3
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+)
+
+// Expected values computed with OpenSSL 3:
+// openssl kdf -kdfopt digest:SM3 -kdfopt mode:EXPAND_ONLY ... HKDF
+func TestTLSKeySchedule(t *testing.T) {
+	secret, _ := hex.DecodeString("000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f")
+	for _, tc := range []struct {
+		label, context string
+		length         int
+		want           string
+	}{
+		{"key", "", 16, "61239cba001ad7626f97e79fe461368f"},
+		{"iv", "", 12, "6d881d6752dd090573e1b665"},
+		{"c hs traf", "hello", 100, "e3ff57e5fe1d7e5d59afba0b938fbb2efb898b2b2c41ea36d2c7b1b30e0f02997ae4b823a4c3cae1fa9565dcfc9a37372dd1df78c07df640e83e24eda412e1d427488f2c74015b1fcb0eaea540b7cc0411199b336a49ca375576ba1871f04f1d2d1a9159"},
+	} {
+		got := TLSKeySchedule(secret, []byte(tc.label), []byte(tc.context), tc.length)
+		if hex.EncodeToString(got) != tc.want {
+			t.Errorf("%q: got %x, want %s", tc.label, got, tc.want)
+		}
+	}
+}
+
+func TestHKDF(t *testing.T) {
+	prk := HKDFExtract(nil, []byte("input keying material"))
+	if !bytes.Equal(prk, SM3HMAC(make([]byte, Size), []byte("input keying material"))) {
+		t.Error("HKDFExtract with nil salt does not use a zero salt")
+	}
+	long := HKDFExpand(prk, []byte("info"), 255*Size)
+	if !bytes.Equal(HKDFExpand(prk, []byte("info"), 40), long[:40]) {
+		t.Error("HKDFExpand output is not a prefix of a longer output")
+	}
+
+	secret, salt, info := []byte("shared secret"), []byte("salt"), []byte("handshake")
+	okm := HKDF(secret, salt, info, 48)
+	t1 := SM3HMAC(SM3HMAC(salt, secret), append(append([]byte(nil), info...), 1))
+	if !bytes.Equal(okm[:Size], t1) {
+		t.Error("HKDF first block is not HMAC(PRK, info || 0x01)")
+	}
+	if !bytes.Equal(okm, HKDFExpand(HKDFExtract(salt, secret), info, 48)) {
+		t.Error("HKDF differs from HKDFExpand(HKDFExtract(...))")
+	}
+	if len(HKDF(secret, nil, nil, 255*Size)) != 255*Size {
+		t.Error("HKDF rejected the maximum length")
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("HKDFExpand accepted an oversized length")
+		}
+	}()
+	HKDFExpand(prk, nil, 255*Size+1)
+}
+
+func TestHKDFLength(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("HKDF accepted an oversized length")
+		}
+	}()
+	HKDF([]byte("secret"), nil, nil, 255*Size+1)
+}
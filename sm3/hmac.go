@@ -0,0 +1,41 @@
+package sm3
+
+import (
+	"crypto/hmac"
+	"crypto/subtle"
+	"hash"
+)
+
+// NewHMAC returns a new HMAC-SM3 hash using the given key.
+func NewHMAC(key []byte) hash.Hash {
+	return hmac.New(New, key)
+}
+
+// SM3HMAC returns the 32 byte HMAC-SM3 (RFC 2104 with SM3, as used by
+// GM/T 0042) of data under key.
+func SM3HMAC(key, data []byte) []byte {
+	h := NewHMAC(key)
+	h.Write(data)
+	return h.Sum(nil)
+}
+
+// HMACVerify reports whether mac is the HMAC-SM3 of data under key. The
+// comparison runs in constant time and does not stop at the first
+// differing byte.
+func HMACVerify(key, data, mac []byte) bool {
+	return hmac.Equal(SM3HMAC(key, data), mac)
+}
+
+// VerifyHMAC is HMACVerify under the Verify* naming of the sm2 and sm4
+// packages. It compares in constant time as well.
+func VerifyHMAC(key, data, tag []byte) bool {
+	return HMACVerify(key, data, tag)
+}
+
+// Equal reports whether the digests or MAC tags a and b are equal. Unlike
+// bytes.Equal the time taken depends only on the lengths, never on the
+// contents; inputs of different lengths are unequal. Use it to compare
+// HMAC tags and secret-dependent digests.
+func Equal(a, b []byte) bool {
+	return subtle.ConstantTimeCompare(a, b) == 1
+}
@@ -0,0 +1,78 @@
+package sm3
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+)
+
+// The inputs are those of RFC 4231 test cases 1, 2, 3 and 6; the expected
+// values were cross-checked against OpenSSL's HMAC with SM3.
+var hmacTests = []struct {
+	key, data []byte
+	mac       string
+}{
+	{
+		bytes.Repeat([]byte{0x0b}, 20),
+		[]byte("Hi There"),
+		"51b00d1fb49832bfb01c3ce27848e59f871d9ba938dc563b338ca964755cce70",
+	},
+	{
+		[]byte("Jefe"),
+		[]byte("what do ya want for nothing?"),
+		"2e87f1d16862e6d964b50a5200bf2b10b764faa9680a296a2405f24bec39f882",
+	},
+	{
+		bytes.Repeat([]byte{0xaa}, 20),
+		bytes.Repeat([]byte{0xdd}, 50),
+		"dd9421e1c725bdf52ec1aa34edadb3c97f5951a83a2fa93f73a7902bc1dcc777",
+	},
+	{
+		bytes.Repeat([]byte{0xaa}, 131),
+		[]byte("Test Using Larger Than Block-Size Key - Hash Key First"),
+		"b4fd844e13342002f0b2e0690ea7741f1497d993a70494cea601e657bedf67a0",
+	},
+}
+
+func TestSM3HMAC(t *testing.T) {
+	for i, tc := range hmacTests {
+		mac := SM3HMAC(tc.key, tc.data)
+		if got := hex.EncodeToString(mac); got != tc.mac {
+			t.Errorf("vector %d: got %s, want %s", i, got, tc.mac)
+		}
+		if !HMACVerify(tc.key, tc.data, mac) {
+			t.Errorf("vector %d: HMACVerify rejected a valid mac", i)
+		}
+		for _, pos := range []int{0, len(mac) - 1} {
+			bad := append([]byte(nil), mac...)
+			bad[pos] ^= 1
+			if HMACVerify(tc.key, tc.data, bad) {
+				t.Errorf("vector %d: HMACVerify accepted a mac altered at byte %d", i, pos)
+			}
+		}
+		if HMACVerify(tc.key, tc.data, mac[:16]) {
+			t.Errorf("vector %d: HMACVerify accepted a truncated mac", i)
+		}
+		if !VerifyHMAC(tc.key, tc.data, mac) || VerifyHMAC(tc.key, tc.data, mac[1:]) || VerifyHMAC(tc.key[1:], tc.data, mac) {
+			t.Errorf("vector %d: VerifyHMAC disagrees with HMACVerify", i)
+		}
+	}
+}
+
+func TestEqual(t *testing.T) {
+	tag := SM3HMAC([]byte("key"), []byte("data"))
+	other := append([]byte(nil), tag...)
+	if !Equal(tag, other) {
+		t.Error("equal tags compared unequal")
+	}
+	other[31] ^= 1
+	if Equal(tag, other) {
+		t.Error("tags differing in the last byte compared equal")
+	}
+	if Equal(tag, tag[:16]) || Equal(tag[:16], tag) {
+		t.Error("tags of different lengths compared equal")
+	}
+	if !Equal(nil, []byte{}) {
+		t.Error("empty inputs compared unequal")
+	}
+}
@@ -0,0 +1,46 @@
+package sm3
+
+import (
+	"encoding/binary"
+)
+
+// KDF derives length bytes from the concatenation of z using the SM3 based
+// key derivation function of GM/T 0003.4-2012:
+//
+//	K = SM3(Z || ct1) || SM3(Z || ct2) || ...
+//
+// where the 32 bit big-endian counter starts at 1. The output is truncated
+// to length bytes. KDF panics if length is negative.
+func KDF(length int, z ...[]byte) []byte {
+	if length < 0 {
+		panic("SM3: invalid KDF length")
+	}
+	out := make([]byte, 0, (length+31)/32*32)
+	var ct [4]byte
+	h := New()
+	for i := uint32(1); len(out) < length; i++ {
+		h.Reset()
+		for _, b := range z {
+			h.Write(b)
+		}
+		binary.BigEndian.PutUint32(ct[:], i)
+		h.Write(ct[:])
+		out = h.Sum(out)
+	}
+	return out[:length]
+}
+
+// SumN hashes data once and expands the digest to n bytes with the KDF:
+//
+//	SumN(data, n) = KDF(n, SM3(data))
+//
+// This is an expansion, not a wider native hash: it is no stronger than SM3
+// itself, and SumN(data, 32) differs from Sum(data). Shorter outputs are
+// prefixes of longer ones, so SumN(data, 48) is SumN(data, 64)[:48].
+func SumN(data []byte, n int) []byte {
+	if n < 0 {
+		panic("SM3: invalid SumN length")
+	}
+	digest := Sum(data)
+	return KDF(n, digest[:])
+}
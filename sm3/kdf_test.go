@@ -0,0 +1,49 @@
+package sm3
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestSumN(t *testing.T) {
+	data := []byte("abc")
+	digest := Sum(data)
+	out64 := SumN(data, 64)
+	if len(out64) != 64 {
+		t.Fatalf("got %d bytes, want 64", len(out64))
+	}
+	for i := uint32(1); i <= 2; i++ {
+		block := Sm3Sum(append(digest[:], 0, 0, 0, byte(i)))
+		if !bytes.Equal(out64[(i-1)*32:i*32], block) {
+			t.Errorf("block %d does not match SM3(SM3(data) || %d)", i, i)
+		}
+	}
+	out48 := SumN(data, 48)
+	if !bytes.Equal(out48, out64[:48]) {
+		t.Error("SumN(data, 48) is not a prefix of SumN(data, 64)")
+	}
+	if !bytes.Equal(SumN(data, 48), out48) {
+		t.Error("SumN is not reproducible")
+	}
+	if bytes.Equal(SumN(data, 32), digest[:]) {
+		t.Error("SumN(data, 32) equals Sum(data)")
+	}
+	if len(SumN(data, 0)) != 0 {
+		t.Error("SumN(data, 0) is not empty")
+	}
+}
+
+func TestKDFLength(t *testing.T) {
+	if len(KDF(0, []byte("z"))) != 0 {
+		t.Error("KDF(0) is not empty")
+	}
+	if len(KDF(33, []byte("z"))) != 33 {
+		t.Error("KDF(33) has the wrong length")
+	}
+	defer func() {
+		if recover() == nil {
+			t.Error("KDF accepted a negative length")
+		}
+	}()
+	KDF(-1, []byte("z"))
+}
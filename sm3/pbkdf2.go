@@ -0,0 +1,50 @@
+package sm3
+
+import (
+	"crypto/hmac"
+	"encoding/binary"
+	"strconv"
+)
+
+// maxPBKDF2Len is the longest output RFC 2898 allows for a 32 byte PRF:
+// (2^32 - 1) blocks of 32 bytes.
+const maxPBKDF2Len = (1<<32 - 1) * 32
+
+// PBKDF2 derives a keyLen byte key from password and salt with PBKDF2
+// (RFC 2898) using HMAC-SM3 as the PRF. It is meant for storing and
+// stretching passwords; use a random salt of at least 16 bytes and as many
+// iterations as the deployment can afford. iterations below 1 are treated
+// as 1. PBKDF2 panics if keyLen is negative or exceeds what RFC 2898
+// permits.
+func PBKDF2(password, salt []byte, iterations, keyLen int) []byte {
+	if keyLen < 0 || uint64(keyLen) > maxPBKDF2Len {
+		panic("SM3: invalid PBKDF2 key length " + strconv.Itoa(keyLen))
+	}
+	if iterations < 1 {
+		iterations = 1
+	}
+	prf := hmac.New(New, password)
+	hashLen := prf.Size()
+	dk := make([]byte, 0, (keyLen+hashLen-1)/hashLen*hashLen)
+	var buf [4]byte
+	u := make([]byte, hashLen)
+	t := make([]byte, hashLen)
+	for block := uint32(1); len(dk) < keyLen; block++ {
+		prf.Reset()
+		prf.Write(salt)
+		binary.BigEndian.PutUint32(buf[:], block)
+		prf.Write(buf[:])
+		u = prf.Sum(u[:0])
+		copy(t, u)
+		for n := 1; n < iterations; n++ {
+			prf.Reset()
+			prf.Write(u)
+			u = prf.Sum(u[:0])
+			for i := range t {
+				t[i] ^= u[i]
+			}
+		}
+		dk = append(dk, t...)
+	}
+	return dk[:keyLen]
+}
@@ -0,0 +1,46 @@
+package sm3
+
+import (
+	"encoding/hex"
+	"testing"
+)
+
+// The inputs follow RFC 6070; the expected values were computed with
+// OpenSSL's PBKDF2 using SM3.
+var pbkdf2Tests = []struct {
+	password, salt string
+	iterations     int
+	key            string
+}{
+	{"password", "salt", 1, "4612f922a1fdcefaf4312fc6f8f3322b489cbf24f2ea361b44c2bd8fa2c6dcb0"},
+	{"password", "salt", 2, "fee723a2bc966e11dffb66133f4e8df577383c78ade30e3298edbd3e54ed85b7"},
+	{"password", "salt", 4096, "b6e8f2074c87432b78f62e5ced980fdff89e86af2f693dab1638e2b3683045dd"},
+	{"passwordPASSWORDpassword", "saltSALTsaltSALTsaltSALTsaltSALTsalt", 4096,
+		"3b6282ac8519f059e465abff0ea37b0dbfe6c672a76e6b805312d53900db630732ccc1a88fa5512a"},
+}
+
+func TestPBKDF2(t *testing.T) {
+	for i, tc := range pbkdf2Tests {
+		key := PBKDF2([]byte(tc.password), []byte(tc.salt), tc.iterations, len(tc.key)/2)
+		if got := hex.EncodeToString(key); got != tc.key {
+			t.Errorf("vector %d: got %s, want %s", i, got, tc.key)
+		}
+	}
+
+	one := PBKDF2([]byte("password"), []byte("salt"), 1, 32)
+	for _, it := range []int{0, -5} {
+		if got := PBKDF2([]byte("password"), []byte("salt"), it, 32); string(got) != string(one) {
+			t.Errorf("iterations %d not clamped to 1", it)
+		}
+	}
+	if got := PBKDF2([]byte("password"), []byte("salt"), 1, 0); len(got) != 0 {
+		t.Error("zero keyLen returned data")
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("negative keyLen did not panic")
+		}
+	}()
+	PBKDF2([]byte("password"), []byte("salt"), 1, -1)
+}
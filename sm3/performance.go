@@ -1,7 +1,10 @@
 package sm3
 
 import (
+	"bytes"
+	"context"
 	"hash"
+	"io"
 	"sync"
 )
 
@@ -34,6 +37,20 @@ func Sum(data []byte) [32]byte {
 	return result
 }
 
+// SumMany returns the SM3 checksum of the concatenation of chunks using a
+// pooled hasher, so e.g. a header and body can be hashed without joining them
+func SumMany(chunks ...[]byte) [32]byte {
+	h := Get()
+	defer Put(h)
+
+	for _, c := range chunks {
+		h.Write(c)
+	}
+	var result [32]byte
+	copy(result[:], h.Sum(nil))
+	return result
+}
+
 // NewWriter returns a writer that computes the SM3 checksum of written data
 func NewWriter() *Writer {
 	return &Writer{h: Get()}
@@ -52,13 +69,40 @@ func NewWriter() *Writer {
 //  io.Copy(w, moreData)
 //  sum2 := w.Sum(nil)
 type Writer struct {
-	h hash.Hash
+	h   hash.Hash
+	buf []byte
 }
 
+// readFromBufferSize is the size of the buffer ReadFrom hashes from. It is
+// a multiple of the SM3 block size, so every full read is hashed without
+// copying into the hasher's partial block buffer.
+const readFromBufferSize = 64 * 1024
+
 func (w *Writer) Write(p []byte) (int, error) {
 	return w.h.Write(p)
 }
 
+// ReadFrom hashes everything read from r until io.EOF. It implements
+// io.ReaderFrom, so io.Copy(w, r) reads straight into a reusable,
+// block-aligned buffer owned by w instead of allocating its own.
+func (w *Writer) ReadFrom(r io.Reader) (n int64, err error) {
+	if w.buf == nil {
+		w.buf = make([]byte, readFromBufferSize)
+	}
+	for {
+		m, err := io.ReadFull(r, w.buf)
+		w.h.Write(w.buf[:m])
+		n += int64(m)
+		switch err {
+		case nil:
+		case io.EOF, io.ErrUnexpectedEOF:
+			return n, nil
+		default:
+			return n, err
+		}
+	}
+}
+
 func (w *Writer) Sum(b []byte) []byte {
 	return w.h.Sum(b)
 }
@@ -72,4 +116,114 @@ func (w *Writer) Close() {
 		Put(w.h)
 		w.h = nil
 	}
-}
\ No newline at end of file
+}
+
+// NewTeeWriter returns a writer that forwards everything written to dst
+// while computing the SM3 checksum of the forwarded bytes.
+func NewTeeWriter(dst io.Writer) *TeeWriter {
+	return &TeeWriter{dst: dst, h: New()}
+}
+
+// TeeWriter is a writer that forwards written data to another writer and
+// hashes it at the same time, replacing an io.MultiWriter plus hasher setup.
+type TeeWriter struct {
+	dst io.Writer
+	h   hash.Hash
+}
+
+// Write writes p to the underlying writer and hashes the bytes that were
+// accepted by it.
+func (w *TeeWriter) Write(p []byte) (int, error) {
+	n, err := w.dst.Write(p)
+	w.h.Write(p[:n])
+	return n, err
+}
+
+// Sum returns the SM3 checksum of all bytes forwarded so far.
+func (w *TeeWriter) Sum() [32]byte {
+	var result [32]byte
+	copy(result[:], w.h.Sum(nil))
+	return result
+}
+
+// pairedChunkSize bounds the memory SumPaired uses per reader.
+const pairedChunkSize = 32 * 1024
+
+// SumPaired reads a and b in lockstep and returns the SM3 checksum of each
+// stream together with whether the two streams are byte-identical. Both
+// readers are consumed to the end in a single pass using bounded memory.
+func SumPaired(a, b io.Reader) (hashA, hashB [32]byte, equal bool, err error) {
+	ha, hb := Get(), Get()
+	defer Put(ha)
+	defer Put(hb)
+
+	bufA := make([]byte, pairedChunkSize)
+	bufB := make([]byte, pairedChunkSize)
+	equal = true
+	var doneA, doneB bool
+	for !doneA || !doneB {
+		var na, nb int
+		if !doneA {
+			if na, doneA, err = readChunk(a, bufA); err != nil {
+				return hashA, hashB, false, err
+			}
+			ha.Write(bufA[:na])
+		}
+		if !doneB {
+			if nb, doneB, err = readChunk(b, bufB); err != nil {
+				return hashA, hashB, false, err
+			}
+			hb.Write(bufB[:nb])
+		}
+		// Chunks are always filled completely before EOF, so equal
+		// streams produce equal chunks at every step.
+		if equal && !bytes.Equal(bufA[:na], bufB[:nb]) {
+			equal = false
+		}
+	}
+	copy(hashA[:], ha.Sum(nil))
+	copy(hashB[:], hb.Sum(nil))
+	return hashA, hashB, equal, nil
+}
+
+// readChunk fills buf from r and reports whether r reached EOF.
+func readChunk(r io.Reader, buf []byte) (int, bool, error) {
+	n, err := io.ReadFull(r, buf)
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		return n, true, nil
+	}
+	return n, false, err
+}
+
+// SumReader returns the SM3 checksum of everything read from r until
+// io.EOF, using a pooled hasher.
+func SumReader(r io.Reader) ([32]byte, error) {
+	return SumReaderContext(context.Background(), r)
+}
+
+// SumReaderContext is like SumReader but checks ctx before every read and
+// returns ctx.Err() once it is done, discarding the partial digest. A read
+// that is already blocked is not interrupted; close the underlying
+// connection, or set a deadline on it, to bound a single read.
+func SumReaderContext(ctx context.Context, r io.Reader) ([32]byte, error) {
+	var result [32]byte
+	h := Get()
+	defer Put(h)
+
+	buf := make([]byte, readFromBufferSize)
+	for {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+		n, err := r.Read(buf)
+		h.Write(buf[:n])
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return result, err
+		}
+	}
+	copy(result[:], h.Sum(nil))
+	return result, nil
+}
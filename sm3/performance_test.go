@@ -0,0 +1,193 @@
+package sm3
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"testing/iotest"
+)
+
+func TestTeeWriter(t *testing.T) {
+	data := bytes.Repeat([]byte("0123456789abcdef"), 1000)
+	var dst bytes.Buffer
+	w := NewTeeWriter(&dst)
+	if _, err := io.Copy(w, bytes.NewReader(data)); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(dst.Bytes(), data) {
+		t.Error("destination did not receive all bytes")
+	}
+	if w.Sum() != Sum(data) {
+		t.Error("tee digest differs from Sum")
+	}
+}
+
+func TestSumPaired(t *testing.T) {
+	long := bytes.Repeat([]byte{0x5a}, 3*pairedChunkSize+7)
+	flipped := append([]byte(nil), long...)
+	flipped[2*pairedChunkSize+1] ^= 1
+	cases := []struct {
+		a, b  []byte
+		equal bool
+	}{
+		{nil, nil, true},
+		{[]byte("abc"), []byte("abc"), true},
+		{long, long, true},
+		{long[:pairedChunkSize], long[:pairedChunkSize], true},
+		{[]byte("abc"), []byte("abd"), false},
+		{[]byte("abc"), []byte("ab"), false},
+		{nil, []byte("a"), false},
+		{long, long[:pairedChunkSize], false},
+		{long, flipped, false},
+	}
+	for i, c := range cases {
+		ha, hb, equal, err := SumPaired(bytes.NewReader(c.a), bytes.NewReader(c.b))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if equal != c.equal {
+			t.Errorf("case %d: equal = %v, want %v", i, equal, c.equal)
+		}
+		if ha != Sum(c.a) || hb != Sum(c.b) {
+			t.Errorf("case %d: digests differ from Sum", i)
+		}
+	}
+}
+
+func TestSumMany(t *testing.T) {
+	header := []byte("header: value\r\n\r\n")
+	body := bytes.Repeat([]byte("body"), 100)
+	if SumMany(header, body) != Sum(append(append([]byte(nil), header...), body...)) {
+		t.Error("SumMany(header, body) differs from Sum of the concatenation")
+	}
+	if SumMany() != Sum(nil) {
+		t.Error("SumMany() differs from Sum(nil)")
+	}
+	if SumMany(nil, []byte("abc"), nil) != Sum([]byte("abc")) {
+		t.Error("SumMany with empty chunks differs from Sum")
+	}
+}
+
+func TestWriterReadFrom(t *testing.T) {
+	data := make([]byte, 3*readFromBufferSize+77)
+	for i := range data {
+		data[i] = byte(i * 7)
+	}
+	for _, n := range []int{0, 1, 63, 64, 65, readFromBufferSize, len(data)} {
+		w := NewWriter()
+		var r io.Reader = bytes.NewReader(data[:n])
+		got, err := w.ReadFrom(r)
+		if err != nil || got != int64(n) {
+			t.Fatalf("ReadFrom(%d bytes) = %d, %v", n, got, err)
+		}
+		want := Sum(data[:n])
+		if !bytes.Equal(w.Sum(nil), want[:]) {
+			t.Errorf("ReadFrom digest of %d bytes differs from Sum", n)
+		}
+		w.Close()
+	}
+
+	w := NewWriter()
+	defer w.Close()
+	if _, err := io.Copy(w, iotest.HalfReader(bytes.NewReader(data))); err != nil {
+		t.Fatal(err)
+	}
+	want := Sum(data)
+	if !bytes.Equal(w.Sum(nil), want[:]) {
+		t.Error("io.Copy digest differs from Sum")
+	}
+	w.Reset()
+	if _, err := w.ReadFrom(iotest.ErrReader(io.ErrClosedPipe)); err != io.ErrClosedPipe {
+		t.Errorf("ReadFrom error: got %v, want %v", err, io.ErrClosedPipe)
+	}
+}
+
+func TestWriteSplits(t *testing.T) {
+	data := make([]byte, 1000)
+	for i := range data {
+		data[i] = byte(i)
+	}
+	want := Sm3Sum(data)
+	for _, step := range []int{1, 3, 63, 64, 65, 130, 999} {
+		h := New()
+		for i := 0; i < len(data); i += step {
+			end := i + step
+			if end > len(data) {
+				end = len(data)
+			}
+			h.Write(data[i:end])
+		}
+		if !bytes.Equal(h.Sum(nil), want) {
+			t.Errorf("writes of %d bytes give a different digest", step)
+		}
+	}
+}
+
+func BenchmarkWriterCopy(b *testing.B) {
+	data := make([]byte, 1<<20)
+	b.Run("ReadFrom", func(b *testing.B) {
+		b.SetBytes(int64(len(data)))
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			w := NewWriter()
+			// bytes.Reader implements io.WriterTo, which io.Copy
+			// would prefer over ReadFrom.
+			io.Copy(w, struct{ io.Reader }{bytes.NewReader(data)})
+			w.Close()
+		}
+	})
+	b.Run("Write", func(b *testing.B) {
+		b.SetBytes(int64(len(data)))
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			w := NewWriter()
+			// Hide ReadFrom and WriteTo to force the generic copy loop.
+			io.Copy(struct{ io.Writer }{w}, struct{ io.Reader }{bytes.NewReader(data)})
+			w.Close()
+		}
+	})
+}
+
+// cancelReader cancels its context after the first read.
+type cancelReader struct {
+	r      io.Reader
+	cancel context.CancelFunc
+	reads  int
+}
+
+func (c *cancelReader) Read(p []byte) (int, error) {
+	c.reads++
+	c.cancel()
+	return c.r.Read(p)
+}
+
+func TestSumReaderContext(t *testing.T) {
+	data := bytes.Repeat([]byte("slow network reader "), 10000)
+	want := Sum(data)
+	if got, err := SumReader(iotest.OneByteReader(bytes.NewReader(data[:1000]))); err != nil || got != Sum(data[:1000]) {
+		t.Errorf("SumReader: got %x, %v", got, err)
+	}
+	if got, err := SumReaderContext(context.Background(), iotest.HalfReader(bytes.NewReader(data))); err != nil || got != want {
+		t.Errorf("SumReaderContext: got %x, %v", got, err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	r := &cancelReader{r: bytes.NewReader(data), cancel: cancel}
+	got, err := SumReaderContext(ctx, r)
+	if err != context.Canceled || got != [32]byte{} {
+		t.Errorf("cancelled: got %x, %v", got, err)
+	}
+	if r.reads != 1 {
+		t.Errorf("%d reads after cancellation, want 1", r.reads)
+	}
+	if _, err := SumReaderContext(ctx, bytes.NewReader(data)); err != context.Canceled {
+		t.Errorf("done context: got %v", err)
+	}
+
+	readErr := errors.New("connection reset")
+	if _, err := SumReader(iotest.ErrReader(readErr)); err != readErr {
+		t.Errorf("read error: got %v", err)
+	}
+}
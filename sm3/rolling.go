@@ -0,0 +1,94 @@
+package sm3
+
+import "hash"
+
+// rollingBase is the multiplier of the rolling polynomial. It is odd, so
+// multiplication by it is invertible modulo 2^64.
+const rollingBase = 0x100000001b3
+
+// rollingBufferSize is how many rolled bytes are collected before they are
+// written to SM3, a whole number of SM3 blocks.
+const rollingBufferSize = 4 * 64
+
+// RollingHash supports content-defined chunking. Roll keeps a cheap
+// Rabin-Karp style fingerprint of the last windowSize bytes,
+//
+//	Σ (b_i + 1) * base^(k-1-i)  mod 2^64,
+//
+// over the k = min(rolled, windowSize) most recent bytes b_0 .. b_(k-1),
+// while every rolled byte is also fed to SM3. On a chunk boundary,
+// ChunkSum returns the SM3 digest of the chunk.
+//
+// The fingerprint is a weak, non-cryptographic hash, meant only to pick
+// chunk boundaries (for example where the value modulo some average size
+// is zero). Anyone can construct data with chosen fingerprints; identify
+// chunks by their ChunkSum only.
+type RollingHash struct {
+	window []byte
+	pos    int
+	n      int
+	value  uint64
+	pow    uint64 // base^windowSize
+	chunk  hash.Hash
+	buf    [rollingBufferSize]byte // rolled bytes not yet written to chunk
+	nbuf   int
+}
+
+// NewRollingHash returns a RollingHash over a window of windowSize bytes.
+// It panics if windowSize is not positive.
+func NewRollingHash(windowSize int) *RollingHash {
+	if windowSize <= 0 {
+		panic("SM3: invalid rolling hash window size")
+	}
+	pow := uint64(1)
+	for i := 0; i < windowSize; i++ {
+		pow *= rollingBase
+	}
+	return &RollingHash{window: make([]byte, windowSize), pow: pow, chunk: New()}
+}
+
+// Roll appends b to the window, dropping the oldest byte once the window
+// is full, adds b to the current chunk and returns the new fingerprint.
+func (r *RollingHash) Roll(b byte) uint64 {
+	out := r.window[r.pos]
+	r.window[r.pos] = b
+	if r.pos++; r.pos == len(r.window) {
+		r.pos = 0
+	}
+	r.value = r.value*rollingBase + uint64(b) + 1
+	if r.n == len(r.window) {
+		r.value -= (uint64(out) + 1) * r.pow
+	} else {
+		r.n++
+	}
+	r.buf[r.nbuf] = b
+	if r.nbuf++; r.nbuf == len(r.buf) {
+		r.chunk.Write(r.buf[:])
+		r.nbuf = 0
+	}
+	return r.value
+}
+
+// Value returns the current fingerprint.
+func (r *RollingHash) Value() uint64 {
+	return r.value
+}
+
+// ChunkSum returns the SM3 digest of the bytes rolled since the previous
+// ChunkSum (or since creation) and starts a new chunk. The window is kept,
+// so boundaries do not depend on where previous chunks ended.
+func (r *RollingHash) ChunkSum() [Size]byte {
+	var sum [Size]byte
+	r.chunk.Write(r.buf[:r.nbuf])
+	r.nbuf = 0
+	r.chunk.Sum(sum[:0])
+	r.chunk.Reset()
+	return sum
+}
+
+// Reset clears the window and the current chunk.
+func (r *RollingHash) Reset() {
+	clear(r.window)
+	r.pos, r.n, r.value, r.nbuf = 0, 0, 0, 0
+	r.chunk.Reset()
+}
@@ -0,0 +1,94 @@
+package sm3
+
+import "testing"
+
+// fingerprint computes the rolling value of window directly.
+func fingerprint(window []byte) uint64 {
+	var v uint64
+	for _, b := range window {
+		v = v*rollingBase + uint64(b) + 1
+	}
+	return v
+}
+
+func TestRollingHash(t *testing.T) {
+	const size = 16
+	data := make([]byte, 500)
+	for i := range data {
+		data[i] = byte(i*31 + i/7)
+	}
+	r := NewRollingHash(size)
+	for i, b := range data {
+		got := r.Roll(b)
+		start := i + 1 - size
+		if start < 0 {
+			start = 0
+		}
+		if want := fingerprint(data[start : i+1]); got != want {
+			t.Fatalf("after %d bytes: got %#x, want %#x", i+1, got, want)
+		}
+	}
+
+	// The value only depends on the window, not on what came before it.
+	other := NewRollingHash(size)
+	for _, b := range append([]byte("unrelated prefix"), data[len(data)-size:]...) {
+		other.Roll(b)
+	}
+	if other.Value() != r.Value() {
+		t.Error("equal windows give different fingerprints")
+	}
+	zeros := NewRollingHash(4)
+	if zeros.Roll(0) == 0 || zeros.Roll(0) == zeros.Roll(0) {
+		t.Error("zero bytes do not change the fingerprint")
+	}
+}
+
+func TestRollingHashChunkSum(t *testing.T) {
+	var data []byte
+	for i := 0; i < 32; i++ {
+		data = append(data, Sm3Sum([]byte{byte(i)})...)
+	}
+	r := NewRollingHash(32)
+	var chunks [][Size]byte
+	var bounds []int
+	last := 0
+	for i, b := range data {
+		if r.Roll(b)%16 == 0 {
+			chunks = append(chunks, r.ChunkSum())
+			bounds = append(bounds, i+1)
+		}
+	}
+	if len(bounds) == 0 {
+		t.Fatal("no chunk boundary found")
+	}
+	chunks = append(chunks, r.ChunkSum())
+	bounds = append(bounds, len(data))
+	for i, end := range bounds {
+		if want := Sum(data[last:end]); chunks[i] != want {
+			t.Errorf("chunk %d: ChunkSum does not match Sum", i)
+		}
+		last = end
+	}
+	r.Reset()
+	if r.Value() != 0 || r.ChunkSum() != Sum(nil) {
+		t.Error("Reset did not clear the state")
+	}
+}
+
+func BenchmarkRollingHash(b *testing.B) {
+	data := make([]byte, 1<<20)
+	for i := range data {
+		data[i] = byte(i*31 + i/7)
+	}
+	r := NewRollingHash(48)
+	b.SetBytes(int64(len(data)))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		for _, c := range data {
+			if r.Roll(c)&0x1fff == 0 {
+				r.ChunkSum()
+			}
+		}
+		r.ChunkSum()
+	}
+}
@@ -27,19 +27,19 @@ type SM3 struct {
 	unhandleMsg []byte    // uint8  //
 }
 
-func (sm3 *SM3) ff0(x, y, z uint32) uint32 { return x ^ y ^ z }
+func ff0(x, y, z uint32) uint32 { return x ^ y ^ z }
 
-func (sm3 *SM3) ff1(x, y, z uint32) uint32 { return (x & y) | (x & z) | (y & z) }
+func ff1(x, y, z uint32) uint32 { return (x & y) | (x & z) | (y & z) }
 
-func (sm3 *SM3) gg0(x, y, z uint32) uint32 { return x ^ y ^ z }
+func gg0(x, y, z uint32) uint32 { return x ^ y ^ z }
 
-func (sm3 *SM3) gg1(x, y, z uint32) uint32 { return (x & y) | (^x & z) }
+func gg1(x, y, z uint32) uint32 { return (x & y) | (^x & z) }
 
-func (sm3 *SM3) p0(x uint32) uint32 { return x ^ sm3.leftRotate(x, 9) ^ sm3.leftRotate(x, 17) }
+func p0(x uint32) uint32 { return x ^ leftRotate(x, 9) ^ leftRotate(x, 17) }
 
-func (sm3 *SM3) p1(x uint32) uint32 { return x ^ sm3.leftRotate(x, 15) ^ sm3.leftRotate(x, 23) }
+func p1(x uint32) uint32 { return x ^ leftRotate(x, 15) ^ leftRotate(x, 23) }
 
-func (sm3 *SM3) leftRotate(x uint32, i uint32) uint32 { return x<<(i%32) | x>>(32-i%32) }
+func leftRotate(x uint32, i uint32) uint32 { return x<<(i%32) | x>>(32-i%32) }
 
 func (sm3 *SM3) pad() []byte {
 	msg := sm3.unhandleMsg
@@ -64,49 +64,50 @@ func (sm3 *SM3) pad() []byte {
 	return msg
 }
 
-func (sm3 *SM3) update(msg []byte) {
+// blockGeneric hashes the whole 64 byte blocks of msg into dig.
+func blockGeneric(dig *[8]uint32, msg []byte) {
 	var w [68]uint32
 	var w1 [64]uint32
 
-	a, b, c, d, e, f, g, h := sm3.digest[0], sm3.digest[1], sm3.digest[2], sm3.digest[3], sm3.digest[4], sm3.digest[5], sm3.digest[6], sm3.digest[7]
+	a, b, c, d, e, f, g, h := dig[0], dig[1], dig[2], dig[3], dig[4], dig[5], dig[6], dig[7]
 	for len(msg) >= 64 {
 		for i := 0; i < 16; i++ {
 			w[i] = binary.BigEndian.Uint32(msg[4*i : 4*(i+1)])
 		}
 		for i := 16; i < 68; i++ {
-			w[i] = sm3.p1(w[i-16]^w[i-9]^sm3.leftRotate(w[i-3], 15)) ^ sm3.leftRotate(w[i-13], 7) ^ w[i-6]
+			w[i] = p1(w[i-16]^w[i-9]^leftRotate(w[i-3], 15)) ^ leftRotate(w[i-13], 7) ^ w[i-6]
 		}
 		for i := 0; i < 64; i++ {
 			w1[i] = w[i] ^ w[i+4]
 		}
 		A, B, C, D, E, F, G, H := a, b, c, d, e, f, g, h
 		for i := 0; i < 16; i++ {
-			SS1 := sm3.leftRotate(sm3.leftRotate(A, 12)+E+sm3.leftRotate(0x79cc4519, uint32(i)), 7)
-			SS2 := SS1 ^ sm3.leftRotate(A, 12)
-			TT1 := sm3.ff0(A, B, C) + D + SS2 + w1[i]
-			TT2 := sm3.gg0(E, F, G) + H + SS1 + w[i]
+			SS1 := leftRotate(leftRotate(A, 12)+E+leftRotate(0x79cc4519, uint32(i)), 7)
+			SS2 := SS1 ^ leftRotate(A, 12)
+			TT1 := ff0(A, B, C) + D + SS2 + w1[i]
+			TT2 := gg0(E, F, G) + H + SS1 + w[i]
 			D = C
-			C = sm3.leftRotate(B, 9)
+			C = leftRotate(B, 9)
 			B = A
 			A = TT1
 			H = G
-			G = sm3.leftRotate(F, 19)
+			G = leftRotate(F, 19)
 			F = E
-			E = sm3.p0(TT2)
+			E = p0(TT2)
 		}
 		for i := 16; i < 64; i++ {
-			SS1 := sm3.leftRotate(sm3.leftRotate(A, 12)+E+sm3.leftRotate(0x7a879d8a, uint32(i)), 7)
-			SS2 := SS1 ^ sm3.leftRotate(A, 12)
-			TT1 := sm3.ff1(A, B, C) + D + SS2 + w1[i]
-			TT2 := sm3.gg1(E, F, G) + H + SS1 + w[i]
+			SS1 := leftRotate(leftRotate(A, 12)+E+leftRotate(0x7a879d8a, uint32(i)), 7)
+			SS2 := SS1 ^ leftRotate(A, 12)
+			TT1 := ff1(A, B, C) + D + SS2 + w1[i]
+			TT2 := gg1(E, F, G) + H + SS1 + w[i]
 			D = C
-			C = sm3.leftRotate(B, 9)
+			C = leftRotate(B, 9)
 			B = A
 			A = TT1
 			H = G
-			G = sm3.leftRotate(F, 19)
+			G = leftRotate(F, 19)
 			F = E
-			E = sm3.p0(TT2)
+			E = p0(TT2)
 		}
 		a ^= A
 		b ^= B
@@ -118,64 +119,17 @@ func (sm3 *SM3) update(msg []byte) {
 		h ^= H
 		msg = msg[64:]
 	}
-	sm3.digest[0], sm3.digest[1], sm3.digest[2], sm3.digest[3], sm3.digest[4], sm3.digest[5], sm3.digest[6], sm3.digest[7] = a, b, c, d, e, f, g, h
+	dig[0], dig[1], dig[2], dig[3], dig[4], dig[5], dig[6], dig[7] = a, b, c, d, e, f, g, h
 }
-func (sm3 *SM3) update2(msg []byte,) [8]uint32 {
-	var w [68]uint32
-	var w1 [64]uint32
 
-	a, b, c, d, e, f, g, h := sm3.digest[0], sm3.digest[1], sm3.digest[2], sm3.digest[3], sm3.digest[4], sm3.digest[5], sm3.digest[6], sm3.digest[7]
-	for len(msg) >= 64 {
-		for i := 0; i < 16; i++ {
-			w[i] = binary.BigEndian.Uint32(msg[4*i : 4*(i+1)])
-		}
-		for i := 16; i < 68; i++ {
-			w[i] = sm3.p1(w[i-16]^w[i-9]^sm3.leftRotate(w[i-3], 15)) ^ sm3.leftRotate(w[i-13], 7) ^ w[i-6]
-		}
-		for i := 0; i < 64; i++ {
-			w1[i] = w[i] ^ w[i+4]
-		}
-		A, B, C, D, E, F, G, H := a, b, c, d, e, f, g, h
-		for i := 0; i < 16; i++ {
-			SS1 := sm3.leftRotate(sm3.leftRotate(A, 12)+E+sm3.leftRotate(0x79cc4519, uint32(i)), 7)
-			SS2 := SS1 ^ sm3.leftRotate(A, 12)
-			TT1 := sm3.ff0(A, B, C) + D + SS2 + w1[i]
-			TT2 := sm3.gg0(E, F, G) + H + SS1 + w[i]
-			D = C
-			C = sm3.leftRotate(B, 9)
-			B = A
-			A = TT1
-			H = G
-			G = sm3.leftRotate(F, 19)
-			F = E
-			E = sm3.p0(TT2)
-		}
-		for i := 16; i < 64; i++ {
-			SS1 := sm3.leftRotate(sm3.leftRotate(A, 12)+E+sm3.leftRotate(0x7a879d8a, uint32(i)), 7)
-			SS2 := SS1 ^ sm3.leftRotate(A, 12)
-			TT1 := sm3.ff1(A, B, C) + D + SS2 + w1[i]
-			TT2 := sm3.gg1(E, F, G) + H + SS1 + w[i]
-			D = C
-			C = sm3.leftRotate(B, 9)
-			B = A
-			A = TT1
-			H = G
-			G = sm3.leftRotate(F, 19)
-			F = E
-			E = sm3.p0(TT2)
-		}
-		a ^= A
-		b ^= B
-		c ^= C
-		d ^= D
-		e ^= E
-		f ^= F
-		g ^= G
-		h ^= H
-		msg = msg[64:]
-	}
-	var digest [8]uint32
-	digest[0], digest[1], digest[2], digest[3], digest[4], digest[5], digest[6], digest[7] = a, b, c, d, e, f, g, h
+func (sm3 *SM3) update(msg []byte) {
+	block(&sm3.digest, msg)
+}
+
+// update2 returns the digest after hashing msg without changing sm3.
+func (sm3 *SM3) update2(msg []byte) [8]uint32 {
+	digest := sm3.digest
+	block(&digest, msg)
 	return digest
 }
 
@@ -186,7 +140,9 @@ func (sm3 *SM3) update2(msg []byte,) [8]uint32 {
 //  io.Copy(h, data)
 //  sum := h.Sum(nil)
 func New() hash.Hash {
-	return &SM3{}
+	var sm3 SM3
+	sm3.Reset()
+	return &sm3
 }
 
 // BlockSize returns the hash's underlying block size.
@@ -212,12 +168,23 @@ func (sm3 *SM3) Reset() {
 func (sm3 *SM3) Write(p []byte) (int, error) {
 	toWrite := len(p)
 	sm3.length += uint64(len(p) * 8)
-	sm3.unhandleMsg = append(sm3.unhandleMsg, p...)
-	
-	for len(sm3.unhandleMsg) >= sm3.BlockSize() {
-		sm3.update(sm3.unhandleMsg[:sm3.BlockSize()])
-		sm3.unhandleMsg = sm3.unhandleMsg[sm3.BlockSize():]
+	if n := len(sm3.unhandleMsg); n > 0 {
+		m := sm3.BlockSize() - n
+		if len(p) < m {
+			sm3.unhandleMsg = append(sm3.unhandleMsg, p...)
+			return toWrite, nil
+		}
+		sm3.unhandleMsg = append(sm3.unhandleMsg, p[:m]...)
+		sm3.update(sm3.unhandleMsg)
+		sm3.unhandleMsg = sm3.unhandleMsg[:0]
+		p = p[m:]
+	}
+	// Hash whole blocks straight from p and only buffer the tail.
+	if n := len(p) &^ (sm3.BlockSize() - 1); n > 0 {
+		sm3.update(p[:n])
+		p = p[n:]
 	}
+	sm3.unhandleMsg = append(sm3.unhandleMsg, p...)
 
 	return toWrite, nil
 }
@@ -226,24 +193,15 @@ func (sm3 *SM3) Write(p []byte) (int, error) {
 // Sum appends the current hash to b and returns the resulting slice.
 // It does not change the underlying hash state.
 func (sm3 *SM3) Sum(in []byte) []byte {
-	_, _ = sm3.Write(in)
 	msg := sm3.pad()
 	//Finalize
 	digest := sm3.update2(msg)
 
 	// save hash to in
-	needed := sm3.Size()
-	if cap(in)-len(in) < needed {
-		newIn := make([]byte, len(in), len(in)+needed)
-		copy(newIn, in)
-		in = newIn
-	}
-	out := in[len(in) : len(in)+needed]
 	for i := 0; i < 8; i++ {
-		binary.BigEndian.PutUint32(out[i*4:], digest[i])
+		in = binary.BigEndian.AppendUint32(in, digest[i])
 	}
-	return out
-
+	return in
 }
 
 // sm3Pool 用于重用SM3实例
@@ -0,0 +1,20 @@
+//go:build amd64 && !purego
+
+package sm3
+
+import "golang.org/x/sys/cpu"
+
+// useBMI2 selects the amd64 assembly, which needs the BMI2 RORX
+// instruction.
+var useBMI2 = cpu.X86.HasBMI2
+
+//go:noescape
+func blockAMD64(dig *[8]uint32, p []byte)
+
+func block(dig *[8]uint32, p []byte) {
+	if useBMI2 {
+		blockAMD64(dig, p)
+		return
+	}
+	blockGeneric(dig, p)
+}
@@ -0,0 +1,266 @@
+//go:build amd64 && !purego
+
+#include "textflag.h"
+
+// The compression function of GB/T 32905 with the BMI2 RORX instruction
+// for the rotations. The state A..H lives in R8..R14 and DI; instead of
+// moving registers each round, ROUND writes TT1 into the register of D and
+// P0(TT2) into that of H, so the register roles rotate every round and
+// repeat every four rounds. The expanded message W[0..67] is kept on the
+// stack.
+
+// LOADW loads message word I, converted from big-endian.
+#define LOADW(I) \
+	MOVL (I*4)(SI), AX; \
+	BSWAPL AX; \
+	MOVL AX, (I*4)(SP)
+
+// EXPAND computes W[J] = P1(W[J-16] ^ W[J-9] ^ (W[J-3] <<< 15)) ^
+// (W[J-13] <<< 7) ^ W[J-6].
+#define EXPAND(J) \
+	MOVL ((J-16)*4)(SP), AX; \
+	XORL ((J-9)*4)(SP), AX; \
+	RORXL $17, ((J-3)*4)(SP), BX; \
+	XORL BX, AX; \
+	RORXL $17, AX, BX; \
+	RORXL $9, AX, CX; \
+	XORL BX, AX; \
+	XORL CX, AX; \
+	RORXL $25, ((J-13)*4)(SP), BX; \
+	XORL BX, AX; \
+	XORL ((J-6)*4)(SP), AX; \
+	MOVL AX, (J*4)(SP)
+
+// ROUNDHEAD leaves SS2 + W'[I] in AX and SS1 + W[I] in BX, where K is the
+// round constant T_I <<< I.
+#define ROUNDHEAD(I, K, A, E) \
+	RORXL $20, A, AX; \
+	LEAL (AX)(E*1), BX; \
+	ADDL $K, BX; \
+	RORXL $25, BX, BX; \
+	XORL BX, AX; \
+	MOVL (I*4)(SP), DX; \
+	MOVL ((I+4)*4)(SP), CX; \
+	XORL DX, CX; \
+	ADDL CX, AX; \
+	ADDL DX, BX
+
+// ROUNDTAIL finishes a round given FF(A, B, C) and GG(E, F, G) in CX and
+// DX: D = TT1, H = P0(TT2), B <<<= 9 and F <<<= 19.
+#define ROUNDTAIL(B, D, F, H) \
+	ADDL AX, D; \
+	ADDL CX, D; \
+	ADDL BX, H; \
+	ADDL DX, H; \
+	RORXL $23, H, CX; \
+	RORXL $15, H, DX; \
+	XORL CX, H; \
+	XORL DX, H; \
+	RORXL $23, B, B; \
+	RORXL $13, F, F
+
+// ROUND0 is a round 0 <= I < 16, with FF = GG = X ^ Y ^ Z.
+#define ROUND0(I, K, A, B, C, D, E, F, G, H) \
+	ROUNDHEAD(I, K, A, E); \
+	MOVL A, CX; \
+	XORL B, CX; \
+	XORL C, CX; \
+	MOVL E, DX; \
+	XORL F, DX; \
+	XORL G, DX; \
+	ROUNDTAIL(B, D, F, H)
+
+// ROUND1 is a round 16 <= I < 64, with FF the majority function and
+// GG(X, Y, Z) = ((Y ^ Z) & X) ^ Z.
+#define ROUND1(I, K, A, B, C, D, E, F, G, H) \
+	ROUNDHEAD(I, K, A, E); \
+	MOVL A, CX; \
+	ANDL B, CX; \
+	MOVL A, DX; \
+	ORL  B, DX; \
+	ANDL C, DX; \
+	ORL  DX, CX; \
+	MOVL F, DX; \
+	XORL G, DX; \
+	ANDL E, DX; \
+	XORL G, DX; \
+	ROUNDTAIL(B, D, F, H)
+
+// func blockAMD64(dig *[8]uint32, p []byte)
+TEXT ·blockAMD64(SB), 0, $272-32
+	MOVQ p_base+8(FP), SI
+	MOVQ p_len+16(FP), DX
+	SHRQ $6, DX
+	SHLQ $6, DX
+	JZ   end
+	LEAQ (SI)(DX*1), DX
+	MOVQ DX, p_len+16(FP)
+
+	MOVQ dig+0(FP), AX
+	MOVL 0(AX), R8
+	MOVL 4(AX), R9
+	MOVL 8(AX), R10
+	MOVL 12(AX), R11
+	MOVL 16(AX), R12
+	MOVL 20(AX), R13
+	MOVL 24(AX), R14
+	MOVL 28(AX), DI
+
+loop:
+	LOADW(0)
+	LOADW(1)
+	LOADW(2)
+	LOADW(3)
+	LOADW(4)
+	LOADW(5)
+	LOADW(6)
+	LOADW(7)
+	LOADW(8)
+	LOADW(9)
+	LOADW(10)
+	LOADW(11)
+	LOADW(12)
+	LOADW(13)
+	LOADW(14)
+	LOADW(15)
+	EXPAND(16)
+	EXPAND(17)
+	EXPAND(18)
+	EXPAND(19)
+	EXPAND(20)
+	EXPAND(21)
+	EXPAND(22)
+	EXPAND(23)
+	EXPAND(24)
+	EXPAND(25)
+	EXPAND(26)
+	EXPAND(27)
+	EXPAND(28)
+	EXPAND(29)
+	EXPAND(30)
+	EXPAND(31)
+	EXPAND(32)
+	EXPAND(33)
+	EXPAND(34)
+	EXPAND(35)
+	EXPAND(36)
+	EXPAND(37)
+	EXPAND(38)
+	EXPAND(39)
+	EXPAND(40)
+	EXPAND(41)
+	EXPAND(42)
+	EXPAND(43)
+	EXPAND(44)
+	EXPAND(45)
+	EXPAND(46)
+	EXPAND(47)
+	EXPAND(48)
+	EXPAND(49)
+	EXPAND(50)
+	EXPAND(51)
+	EXPAND(52)
+	EXPAND(53)
+	EXPAND(54)
+	EXPAND(55)
+	EXPAND(56)
+	EXPAND(57)
+	EXPAND(58)
+	EXPAND(59)
+	EXPAND(60)
+	EXPAND(61)
+	EXPAND(62)
+	EXPAND(63)
+	EXPAND(64)
+	EXPAND(65)
+	EXPAND(66)
+	EXPAND(67)
+	ROUND0(0, 0x79cc4519, R8, R9, R10, R11, R12, R13, R14, DI)
+	ROUND0(1, 0xf3988a32, R11, R8, R9, R10, DI, R12, R13, R14)
+	ROUND0(2, 0xe7311465, R10, R11, R8, R9, R14, DI, R12, R13)
+	ROUND0(3, 0xce6228cb, R9, R10, R11, R8, R13, R14, DI, R12)
+	ROUND0(4, 0x9cc45197, R8, R9, R10, R11, R12, R13, R14, DI)
+	ROUND0(5, 0x3988a32f, R11, R8, R9, R10, DI, R12, R13, R14)
+	ROUND0(6, 0x7311465e, R10, R11, R8, R9, R14, DI, R12, R13)
+	ROUND0(7, 0xe6228cbc, R9, R10, R11, R8, R13, R14, DI, R12)
+	ROUND0(8, 0xcc451979, R8, R9, R10, R11, R12, R13, R14, DI)
+	ROUND0(9, 0x988a32f3, R11, R8, R9, R10, DI, R12, R13, R14)
+	ROUND0(10, 0x311465e7, R10, R11, R8, R9, R14, DI, R12, R13)
+	ROUND0(11, 0x6228cbce, R9, R10, R11, R8, R13, R14, DI, R12)
+	ROUND0(12, 0xc451979c, R8, R9, R10, R11, R12, R13, R14, DI)
+	ROUND0(13, 0x88a32f39, R11, R8, R9, R10, DI, R12, R13, R14)
+	ROUND0(14, 0x11465e73, R10, R11, R8, R9, R14, DI, R12, R13)
+	ROUND0(15, 0x228cbce6, R9, R10, R11, R8, R13, R14, DI, R12)
+	ROUND1(16, 0x9d8a7a87, R8, R9, R10, R11, R12, R13, R14, DI)
+	ROUND1(17, 0x3b14f50f, R11, R8, R9, R10, DI, R12, R13, R14)
+	ROUND1(18, 0x7629ea1e, R10, R11, R8, R9, R14, DI, R12, R13)
+	ROUND1(19, 0xec53d43c, R9, R10, R11, R8, R13, R14, DI, R12)
+	ROUND1(20, 0xd8a7a879, R8, R9, R10, R11, R12, R13, R14, DI)
+	ROUND1(21, 0xb14f50f3, R11, R8, R9, R10, DI, R12, R13, R14)
+	ROUND1(22, 0x629ea1e7, R10, R11, R8, R9, R14, DI, R12, R13)
+	ROUND1(23, 0xc53d43ce, R9, R10, R11, R8, R13, R14, DI, R12)
+	ROUND1(24, 0x8a7a879d, R8, R9, R10, R11, R12, R13, R14, DI)
+	ROUND1(25, 0x14f50f3b, R11, R8, R9, R10, DI, R12, R13, R14)
+	ROUND1(26, 0x29ea1e76, R10, R11, R8, R9, R14, DI, R12, R13)
+	ROUND1(27, 0x53d43cec, R9, R10, R11, R8, R13, R14, DI, R12)
+	ROUND1(28, 0xa7a879d8, R8, R9, R10, R11, R12, R13, R14, DI)
+	ROUND1(29, 0x4f50f3b1, R11, R8, R9, R10, DI, R12, R13, R14)
+	ROUND1(30, 0x9ea1e762, R10, R11, R8, R9, R14, DI, R12, R13)
+	ROUND1(31, 0x3d43cec5, R9, R10, R11, R8, R13, R14, DI, R12)
+	ROUND1(32, 0x7a879d8a, R8, R9, R10, R11, R12, R13, R14, DI)
+	ROUND1(33, 0xf50f3b14, R11, R8, R9, R10, DI, R12, R13, R14)
+	ROUND1(34, 0xea1e7629, R10, R11, R8, R9, R14, DI, R12, R13)
+	ROUND1(35, 0xd43cec53, R9, R10, R11, R8, R13, R14, DI, R12)
+	ROUND1(36, 0xa879d8a7, R8, R9, R10, R11, R12, R13, R14, DI)
+	ROUND1(37, 0x50f3b14f, R11, R8, R9, R10, DI, R12, R13, R14)
+	ROUND1(38, 0xa1e7629e, R10, R11, R8, R9, R14, DI, R12, R13)
+	ROUND1(39, 0x43cec53d, R9, R10, R11, R8, R13, R14, DI, R12)
+	ROUND1(40, 0x879d8a7a, R8, R9, R10, R11, R12, R13, R14, DI)
+	ROUND1(41, 0x0f3b14f5, R11, R8, R9, R10, DI, R12, R13, R14)
+	ROUND1(42, 0x1e7629ea, R10, R11, R8, R9, R14, DI, R12, R13)
+	ROUND1(43, 0x3cec53d4, R9, R10, R11, R8, R13, R14, DI, R12)
+	ROUND1(44, 0x79d8a7a8, R8, R9, R10, R11, R12, R13, R14, DI)
+	ROUND1(45, 0xf3b14f50, R11, R8, R9, R10, DI, R12, R13, R14)
+	ROUND1(46, 0xe7629ea1, R10, R11, R8, R9, R14, DI, R12, R13)
+	ROUND1(47, 0xcec53d43, R9, R10, R11, R8, R13, R14, DI, R12)
+	ROUND1(48, 0x9d8a7a87, R8, R9, R10, R11, R12, R13, R14, DI)
+	ROUND1(49, 0x3b14f50f, R11, R8, R9, R10, DI, R12, R13, R14)
+	ROUND1(50, 0x7629ea1e, R10, R11, R8, R9, R14, DI, R12, R13)
+	ROUND1(51, 0xec53d43c, R9, R10, R11, R8, R13, R14, DI, R12)
+	ROUND1(52, 0xd8a7a879, R8, R9, R10, R11, R12, R13, R14, DI)
+	ROUND1(53, 0xb14f50f3, R11, R8, R9, R10, DI, R12, R13, R14)
+	ROUND1(54, 0x629ea1e7, R10, R11, R8, R9, R14, DI, R12, R13)
+	ROUND1(55, 0xc53d43ce, R9, R10, R11, R8, R13, R14, DI, R12)
+	ROUND1(56, 0x8a7a879d, R8, R9, R10, R11, R12, R13, R14, DI)
+	ROUND1(57, 0x14f50f3b, R11, R8, R9, R10, DI, R12, R13, R14)
+	ROUND1(58, 0x29ea1e76, R10, R11, R8, R9, R14, DI, R12, R13)
+	ROUND1(59, 0x53d43cec, R9, R10, R11, R8, R13, R14, DI, R12)
+	ROUND1(60, 0xa7a879d8, R8, R9, R10, R11, R12, R13, R14, DI)
+	ROUND1(61, 0x4f50f3b1, R11, R8, R9, R10, DI, R12, R13, R14)
+	ROUND1(62, 0x9ea1e762, R10, R11, R8, R9, R14, DI, R12, R13)
+	ROUND1(63, 0x3d43cec5, R9, R10, R11, R8, R13, R14, DI, R12)
+
+	MOVQ dig+0(FP), AX
+	XORL 0(AX), R8
+	MOVL R8, 0(AX)
+	XORL 4(AX), R9
+	MOVL R9, 4(AX)
+	XORL 8(AX), R10
+	MOVL R10, 8(AX)
+	XORL 12(AX), R11
+	MOVL R11, 12(AX)
+	XORL 16(AX), R12
+	MOVL R12, 16(AX)
+	XORL 20(AX), R13
+	MOVL R13, 20(AX)
+	XORL 24(AX), R14
+	MOVL R14, 24(AX)
+	XORL 28(AX), DI
+	MOVL DI, 28(AX)
+
+	ADDQ $64, SI
+	CMPQ SI, p_len+16(FP)
+	JB   loop
+
+end:
+	RET
@@ -0,0 +1,7 @@
+//go:build !amd64 || purego
+
+package sm3
+
+func block(dig *[8]uint32, p []byte) {
+	blockGeneric(dig, p)
+}
@@ -16,6 +16,9 @@ limitations under the License.
 package sm3
 
 import (
+	"bytes"
+	"crypto"
+	_ "crypto/sha256"
 	"fmt"
 	"io/ioutil"
 	"os"
@@ -52,6 +55,33 @@ func TestSm3(t *testing.T) {
 
 }
 
+func TestNewIsReset(t *testing.T) {
+	// GM/T 0004-2012 example 1
+	want := []byte{
+		0x66, 0xc7, 0xf0, 0xf4, 0x62, 0xee, 0xed, 0xd9, 0xd1, 0xf2, 0xd4, 0x6b, 0xdc, 0x10, 0xe4, 0xe2,
+		0x41, 0x67, 0xc4, 0x87, 0x5c, 0xf2, 0xf7, 0xa2, 0x29, 0x7d, 0xa0, 0x2b, 0x8f, 0x4b, 0xa8, 0xe0,
+	}
+	h := New()
+	h.Write([]byte("abc"))
+	if got := h.Sum(nil); !bytes.Equal(got, want) {
+		t.Errorf("New().Sum(abc) = %x, want %x", got, want)
+	}
+}
+
+func TestSumAppends(t *testing.T) {
+	h := New()
+	h.Write([]byte("abc"))
+	prefix := []byte("prefix")
+	out := h.Sum(prefix)
+	want := append([]byte("prefix"), Sm3Sum([]byte("abc"))...)
+	if !bytes.Equal(out, want) {
+		t.Errorf("Sum(prefix) = %x, want %x", out, want)
+	}
+	if !bytes.Equal(h.Sum(nil), want[len(prefix):]) {
+		t.Error("Sum changed the hash state")
+	}
+}
+
 func BenchmarkSm3(t *testing.B) {
 	t.ReportAllocs()
 	msg := []byte("test")
@@ -62,3 +92,20 @@ func BenchmarkSm3(t *testing.B) {
 		Sm3Sum(msg)
 	}
 }
+
+func TestHashID(t *testing.T) {
+	if Hash.HashFunc() != Hash {
+		t.Error("Hash.HashFunc is not Hash")
+	}
+	if Hash.Available() {
+		t.Error("Hash is unexpectedly registered with crypto")
+	}
+	h := NewHash(Hash)
+	h.Write([]byte("abc"))
+	if !bytes.Equal(h.Sum(nil), Sm3Sum([]byte("abc"))) {
+		t.Error("NewHash(Hash) does not compute SM3")
+	}
+	if NewHash(crypto.SHA256).Size() != 32 || NewHash(crypto.SHA256).BlockSize() != 64 {
+		t.Error("NewHash(crypto.SHA256) is not SHA-256")
+	}
+}
@@ -0,0 +1,72 @@
+package sm3
+
+import (
+	"hash"
+	"log"
+	"runtime"
+	"strconv"
+	"sync/atomic"
+)
+
+// leakLogf reports hashers from GetTracked that were never returned. It is
+// a variable so tests can capture the reports.
+var leakLogf = log.Printf
+
+// trackedHash wraps a pooled hasher for GetTracked and records whether it
+// has been returned.
+type trackedHash struct {
+	h        hash.Hash
+	returned int32
+	caller   string
+}
+
+// GetTracked is a checked version of Get for finding pool misuse. The
+// returned hasher must be given back with PutTracked: a second PutTracked
+// of the same hasher, or any use of it after PutTracked, panics, and a
+// hasher that is garbage collected without being returned is logged with
+// the location of the GetTracked call. Tracking costs an allocation and a
+// finalizer per call; Get and Put are unaffected.
+func GetTracked() hash.Hash {
+	t := &trackedHash{h: Get(), caller: "unknown caller"}
+	if _, file, line, ok := runtime.Caller(1); ok {
+		t.caller = file + ":" + strconv.Itoa(line)
+	}
+	runtime.SetFinalizer(t, func(t *trackedHash) {
+		if atomic.LoadInt32(&t.returned) == 0 {
+			leakLogf("sm3: hasher from GetTracked at %s was not returned with PutTracked", t.caller)
+		}
+	})
+	return t
+}
+
+// PutTracked returns a hasher obtained from GetTracked to the pool. It
+// panics if h did not come from GetTracked or was already returned.
+func PutTracked(h hash.Hash) {
+	t, ok := h.(*trackedHash)
+	if !ok {
+		panic("sm3: PutTracked of a hasher not obtained from GetTracked")
+	}
+	if !atomic.CompareAndSwapInt32(&t.returned, 0, 1) {
+		panic("sm3: PutTracked called twice for the hasher from " + t.caller)
+	}
+	runtime.SetFinalizer(t, nil)
+	Put(t.h)
+}
+
+// live returns the wrapped hasher, panicking if it was already returned.
+func (t *trackedHash) live() hash.Hash {
+	if atomic.LoadInt32(&t.returned) != 0 {
+		panic("sm3: use of the hasher from " + t.caller + " after PutTracked")
+	}
+	return t.h
+}
+
+func (t *trackedHash) Write(p []byte) (int, error) { return t.live().Write(p) }
+
+func (t *trackedHash) Sum(b []byte) []byte { return t.live().Sum(b) }
+
+func (t *trackedHash) Reset() { t.live().Reset() }
+
+func (t *trackedHash) Size() int { return t.h.Size() }
+
+func (t *trackedHash) BlockSize() int { return t.h.BlockSize() }
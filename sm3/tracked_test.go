@@ -0,0 +1,68 @@
+package sm3
+
+import (
+	"bytes"
+	"fmt"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func mustPanic(t *testing.T, name string, f func()) {
+	t.Helper()
+	defer func() {
+		if recover() == nil {
+			t.Errorf("%s did not panic", name)
+		}
+	}()
+	f()
+}
+
+func TestTracked(t *testing.T) {
+	h := GetTracked()
+	h.Write([]byte("abc"))
+	want := Sm3Sum([]byte("abc"))
+	if got := h.Sum(nil); !bytes.Equal(got, want) {
+		t.Errorf("tracked hasher: got %x, want %x", got, want)
+	}
+	PutTracked(h)
+	mustPanic(t, "double PutTracked", func() { PutTracked(h) })
+	mustPanic(t, "Write after PutTracked", func() { h.Write([]byte("x")) })
+	mustPanic(t, "PutTracked of an untracked hasher", func() { PutTracked(New()) })
+}
+
+func TestTrackedLeak(t *testing.T) {
+	var mu sync.Mutex
+	var logs []string
+	saved := leakLogf
+	defer func() { leakLogf = saved }()
+	leakLogf = func(format string, args ...interface{}) {
+		mu.Lock()
+		logs = append(logs, fmt.Sprintf(format, args...))
+		mu.Unlock()
+	}
+
+	func() {
+		GetTracked().Write([]byte("leaked"))
+	}()
+	for i := 0; i < 50; i++ {
+		runtime.GC()
+		mu.Lock()
+		n := len(logs)
+		mu.Unlock()
+		if n > 0 {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(logs) == 0 {
+		t.Skip("finalizer did not run")
+	}
+	if !strings.Contains(logs[0], "tracked_test.go") {
+		t.Errorf("leak report does not name the caller: %q", logs[0])
+	}
+}
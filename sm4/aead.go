@@ -0,0 +1,113 @@
+package sm4
+
+import (
+	"crypto/cipher"
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// aeadConstructors maps the names accepted by NewAEADByName to the
+// functions building the corresponding SM4 based AEAD from a raw key.
+// Every registered AEAD must compare tags in constant time and return a nil
+// plaintext when Open fails.
+var aeadConstructors = map[string]func(key []byte) (cipher.AEAD, error){
+	"sm4-ccm": newCCMFromKey,
+	"sm4-eax": newEAXFromKey,
+	"sm4-gcm": newGCMFromKey,
+}
+
+// NewAEADByName returns the SM4 based AEAD registered under name, keyed with
+// key. It lets configuration driven systems pick the AEAD mode at runtime.
+// Names are matched case-insensitively; see AEADNames for the supported set.
+func NewAEADByName(name string, key []byte) (cipher.AEAD, error) {
+	newAEAD, ok := aeadConstructors[strings.ToLower(name)]
+	if !ok {
+		return nil, errors.New("SM4: unknown AEAD " + strconv.Quote(name) +
+			" (supported: " + strings.Join(AEADNames(), ", ") + ")")
+	}
+	return newAEAD(key)
+}
+
+// AEADNames returns the sorted list of names accepted by NewAEADByName.
+func AEADNames() []string {
+	names := make([]string, 0, len(aeadConstructors))
+	for name := range aeadConstructors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// NewGCM returns SM4 in Galois Counter Mode with the standard 12 byte nonce
+// and 16 byte tag. It mirrors cipher.NewGCM: Open returns a nil plaintext
+// and an error when authentication fails. block must come from NewCipher.
+// The GHASH tables for the key are computed here, so reuse the returned
+// AEAD rather than calling NewGCM per message.
+func NewGCM(block cipher.Block) (cipher.AEAD, error) {
+	return NewGCMWithNonceSize(block, 12)
+}
+
+// NewGCMWithNonceSize is like NewGCM but accepts nonces of the given
+// length. Only use it for interoperability with systems using non-standard
+// nonce lengths.
+func NewGCMWithNonceSize(block cipher.Block, size int) (cipher.AEAD, error) {
+	c, ok := block.(*Sm4Cipher)
+	if !ok {
+		return nil, errors.New("SM4: GCM requires a block created by NewCipher")
+	}
+	return newGCM(c, size, gcmTagSize)
+}
+
+func newGCMFromKey(key []byte) (cipher.AEAD, error) {
+	block, err := NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return NewGCM(block)
+}
+
+// AuthenticateOnly returns the SM4-GCM tag over aad with an empty
+// plaintext, for protocols that send authenticated acknowledgements
+// carrying no payload. nonce must be 12 bytes and never reused with key.
+func AuthenticateOnly(key, nonce, aad []byte) (tag []byte, err error) {
+	aead, err := newGCMFromKey(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(nonce) != aead.NonceSize() {
+		return nil, errors.New("SM4: invalid nonce size " + strconv.Itoa(len(nonce)))
+	}
+	return aead.Seal(nil, nonce, nil, aad), nil
+}
+
+// VerifyOnly checks a tag produced by AuthenticateOnly for aad.
+func VerifyOnly(key, nonce, aad, tag []byte) error {
+	aead, err := newGCMFromKey(key)
+	if err != nil {
+		return err
+	}
+	if len(nonce) != aead.NonceSize() {
+		return errors.New("SM4: invalid nonce size " + strconv.Itoa(len(nonce)))
+	}
+	_, err = aead.Open(nil, nonce, tag, aad)
+	return err
+}
+
+// OpenWithAAD opens an SM4-GCM ciphertext || tag sealed with a 12 byte
+// nonce. A nil error guarantees that the plaintext was authenticated
+// together with exactly aad. It does not check that aad is what the caller
+// expected: when the AAD travels with the message, callers must compare it
+// against the value they expect (a header, a record ID) before relying on
+// the plaintext.
+func OpenWithAAD(key, nonce, ciphertext, aad []byte) (plaintext []byte, err error) {
+	aead, err := newGCMFromKey(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(nonce) != aead.NonceSize() {
+		return nil, errors.New("SM4: invalid nonce size " + strconv.Itoa(len(nonce)))
+	}
+	return aead.Open(nil, nonce, ciphertext, aad)
+}
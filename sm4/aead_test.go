@@ -0,0 +1,186 @@
+package sm4
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+)
+
+func TestNewAEADByName(t *testing.T) {
+	key := []byte("1234567890abcdef")
+	plaintext := []byte("crypto agility for sm4 aead modes")
+	aad := []byte("header")
+	for _, name := range AEADNames() {
+		aead, err := NewAEADByName(name, key)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		nonce := make([]byte, aead.NonceSize())
+		ct := aead.Seal(nil, nonce, plaintext, aad)
+		if len(ct) != len(plaintext)+aead.Overhead() {
+			t.Errorf("%s: unexpected ciphertext length %d", name, len(ct))
+		}
+		pt, err := aead.Open(nil, nonce, ct, aad)
+		if err != nil {
+			t.Fatalf("%s: open failed: %v", name, err)
+		}
+		if !bytes.Equal(pt, plaintext) {
+			t.Errorf("%s: round trip mismatch", name)
+		}
+		ct[0] ^= 1
+		if pt, err := aead.Open(nil, nonce, ct, aad); err == nil {
+			t.Errorf("%s: tampered ciphertext accepted", name)
+		} else if pt != nil {
+			t.Errorf("%s: failed open returned a plaintext", name)
+		}
+	}
+
+	if _, err := NewAEADByName("SM4-GCM", key); err != nil {
+		t.Errorf("names should be case-insensitive: %v", err)
+	}
+}
+
+func TestNewAEADByNameUnknown(t *testing.T) {
+	key := []byte("1234567890abcdef")
+	if _, err := NewAEADByName("sm4-foo", key); err == nil {
+		t.Error("expected error for unknown AEAD name")
+	}
+	if _, err := NewAEADByName("sm4-gcm", key[:8]); err == nil {
+		t.Error("expected error for invalid key size")
+	}
+}
+
+func TestAEADEmptyPlaintext(t *testing.T) {
+	key := []byte("1234567890abcdef")
+	aad := []byte("ack seq=42")
+	for _, name := range AEADNames() {
+		aead, err := NewAEADByName(name, key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		nonce := make([]byte, aead.NonceSize())
+		ct := aead.Seal(nil, nonce, nil, aad)
+		if len(ct) != aead.Overhead() {
+			t.Errorf("%s: empty plaintext sealed to %d bytes", name, len(ct))
+		}
+		if pt, err := aead.Open(nil, nonce, ct, aad); err != nil || len(pt) != 0 {
+			t.Errorf("%s: empty plaintext did not round-trip: %v", name, err)
+		}
+		if _, err := aead.Open(nil, nonce, ct, []byte("ack seq=43")); err == nil {
+			t.Errorf("%s: tampered AAD accepted", name)
+		}
+	}
+}
+
+func TestAuthenticateOnly(t *testing.T) {
+	key := []byte("1234567890abcdef")
+	nonce := []byte("unique nonce")
+	aad := []byte("ack seq=42")
+	tag, err := AuthenticateOnly(key, nonce, aad)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tag) != 16 {
+		t.Errorf("tag is %d bytes", len(tag))
+	}
+	if err := VerifyOnly(key, nonce, aad, tag); err != nil {
+		t.Errorf("valid tag rejected: %v", err)
+	}
+	if err := VerifyOnly(key, nonce, []byte("ack seq=43"), tag); err == nil {
+		t.Error("tag accepted for tampered AAD")
+	}
+	tag[0] ^= 1
+	if err := VerifyOnly(key, nonce, aad, tag); err == nil {
+		t.Error("tampered tag accepted")
+	}
+	if _, err := AuthenticateOnly(key, nonce[:8], aad); err == nil {
+		t.Error("short nonce accepted")
+	}
+}
+
+func TestOpenWithAAD(t *testing.T) {
+	key := []byte("1234567890abcdef")
+	nonce := []byte("unique nonce")
+	aad := []byte("record-id=7")
+	aead, err := newGCMFromKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ct := aead.Seal(nil, nonce, []byte("payload"), aad)
+
+	pt, err := OpenWithAAD(key, nonce, ct, aad)
+	if err != nil || string(pt) != "payload" {
+		t.Errorf("matching aad: got %q, %v", pt, err)
+	}
+	if pt, err := OpenWithAAD(key, nonce, ct, []byte("record-id=8")); err == nil || pt != nil {
+		t.Errorf("mismatched aad: got %q, %v", pt, err)
+	}
+	if _, err := OpenWithAAD(key, nonce, ct, nil); err == nil {
+		t.Error("missing aad accepted")
+	}
+	if _, err := OpenWithAAD(key, nonce[:8], ct, aad); err == nil {
+		t.Error("short nonce accepted")
+	}
+}
+
+// TestNewGCMVector checks the SM4-GCM example of RFC 8998 appendix A.
+func TestNewGCMVector(t *testing.T) {
+	block, err := NewCipher(fromHex("0123456789abcdeffedcba9876543210"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	aead, err := NewGCM(block)
+	if err != nil {
+		t.Fatal(err)
+	}
+	nonce := fromHex("00001234567800000000abcd")
+	aad := fromHex("feedfacedeadbeeffeedfacedeadbeefabaddad2")
+	plaintext := fromHex("aaaaaaaaaaaaaaaabbbbbbbbbbbbbbbbccccccccccccccccdddddddddddddddd" +
+		"eeeeeeeeeeeeeeeeffffffffffffffffeeeeeeeeeeeeeeeeaaaaaaaaaaaaaaaa")
+	want := "17f399f08c67d5ee19d0dc9969c4bb7d5fd46fd3756489069157b282bb200735" +
+		"d82710ca5c22f0ccfa7cbf93d496ac15a56834cbcf98c397b4024a2691233b8d" +
+		"83de3541e4c2b58177e065a9bf7b62ec"
+	if got := hex.EncodeToString(aead.Seal(nil, nonce, plaintext, aad)); got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
+
+func TestNewGCM(t *testing.T) {
+	key := []byte("1234567890abcdef")
+	block, err := NewCipher(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	plaintext := []byte("drop-in cipher.AEAD for sm4")
+	aad := []byte("record header")
+	for _, nonceSize := range []int{12, 8, 16} {
+		aead, err := NewGCMWithNonceSize(block, nonceSize)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if aead.NonceSize() != nonceSize || aead.Overhead() != 16 {
+			t.Errorf("nonce size %d: NonceSize %d, Overhead %d", nonceSize, aead.NonceSize(), aead.Overhead())
+		}
+		nonce := bytes.Repeat([]byte{7}, nonceSize)
+		ct := aead.Seal(nil, nonce, plaintext, aad)
+
+		pt, err := aead.Open(nil, nonce, ct, aad)
+		if err != nil || !bytes.Equal(pt, plaintext) {
+			t.Errorf("nonce size %d: open failed: %v", nonceSize, err)
+		}
+		ct[len(ct)-1] ^= 1
+		if pt, err := aead.Open(nil, nonce, ct, aad); err == nil || pt != nil {
+			t.Errorf("nonce size %d: tampered tag accepted", nonceSize)
+		}
+	}
+
+	if _, err := NewGCM(fakeBlock{}); err == nil {
+		t.Error("foreign block accepted")
+	}
+}
+
+type fakeBlock struct{}
+
+func (fakeBlock) BlockSize() int          { return BlockSize }
+func (fakeBlock) Encrypt(dst, src []byte) { copy(dst, src) }
+func (fakeBlock) Decrypt(dst, src []byte) { copy(dst, src) }
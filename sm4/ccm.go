@@ -0,0 +1,184 @@
+package sm4
+
+import (
+	"crypto/cipher"
+	"crypto/subtle"
+	"encoding/binary"
+	"errors"
+	"strconv"
+)
+
+var errCCMOpen = errors.New("SM4: CCM message authentication failed")
+
+// ccm implements CCM (NIST SP 800-38C): CBC-MAC over the formatted nonce,
+// AAD and plaintext, followed by CTR encryption of the plaintext and tag.
+type ccm struct {
+	block     cipher.Block
+	nonceSize int
+	tagSize   int
+}
+
+// NewCCM returns SM4 in CCM mode with the given nonce length (7 to 13
+// bytes) and tag length (4, 6, 8, 10, 12, 14 or 16 bytes). A nonce of n
+// bytes limits messages to 2^(8*(15-n)) - 1 bytes.
+func NewCCM(key []byte, nonceSize, tagSize int) (cipher.AEAD, error) {
+	block, err := NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return newCCMWithBlock(block, nonceSize, tagSize)
+}
+
+func newCCMWithBlock(block cipher.Block, nonceSize, tagSize int) (cipher.AEAD, error) {
+	if block.BlockSize() != BlockSize {
+		return nil, errors.New("SM4: CCM requires a 128-bit block cipher")
+	}
+	if nonceSize < 7 || nonceSize > 13 {
+		return nil, errors.New("SM4: invalid CCM nonce size " + strconv.Itoa(nonceSize))
+	}
+	if tagSize < 4 || tagSize > 16 || tagSize%2 != 0 {
+		return nil, errors.New("SM4: invalid CCM tag size " + strconv.Itoa(tagSize))
+	}
+	return &ccm{block: block, nonceSize: nonceSize, tagSize: tagSize}, nil
+}
+
+func newCCMFromKey(key []byte) (cipher.AEAD, error) {
+	return NewCCM(key, 12, 16)
+}
+
+// EncryptCCM seals plaintext with SM4-CCM and returns ciphertext || tag.
+func EncryptCCM(key, nonce, plaintext, aad []byte, tagSize int) ([]byte, error) {
+	aead, err := NewCCM(key, len(nonce), tagSize)
+	if err != nil {
+		return nil, err
+	}
+	if uint64(len(plaintext)) > aead.(*ccm).maxLength() {
+		return nil, errors.New("SM4: plaintext too large for CCM nonce size")
+	}
+	return aead.Seal(nil, nonce, plaintext, aad), nil
+}
+
+// DecryptCCM verifies and opens ciphertext || tag produced by EncryptCCM.
+// No plaintext is returned unless the tag is valid.
+func DecryptCCM(key, nonce, ciphertext, aad []byte, tagSize int) ([]byte, error) {
+	aead, err := NewCCM(key, len(nonce), tagSize)
+	if err != nil {
+		return nil, err
+	}
+	return aead.Open(nil, nonce, ciphertext, aad)
+}
+
+func (c *ccm) NonceSize() int { return c.nonceSize }
+
+func (c *ccm) Overhead() int { return c.tagSize }
+
+// maxLength returns the largest message length encodable in the q = 15 - n
+// length bytes of the first block.
+func (c *ccm) maxLength() uint64 {
+	q := uint(15 - c.nonceSize)
+	if q >= 8 {
+		return 1<<63 - 1
+	}
+	return 1<<(8*q) - 1
+}
+
+func (c *ccm) Seal(dst, nonce, plaintext, aad []byte) []byte {
+	if len(nonce) != c.nonceSize {
+		panic("SM4: incorrect nonce length given to CCM")
+	}
+	if uint64(len(plaintext)) > c.maxLength() {
+		panic("SM4: message too large for CCM")
+	}
+	ret, out := sliceForAppend(dst, len(plaintext)+c.tagSize)
+	tag := c.mac(nonce, plaintext, aad)
+	c.ctr(nonce, out[:len(plaintext)], plaintext, tag[:])
+	copy(out[len(plaintext):], tag[:c.tagSize])
+	return ret
+}
+
+func (c *ccm) Open(dst, nonce, ciphertext, aad []byte) ([]byte, error) {
+	if len(nonce) != c.nonceSize {
+		panic("SM4: incorrect nonce length given to CCM")
+	}
+	if len(ciphertext) < c.tagSize || uint64(len(ciphertext)-c.tagSize) > c.maxLength() {
+		return nil, errCCMOpen
+	}
+	n := len(ciphertext) - c.tagSize
+	ret, out := sliceForAppend(dst, n)
+	var s0 [BlockSize]byte
+	copy(s0[:], ciphertext[n:])
+	c.ctr(nonce, out, ciphertext[:n], s0[:])
+	tag := c.mac(nonce, out, aad)
+	if subtle.ConstantTimeCompare(tag[:c.tagSize], s0[:c.tagSize]) != 1 {
+		for i := range out {
+			out[i] = 0
+		}
+		return nil, errCCMOpen
+	}
+	return ret, nil
+}
+
+// ctr encrypts src into dst with counter blocks 1, 2, ... and XORs the
+// first tagSize bytes of tag with the keystream block of counter 0.
+func (c *ccm) ctr(nonce, dst, src, tag []byte) {
+	var ctr, s0 [BlockSize]byte
+	ctr[0] = byte(14 - c.nonceSize)
+	copy(ctr[1:], nonce)
+	c.block.Encrypt(s0[:], ctr[:])
+	for i := 0; i < c.tagSize; i++ {
+		tag[i] ^= s0[i]
+	}
+	ctr[BlockSize-1] = 1
+	cipher.NewCTR(c.block, ctr[:]).XORKeyStream(dst, src)
+}
+
+// mac computes the CBC-MAC of the formatted B0, AAD and plaintext blocks.
+func (c *ccm) mac(nonce, plaintext, aad []byte) [BlockSize]byte {
+	var x [BlockSize]byte
+	x[0] = byte((c.tagSize-2)/2<<3 | (14 - c.nonceSize))
+	if len(aad) > 0 {
+		x[0] |= 0x40
+	}
+	copy(x[1:], nonce)
+	var l [8]byte
+	binary.BigEndian.PutUint64(l[:], uint64(len(plaintext)))
+	copy(x[1+c.nonceSize:], l[8-(15-c.nonceSize):])
+	c.block.Encrypt(x[:], x[:])
+
+	if len(aad) > 0 {
+		var hdr []byte
+		switch n := uint64(len(aad)); {
+		case n < 1<<16-1<<8:
+			hdr = binary.BigEndian.AppendUint16(nil, uint16(n))
+		case n <= 1<<32-1:
+			hdr = binary.BigEndian.AppendUint32([]byte{0xff, 0xfe}, uint32(n))
+		default:
+			hdr = binary.BigEndian.AppendUint64([]byte{0xff, 0xff}, n)
+		}
+		c.cbcMAC(&x, append(hdr, aad...))
+	}
+	c.cbcMAC(&x, plaintext)
+	return x
+}
+
+// cbcMAC absorbs data zero padded to a multiple of the block size into x.
+func (c *ccm) cbcMAC(x *[BlockSize]byte, data []byte) {
+	for len(data) > 0 {
+		n := subtle.XORBytes(x[:], x[:], data)
+		c.block.Encrypt(x[:], x[:])
+		data = data[n:]
+	}
+}
+
+// sliceForAppend extends in by n bytes, reallocating if needed, and returns
+// the whole slice and the n new bytes.
+func sliceForAppend(in []byte, n int) (head, tail []byte) {
+	if total := len(in) + n; cap(in) >= total {
+		head = in[:total]
+	} else {
+		head = make([]byte, total)
+		copy(head, in)
+	}
+	tail = head[len(in):]
+	return
+}
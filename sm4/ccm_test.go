@@ -0,0 +1,92 @@
+package sm4
+
+import (
+	"bytes"
+	"crypto/aes"
+	"encoding/hex"
+	"testing"
+)
+
+func fromHex(s string) []byte {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		panic(err)
+	}
+	return b
+}
+
+// TestCCMVectors checks the mode itself against the AES examples of NIST
+// SP 800-38C appendix C, since SM4 has no published CCM vectors.
+func TestCCMVectors(t *testing.T) {
+	key := fromHex("404142434445464748494a4b4c4d4e4f")
+	block, _ := aes.NewCipher(key)
+	for i, tc := range []struct {
+		nonce, aad, plaintext, ciphertext string
+		tagSize                           int
+	}{
+		{"10111213141516", "0001020304050607", "20212223", "7162015b4dac255d", 4},
+		{"1011121314151617", "000102030405060708090a0b0c0d0e0f",
+			"202122232425262728292a2b2c2d2e2f", "d2a1f0e051ea5f62081a7792073d593d1fc64fbfaccd", 6},
+		{"101112131415161718191a1b", "000102030405060708090a0b0c0d0e0f10111213",
+			"202122232425262728292a2b2c2d2e2f3031323334353637",
+			"e3b201a9f5b71a7a9b1ceaeccd97e70b6176aad9a4428aa5484392fbc1b09951", 8},
+	} {
+		aead, err := newCCMWithBlock(block, len(tc.nonce)/2, tc.tagSize)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ct := aead.Seal(nil, fromHex(tc.nonce), fromHex(tc.plaintext), fromHex(tc.aad))
+		if got := hex.EncodeToString(ct); got != tc.ciphertext {
+			t.Errorf("example %d: got %s, want %s", i+1, got, tc.ciphertext)
+		}
+		pt, err := aead.Open(nil, fromHex(tc.nonce), ct, fromHex(tc.aad))
+		if err != nil || !bytes.Equal(pt, fromHex(tc.plaintext)) {
+			t.Errorf("example %d: open failed: %v", i+1, err)
+		}
+	}
+}
+
+func TestCCMRoundTrip(t *testing.T) {
+	key := []byte("1234567890abcdef")
+	plaintext := bytes.Repeat([]byte("iot payload "), 20)
+	aad := []byte("header")
+	for nonceSize := 7; nonceSize <= 13; nonceSize += 3 {
+		for tagSize := 4; tagSize <= 16; tagSize += 2 {
+			nonce := bytes.Repeat([]byte{0x42}, nonceSize)
+			for _, msg := range [][]byte{nil, plaintext[:1], plaintext[:16], plaintext} {
+				ct, err := EncryptCCM(key, nonce, msg, aad, tagSize)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if len(ct) != len(msg)+tagSize {
+					t.Fatalf("n=%d t=%d: ciphertext length %d", nonceSize, tagSize, len(ct))
+				}
+				pt, err := DecryptCCM(key, nonce, ct, aad, tagSize)
+				if err != nil || !bytes.Equal(pt, msg) {
+					t.Fatalf("n=%d t=%d: round trip failed: %v", nonceSize, tagSize, err)
+				}
+				ct[len(ct)-1] ^= 1
+				if pt, err := DecryptCCM(key, nonce, ct, aad, tagSize); err == nil || pt != nil {
+					t.Fatalf("n=%d t=%d: tampered tag accepted", nonceSize, tagSize)
+				}
+			}
+		}
+	}
+}
+
+func TestCCMInvalidParameters(t *testing.T) {
+	key := []byte("1234567890abcdef")
+	for _, n := range []int{6, 14} {
+		if _, err := EncryptCCM(key, make([]byte, n), nil, nil, 16); err == nil {
+			t.Errorf("nonce size %d accepted", n)
+		}
+	}
+	for _, tagSize := range []int{0, 2, 5, 17, 18} {
+		if _, err := EncryptCCM(key, make([]byte, 12), nil, nil, tagSize); err == nil {
+			t.Errorf("tag size %d accepted", tagSize)
+		}
+	}
+	if _, err := DecryptCCM(key, make([]byte, 12), make([]byte, 8), nil, 16); err == nil {
+		t.Error("ciphertext shorter than the tag accepted")
+	}
+}
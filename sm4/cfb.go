@@ -0,0 +1,66 @@
+package sm4
+
+import (
+	"crypto/subtle"
+	"errors"
+	"strconv"
+)
+
+// Sm4CFBWithSize encrypts or decrypts data with SM4 in CFB mode using a
+// segment size of segBits bits (NIST SP 800-38A CFB-s). segBits must be 1,
+// 8, 64 or 128, and data must be a whole number of segments; CFB1 and CFB8
+// therefore accept any length. No padding is applied, unlike Sm4CFB, and
+// the IV is taken from iv rather than the package IV.
+func Sm4CFBWithSize(key, iv, data []byte, encrypt bool, segBits int) ([]byte, error) {
+	switch segBits {
+	case 1, 8, 64, 128:
+	default:
+		return nil, errors.New("SM4: invalid CFB segment size " + strconv.Itoa(segBits))
+	}
+	if len(iv) != BlockSize {
+		return nil, errors.New("SM4: invalid iv size")
+	}
+	if segBits >= 8 && len(data)%(segBits/8) != 0 {
+		return nil, errors.New("SM4: CFB" + strconv.Itoa(segBits) + " input is not a whole number of segments")
+	}
+	c, err := NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	var reg, ks [BlockSize]byte
+	copy(reg[:], iv)
+	out := make([]byte, len(data))
+	if segBits == 1 {
+		for i := 0; i < len(data)*8; i++ {
+			c.Encrypt(ks[:], reg[:])
+			shift := uint(7 - i%8)
+			in := data[i/8] >> shift & 1
+			o := in ^ ks[0]>>7
+			out[i/8] |= o << shift
+			fb := o
+			if !encrypt {
+				fb = in
+			}
+			// Shift the register left by one bit, feeding in the ciphertext bit.
+			for j := 0; j < BlockSize-1; j++ {
+				reg[j] = reg[j]<<1 | reg[j+1]>>7
+			}
+			reg[BlockSize-1] = reg[BlockSize-1]<<1 | fb
+		}
+		return out, nil
+	}
+
+	s := segBits / 8
+	for off := 0; off < len(data); off += s {
+		c.Encrypt(ks[:], reg[:])
+		subtle.XORBytes(out[off:off+s], data[off:off+s], ks[:s])
+		fb := out[off : off+s]
+		if !encrypt {
+			fb = data[off : off+s]
+		}
+		copy(reg[:], reg[s:])
+		copy(reg[BlockSize-s:], fb)
+	}
+	return out, nil
+}
@@ -0,0 +1,75 @@
+package sm4
+
+import (
+	"bytes"
+	"crypto/cipher"
+	"testing"
+)
+
+func TestSm4CFBWithSize(t *testing.T) {
+	key := []byte("1234567890abcdef")
+	iv := []byte("fedcba0987654321")
+	data := make([]byte, 64)
+	for i := range data {
+		data[i] = byte(i * 7)
+	}
+
+	block, err := NewCipher(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := make([]byte, len(data))
+	cipher.NewCFBEncrypter(block, iv).XORKeyStream(want, data)
+	got, err := Sm4CFBWithSize(key, iv, data, true, 128)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Error("CFB128 does not match cipher.NewCFBEncrypter")
+	}
+
+	for _, seg := range []int{1, 8, 64, 128} {
+		ct, err := Sm4CFBWithSize(key, iv, data, true, seg)
+		if err != nil {
+			t.Fatalf("CFB%d: %v", seg, err)
+		}
+		pt, err := Sm4CFBWithSize(key, iv, ct, false, seg)
+		if err != nil {
+			t.Fatalf("CFB%d: %v", seg, err)
+		}
+		if !bytes.Equal(pt, data) {
+			t.Errorf("CFB%d: round trip failed", seg)
+		}
+		// A segment of the keystream depends on the previous ciphertext, so
+		// each segment size yields its own ciphertext after the first byte.
+		if seg != 128 && bytes.Equal(ct[8:], want[8:]) {
+			t.Errorf("CFB%d: ciphertext equals CFB128", seg)
+		}
+	}
+
+	// CFB8 accepts unaligned input and resynchronises after a corrupted
+	// byte has left the 16 byte register.
+	odd := data[:37]
+	ct, err := Sm4CFBWithSize(key, iv, odd, true, 8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ct[3] ^= 1
+	pt, err := Sm4CFBWithSize(key, iv, ct, false, 8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(pt[:3], odd[:3]) || bytes.Equal(pt[3:4], odd[3:4]) || !bytes.Equal(pt[20:], odd[20:]) {
+		t.Error("CFB8 does not resynchronise after a corrupted byte")
+	}
+
+	if _, err := Sm4CFBWithSize(key, iv, data[:12], true, 64); err == nil {
+		t.Error("CFB64 accepted a partial segment")
+	}
+	if _, err := Sm4CFBWithSize(key, iv, data, true, 32); err == nil {
+		t.Error("segment size 32 accepted")
+	}
+	if _, err := Sm4CFBWithSize(key, iv[:8], data, true, 8); err == nil {
+		t.Error("short iv accepted")
+	}
+}
@@ -0,0 +1,76 @@
+package sm4
+
+import (
+	"crypto/cipher"
+	"errors"
+)
+
+// EncryptChannel encrypts the chunks received from in and sends the
+// ciphertext to out. Chunks may have any length: chaining state is kept
+// across them and only whole blocks are emitted, so the concatenation of
+// the output equals EncryptWithKey (with IV set to iv) of the concatenation
+// of the input. PKCS#7 padding is applied once in is closed. out is closed
+// when EncryptChannel returns.
+//
+// If the key, iv or mode is rejected, out is closed at once and the chunks
+// received from in are discarded until in is closed, so a producer still
+// sending on in does not block; the error is returned after that.
+func EncryptChannel(in <-chan []byte, out chan<- []byte, key, iv []byte, mode CipherMode) error {
+	crypt, err := newChannelCrypter(key, iv, mode)
+	if err != nil {
+		close(out)
+		for range in {
+		}
+		return err
+	}
+	defer close(out)
+
+	var pending []byte
+	for chunk := range in {
+		pending = append(pending, chunk...)
+		n := len(pending) / BlockSize * BlockSize
+		if n == 0 {
+			continue
+		}
+		dst := make([]byte, n)
+		crypt(dst, pending[:n])
+		out <- dst
+		pending = append(pending[:0], pending[n:]...)
+	}
+	pending = pkcs7Padding(pending)
+	dst := make([]byte, len(pending))
+	crypt(dst, pending)
+	out <- dst
+	return nil
+}
+
+// newChannelCrypter returns a function encrypting whole blocks in mode,
+// carrying the chaining state from one call to the next.
+func newChannelCrypter(key, iv []byte, mode CipherMode) (func(dst, src []byte), error) {
+	if err := checkECB(mode); err != nil {
+		return nil, err
+	}
+	block, err := NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	if mode != ECB && len(iv) != BlockSize {
+		return nil, errors.New("SM4: invalid iv size")
+	}
+	switch mode {
+	case ECB:
+		return func(dst, src []byte) {
+			for i := 0; i < len(src); i += BlockSize {
+				block.Encrypt(dst[i:i+BlockSize], src[i:i+BlockSize])
+			}
+		}, nil
+	case CBC:
+		return cipher.NewCBCEncrypter(block, iv).CryptBlocks, nil
+	case CFB:
+		return cipher.NewCFBEncrypter(block, iv).XORKeyStream, nil
+	case OFB:
+		return cipher.NewOFB(block, iv).XORKeyStream, nil
+	default:
+		return nil, errors.New("SM4: unsupported cipher mode")
+	}
+}
@@ -0,0 +1,91 @@
+package sm4
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestEncryptChannel(t *testing.T) {
+	key := []byte("1234567890abcdef")
+	iv := []byte("fedcba0987654321")
+	oldIV := IV
+	defer func() { IV = oldIV }()
+	if err := SetIV(iv); err != nil {
+		t.Fatal(err)
+	}
+
+	data := bytes.Repeat([]byte("channel pipeline data "), 20)
+	chunkSizes := []int{1, 15, 16, 17, 100, 0, 3}
+	for _, mode := range []CipherMode{ECB, CBC, CFB, OFB} {
+		for _, total := range []int{0, 16, 100, len(data)} {
+			plaintext := data[:total]
+			want, err := EncryptWithKey(key, append([]byte(nil), plaintext...), mode)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			in := make(chan []byte)
+			out := make(chan []byte)
+			errc := make(chan error, 1)
+			go func() { errc <- EncryptChannel(in, out, key, iv, mode) }()
+			go func() {
+				rest := plaintext
+				for i := 0; len(rest) > 0; i++ {
+					n := chunkSizes[i%len(chunkSizes)]
+					if n > len(rest) {
+						n = len(rest)
+					}
+					in <- rest[:n]
+					rest = rest[n:]
+				}
+				close(in)
+			}()
+			var got []byte
+			for chunk := range out {
+				if len(chunk)%BlockSize != 0 {
+					t.Errorf("mode %d: chunk of %d bytes is not block aligned", mode, len(chunk))
+				}
+				got = append(got, chunk...)
+			}
+			if err := <-errc; err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, want) {
+				t.Errorf("mode %d, %d bytes: channel output differs from EncryptWithKey", mode, total)
+			}
+		}
+	}
+
+	in := make(chan []byte)
+	close(in)
+	if err := EncryptChannel(in, make(chan []byte), key, iv[:8], CBC); err == nil {
+		t.Error("short iv accepted")
+	}
+}
+
+func TestEncryptChannelSetupError(t *testing.T) {
+	in := make(chan []byte)
+	out := make(chan []byte)
+	done := make(chan struct{})
+	go func() {
+		for i := 0; i < 4; i++ {
+			in <- []byte("still sending")
+		}
+		close(in)
+		close(done)
+	}()
+	errc := make(chan error, 1)
+	go func() { errc <- EncryptChannel(in, out, []byte("short key"), nil, CBC) }()
+	for range out {
+		t.Error("ciphertext sent for a rejected key")
+	}
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("producer blocked after a setup error")
+	}
+	if err := <-errc; err == nil {
+		t.Error("short key accepted")
+	}
+}
@@ -0,0 +1,119 @@
+package sm4
+
+import (
+	"crypto/cipher"
+	"errors"
+)
+
+// cmacRb is the constant of the GF(2^128) doubling used for subkey derivation.
+const cmacRb = 0x87
+
+// cmacState holds the block cipher and the K1/K2 subkeys of a CMAC key.
+type cmacState struct {
+	block  cipher.Block
+	k1, k2 [BlockSize]byte
+}
+
+func newCMAC(block cipher.Block) *cmacState {
+	m := &cmacState{block: block}
+	var l [BlockSize]byte
+	block.Encrypt(l[:], l[:])
+	m.k1 = gfDouble(l)
+	m.k2 = gfDouble(m.k1)
+	return m
+}
+
+// gfDouble multiplies b by x in GF(2^128) as defined for CMAC.
+func gfDouble(b [BlockSize]byte) [BlockSize]byte {
+	var out [BlockSize]byte
+	carry := b[0] >> 7
+	for i := 0; i < BlockSize-1; i++ {
+		out[i] = b[i]<<1 | b[i+1]>>7
+	}
+	out[BlockSize-1] = b[BlockSize-1]<<1 ^ byte(-int8(carry))&cmacRb
+	return out
+}
+
+// sum returns the CMAC tag of data.
+func (m *cmacState) sum(data []byte) []byte {
+	var x [BlockSize]byte
+	return m.finish(&x, data)
+}
+
+// sumPrefixed returns the CMAC tag of prefix || data, reading data in place
+// instead of copying it behind the prefix.
+func (m *cmacState) sumPrefixed(prefix *[BlockSize]byte, data []byte) []byte {
+	var x [BlockSize]byte
+	if len(data) == 0 {
+		return m.finish(&x, prefix[:])
+	}
+	x = *prefix
+	m.block.Encrypt(x[:], x[:])
+	return m.finish(&x, data)
+}
+
+// finish absorbs data into the chaining value x and returns the tag,
+// treating the end of data as the end of the message.
+func (m *cmacState) finish(x *[BlockSize]byte, data []byte) []byte {
+	for len(data) > BlockSize {
+		for i := range x {
+			x[i] ^= data[i]
+		}
+		m.block.Encrypt(x[:], x[:])
+		data = data[BlockSize:]
+	}
+	// The last block is XORed with K1 when complete and with K2 after
+	// 10* padding otherwise, which includes the empty message.
+	if len(data) == BlockSize {
+		for i := range x {
+			x[i] ^= data[i] ^ m.k1[i]
+		}
+	} else {
+		for i := range data {
+			x[i] ^= data[i]
+		}
+		x[len(data)] ^= 0x80
+		for i := range x {
+			x[i] ^= m.k2[i]
+		}
+	}
+	m.block.Encrypt(x[:], x[:])
+	return x[:]
+}
+
+// CMAC returns the 16 byte CMAC (OMAC1, NIST SP 800-38B) of data computed
+// with the SM4 block cipher under key.
+func CMAC(key, data []byte) ([]byte, error) {
+	block, err := NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return newCMAC(block).sum(data), nil
+}
+
+// CBCMAC returns the raw CBC-MAC of data under key: the last ciphertext
+// block of SM4-CBC encryption with a zero IV. data must be a non-empty
+// multiple of the block size; no padding is applied.
+//
+// CBCMAC is only provided for interoperability with legacy systems and
+// should not be used in new designs. It is only secure when every message
+// authenticated under a key has the same fixed length: given the tags of
+// two messages, an attacker can forge the tag of a longer one. Use CMAC or
+// an AEAD mode instead.
+func CBCMAC(key, data []byte) ([]byte, error) {
+	if len(data) == 0 || len(data)%BlockSize != 0 {
+		return nil, errors.New("SM4: CBC-MAC input is not a non-empty multiple of the block size")
+	}
+	block, err := NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	var x [BlockSize]byte
+	for ; len(data) > 0; data = data[BlockSize:] {
+		for i := range x {
+			x[i] ^= data[i]
+		}
+		block.Encrypt(x[:], x[:])
+	}
+	return x[:], nil
+}
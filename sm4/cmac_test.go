@@ -0,0 +1,85 @@
+package sm4
+
+import (
+	"bytes"
+	"crypto/cipher"
+	"encoding/hex"
+	"testing"
+)
+
+// The RFC 4493 (AES-CMAC) example key and messages, with the tags computed
+// for SM4 instead of AES.
+func TestCMAC(t *testing.T) {
+	key, _ := hex.DecodeString("2b7e151628aed2a6abf7158809cf4f3c")
+	msg, _ := hex.DecodeString("6bc1bee22e409f96e93d7e117393172a" +
+		"ae2d8a571e03ac9c9eb76fac45af8e51" +
+		"30c81c46a35ce411e5fbc1191a0a52ef" +
+		"f69f2445df4f9b17ad2b417be66c3710")
+	tests := []struct {
+		len int
+		tag string
+	}{
+		{0, "399a9c930964a3d4e38c59da47f0b309"},
+		{16, "4e4c2a4417e567fef081e0fab55a5762"},
+		{40, "8e31701927d50b28d53787513b69dd75"},
+		{64, "cc2b4f3d2c5aaf8a4ac30e28650eddc0"},
+	}
+	for _, test := range tests {
+		tag, err := CMAC(key, msg[:test.len])
+		if err != nil {
+			t.Fatal(err)
+		}
+		want, _ := hex.DecodeString(test.tag)
+		if !bytes.Equal(tag, want) {
+			t.Errorf("CMAC(msg[:%d]) = %x, want %s", test.len, tag, test.tag)
+		}
+	}
+	if _, err := CMAC(key[:15], msg); err == nil {
+		t.Error("expected error for invalid key size")
+	}
+}
+
+func TestCMACPrefixed(t *testing.T) {
+	block, err := NewCipher([]byte("1234567890abcdef"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := newCMAC(block)
+	var prefix [BlockSize]byte
+	prefix[BlockSize-1] = 2
+	data := bytes.Repeat([]byte{0xa5, 0x3c, 0x0f}, 30)
+	for n := 0; n <= len(data); n++ {
+		want := m.sum(append(prefix[:], data[:n]...))
+		if got := m.sumPrefixed(&prefix, data[:n]); !bytes.Equal(got, want) {
+			t.Errorf("%d bytes: sumPrefixed = %x, want %x", n, got, want)
+		}
+	}
+}
+
+func TestCBCMAC(t *testing.T) {
+	key := []byte("1234567890abcdef")
+	block, err := NewCipher(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, n := range []int{16, 32, 160} {
+		data := bytes.Repeat([]byte{byte(n)}, n)
+		ct := make([]byte, n)
+		cipher.NewCBCEncrypter(block, make([]byte, BlockSize)).CryptBlocks(ct, data)
+		mac, err := CBCMAC(key, data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(mac, ct[n-BlockSize:]) {
+			t.Errorf("%d bytes: got %x, want %x", n, mac, ct[n-BlockSize:])
+		}
+	}
+	for _, n := range []int{0, 1, 15, 17} {
+		if _, err := CBCMAC(key, make([]byte, n)); err == nil {
+			t.Errorf("%d byte input accepted", n)
+		}
+	}
+	if _, err := CBCMAC(key[:8], make([]byte, 16)); err == nil {
+		t.Error("short key accepted")
+	}
+}
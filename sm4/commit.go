@@ -0,0 +1,84 @@
+package sm4
+
+import (
+	"crypto/cipher"
+	"crypto/subtle"
+	"errors"
+	"strconv"
+
+	"github.com/tjfoc/gmsm/sm3"
+)
+
+const commitmentSize = 32
+
+var (
+	commitEncLabel = []byte("SM4 committing AEAD encryption key")
+	commitTagLabel = []byte("SM4 committing AEAD commitment")
+
+	errCommitOpen = errors.New("SM4: committing AEAD message authentication failed")
+)
+
+// committingAEAD is SM4-GCM with an SM3 key commitment; see
+// NewCommittingAEAD.
+type committingAEAD struct {
+	key  []byte
+	aead cipher.AEAD
+}
+
+// NewCommittingAEAD returns a key-committing AEAD built on SM4-GCM. Plain
+// GCM ciphertexts can be crafted to open under several keys; this
+// construction prevents that:
+//
+//	encKey     = KDF(16, "SM4 committing AEAD encryption key", key)
+//	commitment = KDF(32, "SM4 committing AEAD commitment", key, nonce)
+//	output     = commitment || SM4-GCM(encKey, nonce, plaintext, aad)
+//
+// where KDF is sm3.KDF. Because SM3 is collision resistant, no ciphertext
+// carries a commitment that is valid for two keys. Open checks the
+// commitment before the GCM tag. Nonces are 12 bytes and the overhead is
+// 48 bytes.
+func NewCommittingAEAD(key []byte) (cipher.AEAD, error) {
+	if len(key) != BlockSize {
+		return nil, errors.New("SM4: invalid key size " + strconv.Itoa(len(key)))
+	}
+	aead, err := newGCMFromKey(sm3.KDF(BlockSize, commitEncLabel, key))
+	if err != nil {
+		return nil, err
+	}
+	k := make([]byte, len(key))
+	copy(k, key)
+	return &committingAEAD{key: k, aead: aead}, nil
+}
+
+func (c *committingAEAD) NonceSize() int { return c.aead.NonceSize() }
+
+func (c *committingAEAD) Overhead() int { return commitmentSize + c.aead.Overhead() }
+
+func (c *committingAEAD) commitment(nonce []byte) []byte {
+	return sm3.KDF(commitmentSize, commitTagLabel, c.key, nonce)
+}
+
+func (c *committingAEAD) Seal(dst, nonce, plaintext, aad []byte) []byte {
+	if len(nonce) != c.NonceSize() {
+		panic("SM4: incorrect nonce length given to committing AEAD")
+	}
+	dst = append(dst, c.commitment(nonce)...)
+	return c.aead.Seal(dst, nonce, plaintext, aad)
+}
+
+func (c *committingAEAD) Open(dst, nonce, ciphertext, aad []byte) ([]byte, error) {
+	if len(nonce) != c.NonceSize() {
+		panic("SM4: incorrect nonce length given to committing AEAD")
+	}
+	if len(ciphertext) < c.Overhead() {
+		return nil, errCommitOpen
+	}
+	if subtle.ConstantTimeCompare(ciphertext[:commitmentSize], c.commitment(nonce)) != 1 {
+		return nil, errCommitOpen
+	}
+	out, err := c.aead.Open(dst, nonce, ciphertext[commitmentSize:], aad)
+	if err != nil {
+		return nil, errCommitOpen
+	}
+	return out, nil
+}
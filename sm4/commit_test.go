@@ -0,0 +1,59 @@
+package sm4
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/tjfoc/gmsm/sm3"
+)
+
+func TestCommittingAEAD(t *testing.T) {
+	key1 := []byte("1234567890abcdef")
+	key2 := []byte("fedcba0987654321")
+	nonce := []byte("unique nonce")
+	plaintext := []byte("committed message")
+	aad := []byte("aad")
+
+	a1, err := NewCommittingAEAD(key1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a2, err := NewCommittingAEAD(key2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ct := a1.Seal(nil, nonce, plaintext, aad)
+	if len(ct) != len(plaintext)+a1.Overhead() {
+		t.Errorf("ciphertext is %d bytes", len(ct))
+	}
+	pt, err := a1.Open(nil, nonce, ct, aad)
+	if err != nil || !bytes.Equal(pt, plaintext) {
+		t.Fatalf("round trip failed: %v", err)
+	}
+	if pt, err := a2.Open(nil, nonce, ct, aad); err == nil || pt != nil {
+		t.Error("ciphertext opened under a different key")
+	}
+
+	// Colliding setup: the GCM part is valid under key2's encryption key but
+	// carries key1's commitment. key2 must reject it on the commitment even
+	// though its GCM tag verifies, and key1 on the GCM tag.
+	inner2 := a2.Seal(nil, nonce, []byte("other plaintext"), aad)[commitmentSize:]
+	forged := append(sm3.KDF(commitmentSize, commitTagLabel, key1, nonce), inner2...)
+	if _, err := a1.Open(nil, nonce, forged, aad); err == nil {
+		t.Error("ciphertext for another key accepted under key1's commitment")
+	}
+	if _, err := a2.Open(nil, nonce, forged, aad); err == nil {
+		t.Error("ciphertext accepted with a commitment to another key")
+	}
+
+	ct[0] ^= 1
+	if _, err := a1.Open(nil, nonce, ct, aad); err == nil {
+		t.Error("altered commitment accepted")
+	}
+	if _, err := a1.Open(nil, nonce, ct[:10], aad); err == nil {
+		t.Error("short ciphertext accepted")
+	}
+	if _, err := NewCommittingAEAD(key1[:8]); err == nil {
+		t.Error("short key accepted")
+	}
+}
@@ -0,0 +1,154 @@
+package sm4
+
+import (
+	"crypto/cipher"
+	"crypto/subtle"
+	"encoding/binary"
+	"errors"
+	"io"
+)
+
+// CTRSeek returns an SM4-CTR stream positioned as if offset bytes had
+// already been processed with cipher.NewCTR(block, iv). The counter is
+// advanced by offset/BlockSize blocks directly, so seeking costs at most
+// one block of keystream regardless of offset.
+func CTRSeek(key, iv []byte, offset int64) (cipher.Stream, error) {
+	if len(iv) != BlockSize {
+		return nil, errors.New("SM4: invalid iv size")
+	}
+	if offset < 0 {
+		return nil, errors.New("SM4: negative CTR offset")
+	}
+	block, err := NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return ctrAt(block, iv, offset), nil
+}
+
+// ctrAt returns the CTR stream of block and iv positioned at offset.
+func ctrAt(block cipher.Block, iv []byte, offset int64) cipher.Stream {
+	// The counter is the whole IV taken as a 128-bit big-endian integer,
+	// matching the increment used by cipher.NewCTR.
+	var ctr [BlockSize]byte
+	hi := binary.BigEndian.Uint64(iv[:8])
+	lo := binary.BigEndian.Uint64(iv[8:])
+	blocks := uint64(offset / BlockSize)
+	if lo+blocks < lo {
+		hi++
+	}
+	lo += blocks
+	binary.BigEndian.PutUint64(ctr[:8], hi)
+	binary.BigEndian.PutUint64(ctr[8:], lo)
+
+	stream := cipher.NewCTR(block, ctr[:])
+	if skip := offset % BlockSize; skip > 0 {
+		var discard [BlockSize]byte
+		stream.XORKeyStream(discard[:skip], discard[:skip])
+	}
+	return stream
+}
+
+// SeekableCTR is an SM4-CTR stream, compatible with cipher.NewCTR, that can
+// be repositioned to any byte offset, for random access into large
+// encrypted files. It implements cipher.Stream and io.Seeker.
+type SeekableCTR struct {
+	block  cipher.Block
+	iv     [BlockSize]byte
+	stream cipher.Stream
+	offset int64
+}
+
+// NewSeekableCTR returns a SeekableCTR for key and iv, positioned at offset
+// 0.
+func NewSeekableCTR(key, iv []byte) (*SeekableCTR, error) {
+	if len(iv) != BlockSize {
+		return nil, errors.New("SM4: invalid iv size")
+	}
+	block, err := NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	c := &SeekableCTR{block: block}
+	copy(c.iv[:], iv)
+	c.stream = ctrAt(block, c.iv[:], 0)
+	return c, nil
+}
+
+// Seek implements io.Seeker, positioning the keystream at offset bytes
+// relative to the start (io.SeekStart) or the current position
+// (io.SeekCurrent). The stream has no end, so io.SeekEnd is rejected. Like
+// CTRSeek it computes the counter block directly and costs at most one
+// block of keystream.
+func (c *SeekableCTR) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += c.offset
+	default:
+		return c.offset, errors.New("SM4: invalid CTR seek whence")
+	}
+	if offset < 0 {
+		return c.offset, errors.New("SM4: negative CTR offset")
+	}
+	c.stream = ctrAt(c.block, c.iv[:], offset)
+	c.offset = offset
+	return offset, nil
+}
+
+// Offset returns the current position in the keystream.
+func (c *SeekableCTR) Offset() int64 {
+	return c.offset
+}
+
+// XORKeyStream XORs src with the keystream at the current offset into dst
+// and advances the offset by len(src).
+func (c *SeekableCTR) XORKeyStream(dst, src []byte) {
+	c.stream.XORKeyStream(dst, src)
+	c.offset += int64(len(src))
+}
+
+// ctr32 is CTR mode with a counter block of a fixed 96-bit nonce followed
+// by a 32-bit big-endian counter, as in GCM.
+type ctr32 struct {
+	block cipher.Block
+	ctr   [BlockSize]byte
+	ks    [BlockSize]byte
+	used  int
+}
+
+// NewCTRWithNonce returns an SM4-CTR stream whose counter block is the 12
+// byte nonce followed by initialCounter as a 32-bit big-endian integer.
+// Only those low 32 bits are incremented: after 2^32-1 the counter wraps to
+// 0 without carrying into the nonce, so the keystream repeats after 2^36
+// bytes. Callers must keep messages well below that length. This differs
+// from cipher.NewCTR, which increments the whole 128-bit block.
+func NewCTRWithNonce(key, nonce []byte, initialCounter uint32) (cipher.Stream, error) {
+	if len(nonce) != 12 {
+		return nil, errors.New("SM4: invalid CTR nonce size")
+	}
+	block, err := NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	c := &ctr32{block: block, used: BlockSize}
+	copy(c.ctr[:], nonce)
+	binary.BigEndian.PutUint32(c.ctr[12:], initialCounter)
+	return c, nil
+}
+
+func (c *ctr32) XORKeyStream(dst, src []byte) {
+	if len(dst) < len(src) {
+		panic("SM4: output smaller than input")
+	}
+	for len(src) > 0 {
+		if c.used == BlockSize {
+			c.block.Encrypt(c.ks[:], c.ctr[:])
+			binary.BigEndian.PutUint32(c.ctr[12:], binary.BigEndian.Uint32(c.ctr[12:])+1)
+			c.used = 0
+		}
+		n := subtle.XORBytes(dst, src, c.ks[c.used:])
+		c.used += n
+		dst, src = dst[n:], src[n:]
+	}
+}
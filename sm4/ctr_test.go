@@ -0,0 +1,159 @@
+package sm4
+
+import (
+	"bytes"
+	"crypto/cipher"
+	"io"
+	"math/rand"
+	"testing"
+)
+
+func TestCTRSeek(t *testing.T) {
+	key := []byte("1234567890abcdef")
+	plaintext := make([]byte, 1000)
+	for i := range plaintext {
+		plaintext[i] = byte(i)
+	}
+	ivs := [][]byte{
+		make([]byte, BlockSize),
+		// Low 64 bits about to wrap, so seeking must carry into the high half.
+		{0, 0, 0, 0, 0, 0, 0, 1, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xfe},
+	}
+	for _, iv := range ivs {
+		block, err := NewCipher(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		full := make([]byte, len(plaintext))
+		cipher.NewCTR(block, iv).XORKeyStream(full, plaintext)
+
+		for _, r := range [][2]int{{0, 10}, {16, 48}, {37, 500}, {999, 1000}, {500, 1000}} {
+			stream, err := CTRSeek(key, iv, int64(r[0]))
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := make([]byte, r[1]-r[0])
+			stream.XORKeyStream(got, full[r[0]:r[1]])
+			if !bytes.Equal(got, plaintext[r[0]:r[1]]) {
+				t.Errorf("iv %x: range [%d, %d) decrypts incorrectly", iv, r[0], r[1])
+			}
+		}
+	}
+
+	if _, err := CTRSeek(key, make([]byte, 8), 0); err == nil {
+		t.Error("short iv accepted")
+	}
+	if _, err := CTRSeek(key, make([]byte, BlockSize), -1); err == nil {
+		t.Error("negative offset accepted")
+	}
+}
+
+func TestSeekableCTR(t *testing.T) {
+	key := []byte("1234567890abcdef")
+	iv := []byte{0, 0, 0, 0, 0, 0, 0, 0, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xf0}
+	plaintext := make([]byte, 4096)
+	for i := range plaintext {
+		plaintext[i] = byte(i * 31)
+	}
+	block, err := NewCipher(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	full := make([]byte, len(plaintext))
+	cipher.NewCTR(block, iv).XORKeyStream(full, plaintext)
+
+	c, err := NewSeekableCTR(key, iv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var _ cipher.Stream = c
+	var _ io.Seeker = c
+	for i := 0; i < 20; i++ {
+		start := rand.Intn(len(full))
+		end := start + rand.Intn(len(full)-start+1)
+		if _, err := c.Seek(int64(start), io.SeekStart); err != nil {
+			t.Fatal(err)
+		}
+		got := make([]byte, end-start)
+		// Two calls, so the offset must carry over between them.
+		half := len(got) / 2
+		c.XORKeyStream(got[:half], full[start:start+half])
+		c.XORKeyStream(got[half:], full[start+half:end])
+		if !bytes.Equal(got, plaintext[start:end]) {
+			t.Errorf("range [%d, %d) decrypts incorrectly", start, end)
+		}
+		if c.Offset() != int64(end) {
+			t.Errorf("offset is %d after reading to %d", c.Offset(), end)
+		}
+	}
+
+	c.Seek(1000, io.SeekStart)
+	if pos, err := c.Seek(-100, io.SeekCurrent); err != nil || pos != 900 {
+		t.Errorf("relative seek: got %d, %v", pos, err)
+	}
+	got := make([]byte, 100)
+	c.XORKeyStream(got, full[900:1000])
+	if !bytes.Equal(got, plaintext[900:1000]) {
+		t.Error("relative seek decrypts incorrectly")
+	}
+	if _, err := c.Seek(-1, io.SeekStart); err == nil {
+		t.Error("negative offset accepted")
+	}
+	if _, err := c.Seek(0, io.SeekEnd); err == nil {
+		t.Error("io.SeekEnd accepted")
+	}
+	if _, err := NewSeekableCTR(key, iv[:8]); err == nil {
+		t.Error("short iv accepted")
+	}
+}
+
+func TestNewCTRWithNonce(t *testing.T) {
+	key := []byte("1234567890abcdef")
+	nonce := []byte("twelve bytes")
+	block, err := NewCipher(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	plaintext := make([]byte, 1000)
+	rand.New(rand.NewSource(1)).Read(plaintext)
+
+	// Without a wrap the stream matches cipher.NewCTR on nonce || counter.
+	iv := append(append([]byte(nil), nonce...), 0, 0, 0, 7)
+	want := make([]byte, len(plaintext))
+	cipher.NewCTR(block, iv).XORKeyStream(want, plaintext)
+	stream, err := NewCTRWithNonce(key, nonce, 7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := make([]byte, len(plaintext))
+	for off, n := 0, 1; off < len(got); off, n = off+n, n+3 {
+		end := off + n
+		if end > len(got) {
+			end = len(got)
+		}
+		stream.XORKeyStream(got[off:end], plaintext[off:end])
+	}
+	if !bytes.Equal(got, want) {
+		t.Error("stream differs from cipher.NewCTR")
+	}
+
+	// The counter wraps from 2^32-1 to 0 without touching the nonce.
+	stream, err = NewCTRWithNonce(key, nonce, 0xffffffff)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ks := make([]byte, 2*BlockSize)
+	stream.XORKeyStream(ks, ks)
+	var wantKS [2 * BlockSize]byte
+	block.Encrypt(wantKS[:BlockSize], append(append([]byte(nil), nonce...), 0xff, 0xff, 0xff, 0xff))
+	block.Encrypt(wantKS[BlockSize:], append(append([]byte(nil), nonce...), 0, 0, 0, 0))
+	if !bytes.Equal(ks, wantKS[:]) {
+		t.Error("counter does not wrap within the low 32 bits")
+	}
+
+	for _, n := range []int{0, 8, 16} {
+		if _, err := NewCTRWithNonce(key, make([]byte, n), 0); err == nil {
+			t.Errorf("%d byte nonce accepted", n)
+		}
+	}
+}
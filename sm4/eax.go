@@ -0,0 +1,117 @@
+package sm4
+
+import (
+	"crypto/cipher"
+	"crypto/subtle"
+	"errors"
+	"strconv"
+)
+
+var errEAXOpen = errors.New("SM4: EAX message authentication failed")
+
+// eaxTagSize is the length of the EAX tag, the full block size.
+const eaxTagSize = BlockSize
+
+// eax implements EAX (Bellare, Rogaway and Wagner): the plaintext is
+// encrypted in CTR mode starting at OMAC0(nonce), and the tag is
+// OMAC0(nonce) ^ OMAC1(aad) ^ OMAC2(ciphertext).
+type eax struct {
+	block     cipher.Block
+	mac       *cmacState
+	nonceSize int
+}
+
+// NewEAX returns SM4 in EAX mode with a 16 byte tag for nonces of
+// nonceSize bytes. EAX accepts nonces of any positive length, but
+// cipher.AEAD fixes it per instance; use EncryptEAX and DecryptEAX for
+// nonces of varying length.
+func NewEAX(key []byte, nonceSize int) (cipher.AEAD, error) {
+	block, err := NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return newEAXWithBlock(block, nonceSize)
+}
+
+func newEAXWithBlock(block cipher.Block, nonceSize int) (cipher.AEAD, error) {
+	if block.BlockSize() != BlockSize {
+		return nil, errors.New("SM4: EAX requires a 128-bit block cipher")
+	}
+	if nonceSize <= 0 {
+		return nil, errors.New("SM4: invalid EAX nonce size " + strconv.Itoa(nonceSize))
+	}
+	return &eax{block: block, mac: newCMAC(block), nonceSize: nonceSize}, nil
+}
+
+func newEAXFromKey(key []byte) (cipher.AEAD, error) {
+	return NewEAX(key, 16)
+}
+
+// EncryptEAX seals plaintext with SM4-EAX and returns ciphertext || tag.
+// nonce may have any non-zero length.
+func EncryptEAX(key, nonce, plaintext, aad []byte) ([]byte, error) {
+	aead, err := NewEAX(key, len(nonce))
+	if err != nil {
+		return nil, err
+	}
+	return aead.Seal(nil, nonce, plaintext, aad), nil
+}
+
+// DecryptEAX verifies and opens ciphertext || tag produced by EncryptEAX.
+// No plaintext is returned unless the tag is valid.
+func DecryptEAX(key, nonce, ciphertext, aad []byte) ([]byte, error) {
+	aead, err := NewEAX(key, len(nonce))
+	if err != nil {
+		return nil, err
+	}
+	return aead.Open(nil, nonce, ciphertext, aad)
+}
+
+func (e *eax) NonceSize() int { return e.nonceSize }
+
+func (e *eax) Overhead() int { return eaxTagSize }
+
+func (e *eax) Seal(dst, nonce, plaintext, aad []byte) []byte {
+	if len(nonce) != e.nonceSize {
+		panic("SM4: incorrect nonce length given to EAX")
+	}
+	ret, out := sliceForAppend(dst, len(plaintext)+eaxTagSize)
+	n := e.omac(0, nonce)
+	cipher.NewCTR(e.block, n).XORKeyStream(out[:len(plaintext)], plaintext)
+	e.tag(out[len(plaintext):], n, aad, out[:len(plaintext)])
+	return ret
+}
+
+func (e *eax) Open(dst, nonce, ciphertext, aad []byte) ([]byte, error) {
+	if len(nonce) != e.nonceSize {
+		panic("SM4: incorrect nonce length given to EAX")
+	}
+	if len(ciphertext) < eaxTagSize {
+		return nil, errEAXOpen
+	}
+	m := len(ciphertext) - eaxTagSize
+	n := e.omac(0, nonce)
+	var tag [eaxTagSize]byte
+	e.tag(tag[:], n, aad, ciphertext[:m])
+	if subtle.ConstantTimeCompare(tag[:], ciphertext[m:]) != 1 {
+		return nil, errEAXOpen
+	}
+	ret, out := sliceForAppend(dst, m)
+	cipher.NewCTR(e.block, n).XORKeyStream(out, ciphertext[:m])
+	return ret, nil
+}
+
+// tag writes n ^ OMAC1(aad) ^ OMAC2(ciphertext) to dst.
+func (e *eax) tag(dst, n, aad, ciphertext []byte) {
+	subtle.XORBytes(dst, n, e.omac(1, aad))
+	subtle.XORBytes(dst, dst, e.omac(2, ciphertext))
+}
+
+// omac returns OMAC^t(data), the CMAC of the block encoding t followed by
+// data. data is absorbed in place, so Seal and Open do not copy the
+// additional data or the ciphertext.
+func (e *eax) omac(t byte, data []byte) []byte {
+	var prefix [BlockSize]byte
+	prefix[BlockSize-1] = t
+	return e.mac.sumPrefixed(&prefix, data)
+}
@@ -0,0 +1,83 @@
+package sm4
+
+import (
+	"bytes"
+	"crypto/aes"
+	"encoding/hex"
+	"testing"
+)
+
+// TestEAXVectors checks the mode itself against AES examples from the EAX
+// paper, since SM4 has no published EAX vectors.
+func TestEAXVectors(t *testing.T) {
+	for i, tc := range []struct {
+		key, nonce, aad, plaintext, ciphertext string
+	}{
+		{"233952dee4d5ed5f9b9c6d6ff80ff478", "62ec67f9c3a4a407fcb2a8c49031a8b3", "6bfb914fd07eae6b",
+			"", "e037830e8389f27b025a2d6527e79d01"},
+		{"91945d3f4dcbee0bf45ef52255f095a4", "becaf043b0a23d843194ba972c66debd", "fa3bfd4806eb53fa",
+			"f7fb", "19dd5c4c9331049d0bdab0277408f67967e5"},
+	} {
+		block, _ := aes.NewCipher(fromHex(tc.key))
+		aead, err := newEAXWithBlock(block, len(tc.nonce)/2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ct := aead.Seal(nil, fromHex(tc.nonce), fromHex(tc.plaintext), fromHex(tc.aad))
+		if got := hex.EncodeToString(ct); got != tc.ciphertext {
+			t.Errorf("example %d: got %s, want %s", i+1, got, tc.ciphertext)
+		}
+		pt, err := aead.Open(nil, fromHex(tc.nonce), ct, fromHex(tc.aad))
+		if err != nil || !bytes.Equal(pt, fromHex(tc.plaintext)) {
+			t.Errorf("example %d: open failed: %v", i+1, err)
+		}
+	}
+}
+
+func TestEAXRoundTrip(t *testing.T) {
+	key := []byte("1234567890abcdef")
+	plaintext := bytes.Repeat([]byte("eax payload "), 20)
+	aad := []byte("header")
+	for _, nonceSize := range []int{1, 12, 16, 40} {
+		nonce := bytes.Repeat([]byte{0x42}, nonceSize)
+		for _, msg := range [][]byte{nil, plaintext[:1], plaintext[:16], plaintext} {
+			ct, err := EncryptEAX(key, nonce, msg, aad)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(ct) != len(msg)+16 {
+				t.Fatalf("n=%d: ciphertext length %d", nonceSize, len(ct))
+			}
+			pt, err := DecryptEAX(key, nonce, ct, aad)
+			if err != nil || !bytes.Equal(pt, msg) {
+				t.Fatalf("n=%d: round trip failed: %v", nonceSize, err)
+			}
+		}
+	}
+}
+
+func TestEAXTampered(t *testing.T) {
+	key := []byte("1234567890abcdef")
+	nonce := []byte("unique nonce")
+	aad := []byte("header")
+	ct, err := EncryptEAX(key, nonce, []byte("attack at dawn"), aad)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range ct {
+		bad := append([]byte(nil), ct...)
+		bad[i] ^= 1
+		if pt, err := DecryptEAX(key, nonce, bad, aad); err == nil || pt != nil {
+			t.Fatalf("byte %d: tampered ciphertext accepted", i)
+		}
+	}
+	if _, err := DecryptEAX(key, nonce, ct, []byte("other")); err == nil {
+		t.Error("different aad accepted")
+	}
+	if _, err := DecryptEAX(key, nonce, ct[:15], aad); err == nil {
+		t.Error("ciphertext shorter than the tag accepted")
+	}
+	if _, err := EncryptEAX(key, nil, nil, nil); err == nil {
+		t.Error("empty nonce accepted")
+	}
+}
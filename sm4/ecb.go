@@ -0,0 +1,28 @@
+package sm4
+
+import "errors"
+
+// AllowECB controls whether the package encrypts in ECB mode. ECB encrypts
+// equal plaintext blocks to equal ciphertext blocks, so it leaks the
+// structure of the data (repeated fields, padding, images) and is unsafe
+// for anything but single random blocks. It is true for compatibility;
+// setting it to false makes every ECB encryption in this package return
+// ErrECBDisabled, so a program can enforce "no ECB" in all of its
+// dependencies at once.
+//
+// This covers EncryptWithKey, EncryptWithKeyPooled, Encryptor.Encrypt,
+// EncryptChannel, Sm4Ecb and Sm4EcbInto. Decryption is still allowed so
+// existing ECB data can be read and migrated. Like IV, set it once at
+// program start, before any goroutine uses the package.
+var AllowECB = true
+
+// ErrECBDisabled is returned for ECB encryption when AllowECB is false.
+var ErrECBDisabled = errors.New("SM4: ECB encryption is disabled by AllowECB")
+
+// checkECB returns ErrECBDisabled when encrypting with mode is not allowed.
+func checkECB(mode CipherMode) error {
+	if mode == ECB && !AllowECB {
+		return ErrECBDisabled
+	}
+	return nil
+}
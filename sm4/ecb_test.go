@@ -0,0 +1,51 @@
+package sm4
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestAllowECB(t *testing.T) {
+	key := []byte("1234567890abcdef")
+	data := []byte("structured data")
+	ct, err := EncryptWithKey(key, data, ECB)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	AllowECB = false
+	defer func() { AllowECB = true }()
+
+	if _, err := EncryptWithKey(key, data, ECB); err != ErrECBDisabled {
+		t.Errorf("EncryptWithKey: got %v", err)
+	}
+	if _, err := EncryptWithKeyPooled(key, data, ECB); err != ErrECBDisabled {
+		t.Errorf("EncryptWithKeyPooled: got %v", err)
+	}
+	e, err := NewEncryptor(key, ECB)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := e.Encrypt(data); err != ErrECBDisabled {
+		t.Errorf("Encryptor.Encrypt: got %v", err)
+	}
+	if err := Sm4EcbInto(make([]byte, 16), make([]byte, 16), key); err != ErrECBDisabled {
+		t.Errorf("Sm4EcbInto: got %v", err)
+	}
+	in := make(chan []byte)
+	close(in)
+	if err := EncryptChannel(in, make(chan []byte, 1), key, nil, ECB); err != ErrECBDisabled {
+		t.Errorf("EncryptChannel: got %v", err)
+	}
+
+	// Existing ECB data can still be decrypted, and other modes still work.
+	if pt, err := DecryptWithKey(key, ct, ECB); err != nil || !bytes.Equal(pt, data) {
+		t.Errorf("DecryptWithKey: %v", err)
+	}
+	if pt, err := e.Decrypt(ct); err != nil || !bytes.Equal(pt, data) {
+		t.Errorf("Encryptor.Decrypt: %v", err)
+	}
+	if _, err := EncryptWithKey(key, data, CBC); err != nil {
+		t.Errorf("CBC: %v", err)
+	}
+}
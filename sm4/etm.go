@@ -0,0 +1,64 @@
+package sm4
+
+import (
+	"crypto/subtle"
+	"encoding/binary"
+	"errors"
+
+	"github.com/tjfoc/gmsm/sm3"
+)
+
+var errETMOpen = errors.New("SM4: encrypt-then-MAC authentication failed")
+
+// EncryptThenMAC encrypts plaintext like EncryptWithKeyIV with encKey, iv
+// and mode (CBC, CFB or OFB), and appends the 32 byte HMAC-SM3 under macKey
+// of
+//
+//	len(aad) || aad || iv || ciphertext
+//
+// where len(aad) is 8 bytes big-endian, so that no bytes can be moved
+// between aad and ciphertext. The result is ciphertext || tag; iv and aad
+// are not included. encKey and macKey must be independent keys.
+func EncryptThenMAC(encKey, macKey, iv, plaintext, aad []byte, mode CipherMode) ([]byte, error) {
+	if len(macKey) == 0 {
+		return nil, errors.New("SM4: empty MAC key")
+	}
+	e, err := newEncryptorIV(encKey, iv, mode)
+	if err != nil {
+		return nil, err
+	}
+	ct := e.encrypt(plaintext, iv)
+	return append(ct, etmTag(macKey, iv, ct, aad)...), nil
+}
+
+// VerifyThenDecrypt reverses EncryptThenMAC for data = ciphertext || tag.
+// The tag is checked in constant time and nothing is decrypted unless it
+// is valid.
+func VerifyThenDecrypt(encKey, macKey, iv, data, aad []byte, mode CipherMode) ([]byte, error) {
+	if len(macKey) == 0 {
+		return nil, errors.New("SM4: empty MAC key")
+	}
+	e, err := newEncryptorIV(encKey, iv, mode)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < sm3.Size {
+		return nil, errETMOpen
+	}
+	ct, tag := data[:len(data)-sm3.Size], data[len(data)-sm3.Size:]
+	if subtle.ConstantTimeCompare(etmTag(macKey, iv, ct, aad), tag) != 1 {
+		return nil, errETMOpen
+	}
+	return e.decrypt(ct, iv)
+}
+
+func etmTag(macKey, iv, ct, aad []byte) []byte {
+	h := sm3.NewHMAC(macKey)
+	var l [8]byte
+	binary.BigEndian.PutUint64(l[:], uint64(len(aad)))
+	h.Write(l[:])
+	h.Write(aad)
+	h.Write(iv)
+	h.Write(ct)
+	return h.Sum(nil)
+}
@@ -0,0 +1,59 @@
+package sm4
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestEncryptThenMAC(t *testing.T) {
+	encKey := []byte("1234567890abcdef")
+	macKey := []byte("an independent mac key")
+	iv := []byte("0123456789abcdef")
+	pt := []byte("authenticated but not GCM")
+	aad := []byte("header")
+	for _, mode := range []CipherMode{CBC, CFB, OFB} {
+		data, err := EncryptThenMAC(encKey, macKey, iv, pt, aad, mode)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want, _ := EncryptWithKeyIV(encKey, iv, pt, mode)
+		if !bytes.Equal(data[:len(data)-32], want) {
+			t.Errorf("mode %d: ciphertext differs from EncryptWithKeyIV", mode)
+		}
+		got, err := VerifyThenDecrypt(encKey, macKey, iv, data, aad, mode)
+		if err != nil || !bytes.Equal(got, pt) {
+			t.Errorf("mode %d: round trip failed: %v", mode, err)
+		}
+
+		for i := range data {
+			bad := append([]byte(nil), data...)
+			bad[i] ^= 1
+			if _, err := VerifyThenDecrypt(encKey, macKey, iv, bad, aad, mode); err != errETMOpen {
+				t.Fatalf("mode %d: flipped byte %d: got %v", mode, i, err)
+			}
+		}
+		badIV := append([]byte(nil), iv...)
+		badIV[0] ^= 1
+		for _, c := range []struct {
+			name            string
+			macKey, iv, aad []byte
+			data            []byte
+		}{
+			{"wrong mac key", []byte("another key"), iv, aad, data},
+			{"wrong iv", macKey, badIV, aad, data},
+			{"wrong aad", macKey, iv, []byte("headex"), data},
+			{"aad moved into ciphertext", macKey, iv, aad[:5], append([]byte("r"), data...)},
+			{"truncated", macKey, iv, aad, data[:31]},
+		} {
+			if _, err := VerifyThenDecrypt(encKey, c.macKey, c.iv, c.data, c.aad, mode); err != errETMOpen {
+				t.Errorf("mode %d, %s: got %v", mode, c.name, err)
+			}
+		}
+	}
+	if _, err := EncryptThenMAC(encKey, macKey, iv, pt, aad, ECB); err == nil {
+		t.Error("ECB accepted")
+	}
+	if _, err := EncryptThenMAC(encKey, nil, iv, pt, aad, CBC); err == nil {
+		t.Error("empty MAC key accepted")
+	}
+}
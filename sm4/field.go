@@ -0,0 +1,66 @@
+package sm4
+
+import (
+	"crypto/rand"
+	"errors"
+	"io"
+	"strconv"
+
+	"github.com/tjfoc/gmsm/sm3"
+)
+
+var fieldKeyLabel = []byte("SM4 field key")
+
+// FieldCipher encrypts individual fields of a record, each under its own
+// subkey derived from a single master key:
+//
+//	subkey = KDF(16, "SM4 field key", master, name)
+//
+// where KDF is sm3.KDF. Fields are sealed with SM4-GCM under a random
+// nonce, with the field name as additional data, and encoded as
+// nonce || ciphertext || tag. A ciphertext therefore only decrypts under
+// the field name it was produced for.
+type FieldCipher struct {
+	master []byte
+}
+
+// NewFieldCipher returns a FieldCipher for the 16 byte master key. The key
+// is copied.
+func NewFieldCipher(master []byte) (*FieldCipher, error) {
+	if len(master) != BlockSize {
+		return nil, errors.New("SM4: invalid key size " + strconv.Itoa(len(master)))
+	}
+	return &FieldCipher{master: append([]byte(nil), master...)}, nil
+}
+
+// FieldKey returns the subkey for the named field.
+func (f *FieldCipher) FieldKey(name string) []byte {
+	return sm3.KDF(BlockSize, fieldKeyLabel, f.master, []byte(name))
+}
+
+// EncryptField encrypts plaintext for the named field.
+func (f *FieldCipher) EncryptField(name string, plaintext []byte) ([]byte, error) {
+	aead, err := newGCMFromKey(f.FieldKey(name))
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(plaintext)+aead.Overhead())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, plaintext, []byte(name)), nil
+}
+
+// DecryptField decrypts a value produced by EncryptField for the same
+// field name.
+func (f *FieldCipher) DecryptField(name string, ciphertext []byte) ([]byte, error) {
+	aead, err := newGCMFromKey(f.FieldKey(name))
+	if err != nil {
+		return nil, err
+	}
+	if len(ciphertext) < aead.NonceSize()+aead.Overhead() {
+		return nil, errors.New("SM4: field ciphertext too short")
+	}
+	n := aead.NonceSize()
+	return aead.Open(nil, ciphertext[:n], ciphertext[n:], []byte(name))
+}
@@ -0,0 +1,48 @@
+package sm4
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestFieldCipher(t *testing.T) {
+	fc, err := NewFieldCipher([]byte("0123456789abcdef"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	plaintext := []byte("same value")
+	email, err := fc.EncryptField("email", plaintext)
+	if err != nil {
+		t.Fatal(err)
+	}
+	phone, err := fc.EncryptField("phone", plaintext)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Equal(email, phone) {
+		t.Error("two fields encrypt to the same ciphertext")
+	}
+	if bytes.Equal(fc.FieldKey("email"), fc.FieldKey("phone")) {
+		t.Error("two fields share a subkey")
+	}
+
+	for name, ct := range map[string][]byte{"email": email, "phone": phone} {
+		pt, err := fc.DecryptField(name, ct)
+		if err != nil || !bytes.Equal(pt, plaintext) {
+			t.Errorf("%s: got %q, %v", name, pt, err)
+		}
+	}
+	if _, err := fc.DecryptField("phone", email); err == nil {
+		t.Error("ciphertext decrypted under another field name")
+	}
+	other, _ := NewFieldCipher([]byte("fedcba9876543210"))
+	if _, err := other.DecryptField("email", email); err == nil {
+		t.Error("ciphertext decrypted under another master key")
+	}
+	if _, err := fc.DecryptField("email", email[:20]); err == nil {
+		t.Error("truncated ciphertext accepted")
+	}
+	if _, err := NewFieldCipher([]byte("short")); err == nil {
+		t.Error("short master key accepted")
+	}
+}
@@ -0,0 +1,45 @@
+package sm4
+
+import "testing"
+
+// FuzzSM4Decrypt checks that the padded decryption functions reject
+// malformed ciphertexts and keys with an error instead of panicking.
+func FuzzSM4Decrypt(f *testing.F) {
+	key := []byte("1234567890abcdef")
+	for _, mode := range []CipherMode{ECB, CBC, CFB, OFB} {
+		ct, err := EncryptWithKey(key, []byte("fuzz seed"), mode)
+		if err != nil {
+			f.Fatal(err)
+		}
+		f.Add(key, ct)
+		f.Add(key, ct[:len(ct)-1])
+	}
+	f.Add(key, []byte{})
+	f.Add(key[:15], make([]byte, 16))
+
+	f.Fuzz(func(t *testing.T, key, data []byte) {
+		for _, mode := range []CipherMode{ECB, CBC, CFB, OFB, CTR} {
+			if pt, err := DecryptWithKey(key, data, mode); err != nil && pt != nil {
+				t.Errorf("mode %d: plaintext returned with error %v", mode, err)
+			}
+			if pt, err := DecryptWithKeyLegacy(key, data, mode); err != nil && pt != nil {
+				t.Errorf("legacy mode %d: plaintext returned with error %v", mode, err)
+			}
+		}
+	})
+}
+
+func TestDecryptUnaligned(t *testing.T) {
+	key := []byte("1234567890abcdef")
+	for _, mode := range []CipherMode{ECB, CBC, CFB, OFB} {
+		for _, n := range []int{0, 1, 15, 17, 31} {
+			if pt, err := DecryptWithKey(key, make([]byte, n), mode); err == nil || pt != nil {
+				t.Errorf("mode %d: %d byte ciphertext accepted", mode, n)
+			}
+		}
+	}
+	// A block-aligned ciphertext with invalid padding is an error too.
+	if _, err := Sm4Cbc(key, make([]byte, 32), false); err == nil {
+		t.Error("invalid padding accepted")
+	}
+}
@@ -0,0 +1,247 @@
+package sm4
+
+import (
+	"crypto/cipher"
+	"crypto/subtle"
+	"encoding/binary"
+	"errors"
+)
+
+var errGCMOpen = errors.New("SM4: GCM message authentication failed")
+
+const (
+	gcmStandardNonceSize = 12
+	gcmTagSize           = 16
+	gcmMinimumTagSize    = 12
+)
+
+// gcmFieldElement is an element of GF(2^128) in the bit-reflected GCM
+// representation: low holds the first 8 bytes of the block, high the last.
+type gcmFieldElement struct {
+	low, high uint64
+}
+
+// gcm implements GCM (NIST SP 800-38D) over SM4. The multiples of H by
+// every 4-bit value are computed once in newGCM, so GHASH processes each
+// block with 32 table lookups instead of a bit-by-bit multiplication.
+type gcm struct {
+	block        *Sm4Cipher
+	nonceSize    int
+	tagSize      int
+	productTable [16]gcmFieldElement
+}
+
+// NewGCM is called by cipher.NewGCM and its variants, so that they use the
+// table-driven implementation for SM4 blocks as well.
+func (c *Sm4Cipher) NewGCM(nonceSize, tagSize int) (cipher.AEAD, error) {
+	return newGCM(c, nonceSize, tagSize)
+}
+
+func newGCM(block *Sm4Cipher, nonceSize, tagSize int) (cipher.AEAD, error) {
+	if nonceSize <= 0 {
+		return nil, errors.New("SM4: invalid GCM nonce size")
+	}
+	if tagSize < gcmMinimumTagSize || tagSize > gcmTagSize {
+		return nil, errors.New("SM4: invalid GCM tag size")
+	}
+	var key [BlockSize]byte
+	block.Encrypt(key[:], key[:])
+	g := &gcm{block: block, nonceSize: nonceSize, tagSize: tagSize}
+
+	// productTable[reverseBits(i)] = i * H, built by doubling and adding.
+	x := gcmFieldElement{
+		binary.BigEndian.Uint64(key[:8]),
+		binary.BigEndian.Uint64(key[8:]),
+	}
+	g.productTable[reverseBits(1)] = x
+	for i := 2; i < 16; i += 2 {
+		g.productTable[reverseBits(i)] = gcmDouble(&g.productTable[reverseBits(i/2)])
+		g.productTable[reverseBits(i+1)] = gcmAdd(&g.productTable[reverseBits(i)], &x)
+	}
+	return g, nil
+}
+
+func (g *gcm) NonceSize() int { return g.nonceSize }
+
+func (g *gcm) Overhead() int { return g.tagSize }
+
+func (g *gcm) Seal(dst, nonce, plaintext, aad []byte) []byte {
+	if len(nonce) != g.nonceSize {
+		panic("SM4: incorrect nonce length given to GCM")
+	}
+	if uint64(len(plaintext)) > (1<<32-2)*BlockSize {
+		panic("SM4: message too large for GCM")
+	}
+	ret, out := sliceForAppend(dst, len(plaintext)+g.tagSize)
+
+	var counter, tagMask [BlockSize]byte
+	g.deriveCounter(&counter, nonce)
+	g.block.Encrypt(tagMask[:], counter[:])
+	gcmInc32(&counter)
+
+	g.counterCrypt(out, plaintext, &counter)
+	var tag [gcmTagSize]byte
+	g.auth(tag[:], out[:len(plaintext)], aad, &tagMask)
+	copy(out[len(plaintext):], tag[:])
+	return ret
+}
+
+func (g *gcm) Open(dst, nonce, ciphertext, aad []byte) ([]byte, error) {
+	if len(nonce) != g.nonceSize {
+		panic("SM4: incorrect nonce length given to GCM")
+	}
+	if len(ciphertext) < g.tagSize ||
+		uint64(len(ciphertext)) > (1<<32-2)*BlockSize+uint64(g.tagSize) {
+		return nil, errGCMOpen
+	}
+	tag := ciphertext[len(ciphertext)-g.tagSize:]
+	ciphertext = ciphertext[:len(ciphertext)-g.tagSize]
+
+	var counter, tagMask [BlockSize]byte
+	g.deriveCounter(&counter, nonce)
+	g.block.Encrypt(tagMask[:], counter[:])
+	gcmInc32(&counter)
+
+	var expected [gcmTagSize]byte
+	g.auth(expected[:], ciphertext, aad, &tagMask)
+	if subtle.ConstantTimeCompare(expected[:g.tagSize], tag) != 1 {
+		return nil, errGCMOpen
+	}
+	ret, out := sliceForAppend(dst, len(ciphertext))
+	g.counterCrypt(out, ciphertext, &counter)
+	return ret, nil
+}
+
+// reverseBits reverses the order of the bits of the 4-bit number i.
+func reverseBits(i int) int {
+	i = ((i << 2) & 0xc) | ((i >> 2) & 0x3)
+	i = ((i << 1) & 0xa) | ((i >> 1) & 0x5)
+	return i
+}
+
+func gcmAdd(x, y *gcmFieldElement) gcmFieldElement {
+	return gcmFieldElement{x.low ^ y.low, x.high ^ y.high}
+}
+
+// gcmDouble returns x * 2 in the reflected representation.
+func gcmDouble(x *gcmFieldElement) (double gcmFieldElement) {
+	msbSet := x.high&1 == 1
+	double.high = x.high >> 1
+	double.high |= x.low << 63
+	double.low = x.low >> 1
+	if msbSet {
+		double.low ^= 0xe100000000000000
+	}
+	return
+}
+
+// gcmReductionTable holds the reduction of each 4-bit value shifted out of
+// the field element by mul.
+var gcmReductionTable = [16]uint16{
+	0x0000, 0x1c20, 0x3840, 0x2460, 0x7080, 0x6ca0, 0x48c0, 0x54e0,
+	0xe100, 0xfd20, 0xd940, 0xc560, 0x9180, 0x8da0, 0xa9c0, 0xb5e0,
+}
+
+// mul sets y to y * H using the 4-bit product table.
+func (g *gcm) mul(y *gcmFieldElement) {
+	var z gcmFieldElement
+	for i := 0; i < 2; i++ {
+		word := y.high
+		if i == 1 {
+			word = y.low
+		}
+		for j := 0; j < 64; j += 4 {
+			msw := z.high & 0xf
+			z.high >>= 4
+			z.high |= z.low << 60
+			z.low >>= 4
+			z.low ^= uint64(gcmReductionTable[msw]) << 48
+
+			t := &g.productTable[word&0xf]
+			z.low ^= t.low
+			z.high ^= t.high
+			word >>= 4
+		}
+	}
+	*y = z
+}
+
+// updateBlocks folds the whole blocks of blocks into y.
+func (g *gcm) updateBlocks(y *gcmFieldElement, blocks []byte) {
+	for len(blocks) > 0 {
+		y.low ^= binary.BigEndian.Uint64(blocks)
+		y.high ^= binary.BigEndian.Uint64(blocks[8:])
+		g.mul(y)
+		blocks = blocks[BlockSize:]
+	}
+}
+
+// update folds data into y, zero padding the final partial block.
+func (g *gcm) update(y *gcmFieldElement, data []byte) {
+	full := len(data) &^ (BlockSize - 1)
+	g.updateBlocks(y, data[:full])
+	if len(data) != full {
+		var partial [BlockSize]byte
+		copy(partial[:], data[full:])
+		g.updateBlocks(y, partial[:])
+	}
+}
+
+// deriveCounter computes the initial counter block J0 from the nonce.
+func (g *gcm) deriveCounter(counter *[BlockSize]byte, nonce []byte) {
+	if len(nonce) == gcmStandardNonceSize {
+		copy(counter[:], nonce)
+		counter[BlockSize-1] = 1
+		return
+	}
+	var y gcmFieldElement
+	g.update(&y, nonce)
+	y.high ^= uint64(len(nonce)) * 8
+	g.mul(&y)
+	binary.BigEndian.PutUint64(counter[:8], y.low)
+	binary.BigEndian.PutUint64(counter[8:], y.high)
+}
+
+// gcmInc32 increments the low 32 bits of the counter block, wrapping
+// without carrying into the rest of the block.
+func gcmInc32(counter *[BlockSize]byte) {
+	ctr := counter[BlockSize-4:]
+	binary.BigEndian.PutUint32(ctr, binary.BigEndian.Uint32(ctr)+1)
+}
+
+// counterCrypt XORs src with the keystream of counter into dst, advancing
+// counter. With the assembly it encrypts four counter blocks per call.
+func (g *gcm) counterCrypt(dst, src []byte, counter *[BlockSize]byte) {
+	var ks [4 * BlockSize]byte
+	if useAESNI {
+		for len(src) >= len(ks) {
+			for i := 0; i < len(ks); i += BlockSize {
+				copy(ks[i:], counter[:])
+				gcmInc32(counter)
+			}
+			cryptBlocks4AESNI(&g.block.subkeys[0], &ks[0], &ks[0])
+			subtle.XORBytes(dst, src, ks[:])
+			dst, src = dst[len(ks):], src[len(ks):]
+		}
+	}
+	for len(src) > 0 {
+		g.block.Encrypt(ks[:BlockSize], counter[:])
+		gcmInc32(counter)
+		n := subtle.XORBytes(dst, src, ks[:BlockSize])
+		dst, src = dst[n:], src[n:]
+	}
+}
+
+// auth computes the GHASH of aad and ciphertext, XORed with tagMask, into
+// out.
+func (g *gcm) auth(out, ciphertext, aad []byte, tagMask *[BlockSize]byte) {
+	var y gcmFieldElement
+	g.update(&y, aad)
+	g.update(&y, ciphertext)
+	y.low ^= uint64(len(aad)) * 8
+	y.high ^= uint64(len(ciphertext)) * 8
+	g.mul(&y)
+	binary.BigEndian.PutUint64(out, y.low)
+	binary.BigEndian.PutUint64(out[8:], y.high)
+	subtle.XORBytes(out, out, tagMask[:])
+}
@@ -0,0 +1,87 @@
+package sm4
+
+import (
+	"crypto/cipher"
+	"crypto/subtle"
+	"encoding/binary"
+	"errors"
+)
+
+// kwIV is the default initial value of RFC 3394 section 2.2.3.1.
+var kwIV = [8]byte{0xa6, 0xa6, 0xa6, 0xa6, 0xa6, 0xa6, 0xa6, 0xa6}
+
+var errKWUnwrap = errors.New("SM4: key unwrap integrity check failed")
+
+// WrapKey wraps keyData under kek with the RFC 3394 key wrap algorithm,
+// using SM4 in place of AES. keyData must be a multiple of 8 bytes and at
+// least 16 bytes long; the result is 8 bytes longer.
+func WrapKey(kek, keyData []byte) ([]byte, error) {
+	c, err := NewCipher(kek)
+	if err != nil {
+		return nil, err
+	}
+	return wrapKey(c, keyData)
+}
+
+// UnwrapKey reverses WrapKey and checks the integrity value, returning an
+// error if wrapped was altered or kek is wrong.
+func UnwrapKey(kek, wrapped []byte) ([]byte, error) {
+	c, err := NewCipher(kek)
+	if err != nil {
+		return nil, err
+	}
+	return unwrapKey(c, wrapped)
+}
+
+func wrapKey(b cipher.Block, keyData []byte) ([]byte, error) {
+	if len(keyData) < 16 || len(keyData)%8 != 0 {
+		return nil, errors.New("SM4: key data must be a multiple of 8 bytes and at least 16 bytes")
+	}
+	n := len(keyData) / 8
+	out := make([]byte, len(keyData)+8)
+	copy(out, kwIV[:])
+	copy(out[8:], keyData)
+
+	var buf [BlockSize]byte
+	for j := 0; j < 6; j++ {
+		for i := 1; i <= n; i++ {
+			copy(buf[:8], out[:8])
+			copy(buf[8:], out[i*8:])
+			b.Encrypt(buf[:], buf[:])
+			t := uint64(n*j + i)
+			binary.BigEndian.PutUint64(out[:8], binary.BigEndian.Uint64(buf[:8])^t)
+			copy(out[i*8:], buf[8:])
+		}
+	}
+	return out, nil
+}
+
+func unwrapKey(b cipher.Block, wrapped []byte) ([]byte, error) {
+	if len(wrapped) < 24 || len(wrapped)%8 != 0 {
+		return nil, errors.New("SM4: wrapped key must be a multiple of 8 bytes and at least 24 bytes")
+	}
+	n := len(wrapped)/8 - 1
+	var a [8]byte
+	copy(a[:], wrapped)
+	out := make([]byte, len(wrapped)-8)
+	copy(out, wrapped[8:])
+
+	var buf [BlockSize]byte
+	for j := 5; j >= 0; j-- {
+		for i := n; i >= 1; i-- {
+			t := uint64(n*j + i)
+			binary.BigEndian.PutUint64(buf[:8], binary.BigEndian.Uint64(a[:])^t)
+			copy(buf[8:], out[(i-1)*8:])
+			b.Decrypt(buf[:], buf[:])
+			copy(a[:], buf[:8])
+			copy(out[(i-1)*8:], buf[8:])
+		}
+	}
+	if subtle.ConstantTimeCompare(a[:], kwIV[:]) != 1 {
+		for i := range out {
+			out[i] = 0
+		}
+		return nil, errKWUnwrap
+	}
+	return out, nil
+}
@@ -0,0 +1,83 @@
+package sm4
+
+import (
+	"bytes"
+	"crypto/aes"
+	"encoding/hex"
+	"testing"
+)
+
+// The AES vectors of RFC 3394 section 4 check the algorithm itself.
+func TestKeyWrapRFC3394(t *testing.T) {
+	for _, tc := range []struct{ kek, key, wrapped string }{
+		{
+			"000102030405060708090A0B0C0D0E0F",
+			"00112233445566778899AABBCCDDEEFF",
+			"1FA68B0A8112B447AEF34BD8FB5A7B829D3E862371D2CFE5",
+		},
+		{
+			"000102030405060708090A0B0C0D0E0F1011121314151617",
+			"00112233445566778899AABBCCDDEEFF0001020304050607",
+			"031D33264E15D33268F24EC260743EDCE1C6C7DDEE725A936BA814915C6762D2",
+		},
+	} {
+		kek, _ := hex.DecodeString(tc.kek)
+		key, _ := hex.DecodeString(tc.key)
+		want, _ := hex.DecodeString(tc.wrapped)
+		b, err := aes.NewCipher(kek)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := wrapKey(b, key)
+		if err != nil || !bytes.Equal(got, want) {
+			t.Errorf("wrap: got %x, %v, want %x", got, err, want)
+		}
+		unwrapped, err := unwrapKey(b, want)
+		if err != nil || !bytes.Equal(unwrapped, key) {
+			t.Errorf("unwrap: got %x, %v, want %x", unwrapped, err, key)
+		}
+	}
+}
+
+func TestWrapKey(t *testing.T) {
+	kek := []byte("0123456789abcdef")
+	for _, n := range []int{16, 24, 32, 64} {
+		keyData := bytes.Repeat([]byte{byte(n)}, n)
+		wrapped, err := WrapKey(kek, keyData)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(wrapped) != n+8 {
+			t.Errorf("%d byte key wrapped to %d bytes", n, len(wrapped))
+		}
+		got, err := UnwrapKey(kek, wrapped)
+		if err != nil || !bytes.Equal(got, keyData) {
+			t.Errorf("%d byte key: round trip got %x, %v", n, got, err)
+		}
+
+		for i := range wrapped {
+			tampered := append([]byte(nil), wrapped...)
+			tampered[i] ^= 0x80
+			if _, err := UnwrapKey(kek, tampered); err != errKWUnwrap {
+				t.Fatalf("%d byte key: flipping byte %d: got %v", n, i, err)
+			}
+		}
+		if _, err := UnwrapKey([]byte("fedcba9876543210"), wrapped); err != errKWUnwrap {
+			t.Errorf("wrong kek: got %v", err)
+		}
+	}
+
+	for _, n := range []int{0, 8, 15, 17} {
+		if _, err := WrapKey(kek, make([]byte, n)); err == nil {
+			t.Errorf("%d byte key data accepted", n)
+		}
+	}
+	for _, n := range []int{0, 16, 25} {
+		if _, err := UnwrapKey(kek, make([]byte, n)); err == nil {
+			t.Errorf("%d byte wrapped key accepted", n)
+		}
+	}
+	if _, err := WrapKey(kek[:8], make([]byte, 16)); err == nil {
+		t.Error("short kek accepted")
+	}
+}
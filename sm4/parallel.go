@@ -0,0 +1,122 @@
+package sm4
+
+import (
+	"crypto/cipher"
+	"crypto/subtle"
+)
+
+// The methods in this file let crypto/cipher use the four-block assembly
+// of sm4_amd64.s for CTR and CBC decryption, the modes that can process
+// several blocks at once. Without the assembly they return the generic
+// crypto/cipher modes. Single blocks, and so CBC encryption, stay on the
+// table implementation, which is faster for one block at a time.
+
+// blockOnly hides the optional mode methods of a Sm4Cipher, so that the
+// crypto/cipher constructors fall back to their generic code.
+type blockOnly struct{ cipher.Block }
+
+// NewCTR is called by cipher.NewCTR.
+func (c *Sm4Cipher) NewCTR(iv []byte) cipher.Stream {
+	if !useAESNI {
+		return cipher.NewCTR(blockOnly{c}, iv)
+	}
+	if len(iv) != BlockSize {
+		panic("cipher.NewCTR: IV length must equal block size")
+	}
+	s := &ctr4{c: c}
+	copy(s.ctr[:], iv)
+	s.used = len(s.ks)
+	return s
+}
+
+// ctr4 is CTR mode generating four keystream blocks per assembly call. Like
+// the crypto/cipher implementation it increments the whole 128-bit counter.
+// It reads the round keys from c at every refill, so it panics once c has
+// been zeroized.
+type ctr4 struct {
+	c    *Sm4Cipher
+	ctr  [BlockSize]byte
+	ks   [4 * BlockSize]byte
+	used int
+}
+
+func (s *ctr4) refill() {
+	for i := 0; i < len(s.ks); i += BlockSize {
+		copy(s.ks[i:], s.ctr[:])
+		for j := BlockSize - 1; j >= 0; j-- {
+			s.ctr[j]++
+			if s.ctr[j] != 0 {
+				break
+			}
+		}
+	}
+	cryptBlocks4AESNI(&s.c.subkeys[0], &s.ks[0], &s.ks[0])
+	s.used = 0
+}
+
+func (s *ctr4) XORKeyStream(dst, src []byte) {
+	if len(dst) < len(src) {
+		panic("crypto/cipher: output smaller than input")
+	}
+	for len(src) > 0 {
+		if s.used == len(s.ks) {
+			s.refill()
+		}
+		n := subtle.XORBytes(dst, src, s.ks[s.used:])
+		s.used += n
+		dst, src = dst[n:], src[n:]
+	}
+}
+
+// NewCBCDecrypter is called by cipher.NewCBCDecrypter.
+func (c *Sm4Cipher) NewCBCDecrypter(iv []byte) cipher.BlockMode {
+	if !useAESNI {
+		return cipher.NewCBCDecrypter(blockOnly{c}, iv)
+	}
+	if len(iv) != BlockSize {
+		panic("cipher.NewCBCDecrypter: IV length must equal block size")
+	}
+	m := &cbcDec4{c: c}
+	copy(m.iv[:], iv)
+	return m
+}
+
+// cbcDec4 is CBC decryption of four blocks per assembly call. Like ctr4 it
+// reads the round keys from c at every call.
+type cbcDec4 struct {
+	c  *Sm4Cipher
+	iv [BlockSize]byte
+}
+
+func (m *cbcDec4) BlockSize() int { return BlockSize }
+
+// SetIV resets the IV, like the SetIV method of the crypto/cipher CBC modes
+// that TLS implementations use for explicit per-record IVs.
+func (m *cbcDec4) SetIV(iv []byte) {
+	if len(iv) != BlockSize {
+		panic("cipher: incorrect length IV")
+	}
+	copy(m.iv[:], iv)
+}
+
+func (m *cbcDec4) CryptBlocks(dst, src []byte) {
+	if len(src)%BlockSize != 0 {
+		panic("crypto/cipher: input not full blocks")
+	}
+	if len(dst) < len(src) {
+		panic("crypto/cipher: output smaller than input")
+	}
+	var buf [4 * BlockSize]byte
+	for len(src) > 0 {
+		n := copy(buf[:], src)
+		cryptBlocks4AESNI(&m.c.decSubkeys[0], &buf[0], &buf[0])
+		// Each plaintext block is XORed with the previous ciphertext
+		// block. src is read completely before dst is written, so the
+		// two may overlap exactly.
+		subtle.XORBytes(buf[:BlockSize], buf[:BlockSize], m.iv[:])
+		subtle.XORBytes(buf[BlockSize:n], buf[BlockSize:n], src[:n-BlockSize])
+		copy(m.iv[:], src[n-BlockSize:n])
+		copy(dst, buf[:n])
+		dst, src = dst[n:], src[n:]
+	}
+}
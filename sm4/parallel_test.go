@@ -0,0 +1,128 @@
+package sm4
+
+import (
+	"bytes"
+	"crypto/cipher"
+	"math/rand"
+	"testing"
+)
+
+// tableBlock is the table-based implementation, for comparison with the
+// assembly.
+type tableBlock struct{ c *Sm4Cipher }
+
+func (b tableBlock) BlockSize() int { return BlockSize }
+
+func (b tableBlock) Encrypt(dst, src []byte) {
+	cryptBlock(b.c.subkeys, b.c.block1, b.c.block2, dst, src, false)
+}
+
+func (b tableBlock) Decrypt(dst, src []byte) {
+	cryptBlock(b.c.subkeys, b.c.block1, b.c.block2, dst, src, true)
+}
+
+func TestParallelMatchesTable(t *testing.T) {
+	if !useAESNI {
+		t.Skip("no assembly on this machine")
+	}
+	rng := rand.New(rand.NewSource(1))
+	key := make([]byte, BlockSize)
+	iv := make([]byte, BlockSize)
+	for i := 0; i < 20; i++ {
+		rng.Read(key)
+		rng.Read(iv)
+		block, _ := NewCipher(key)
+		ref := tableBlock{block.(*Sm4Cipher)}
+
+		in, got, want := make([]byte, BlockSize), make([]byte, BlockSize), make([]byte, BlockSize)
+		rng.Read(in)
+		block.Encrypt(got, in)
+		ref.Encrypt(want, in)
+		if !bytes.Equal(got, want) {
+			t.Fatalf("Encrypt: got %x, want %x", got, want)
+		}
+		block.Decrypt(got, in)
+		ref.Decrypt(want, in)
+		if !bytes.Equal(got, want) {
+			t.Fatalf("Decrypt: got %x, want %x", got, want)
+		}
+
+		data := make([]byte, rng.Intn(1000))
+		rng.Read(data)
+		want = make([]byte, len(data))
+		cipher.NewCTR(ref, iv).XORKeyStream(want, data)
+		got = make([]byte, len(data))
+		s := cipher.NewCTR(block, iv)
+		for off := 0; off < len(data); {
+			n := rng.Intn(100)
+			if off+n > len(data) {
+				n = len(data) - off
+			}
+			s.XORKeyStream(got[off:off+n], data[off:off+n])
+			off += n
+		}
+		if !bytes.Equal(got, want) {
+			t.Fatalf("CTR with %d bytes differs from the table implementation", len(data))
+		}
+
+		data = data[:len(data)/BlockSize*BlockSize]
+		want = make([]byte, len(data))
+		cipher.NewCBCDecrypter(ref, iv).CryptBlocks(want, data)
+		got = append([]byte(nil), data...)
+		m := cipher.NewCBCDecrypter(block, iv)
+		half := len(got) / 2 / BlockSize * BlockSize
+		m.CryptBlocks(got[:half], got[:half])
+		m.CryptBlocks(got[half:], got[half:])
+		if !bytes.Equal(got, want) {
+			t.Fatalf("in-place CBC with %d bytes differs from the table implementation", len(data))
+		}
+		m.(interface{ SetIV([]byte) }).SetIV(iv)
+		m.CryptBlocks(got, data)
+		if !bytes.Equal(got, want) {
+			t.Fatal("CBC after SetIV differs from the table implementation")
+		}
+	}
+
+	// The counter carries across all 128 bits.
+	block, _ := NewCipher(key)
+	wrap := bytes.Repeat([]byte{0xff}, BlockSize)
+	want := make([]byte, 5*BlockSize)
+	cipher.NewCTR(tableBlock{block.(*Sm4Cipher)}, wrap).XORKeyStream(want, want)
+	got := make([]byte, len(want))
+	cipher.NewCTR(block, wrap).XORKeyStream(got, got)
+	if !bytes.Equal(got, want) {
+		t.Error("CTR counter wrap-around differs from the table implementation")
+	}
+}
+
+func BenchmarkBulk(b *testing.B) {
+	block, _ := NewCipher([]byte("1234567890abcdef"))
+	iv := make([]byte, BlockSize)
+	buf := make([]byte, 8192)
+	for _, impl := range []struct {
+		name  string
+		block cipher.Block
+	}{
+		{"Table", tableBlock{block.(*Sm4Cipher)}},
+		{"NewCipher", block},
+	} {
+		b.Run("CTR/"+impl.name, func(b *testing.B) {
+			b.SetBytes(int64(len(buf)))
+			for i := 0; i < b.N; i++ {
+				cipher.NewCTR(impl.block, iv).XORKeyStream(buf, buf)
+			}
+		})
+		b.Run("CBCDecrypt/"+impl.name, func(b *testing.B) {
+			b.SetBytes(int64(len(buf)))
+			for i := 0; i < b.N; i++ {
+				cipher.NewCBCDecrypter(impl.block, iv).CryptBlocks(buf, buf)
+			}
+		})
+		b.Run("CBCEncrypt/"+impl.name, func(b *testing.B) {
+			b.SetBytes(int64(len(buf)))
+			for i := 0; i < b.N; i++ {
+				cipher.NewCBCEncrypter(impl.block, iv).CryptBlocks(buf, buf)
+			}
+		})
+	}
+}
@@ -1,7 +1,9 @@
 package sm4
 
 import (
+	"crypto/cipher"
 	"errors"
+	"sync"
 )
 
 // CipherMode represents the different cipher modes supported
@@ -12,10 +14,21 @@ const (
 	CBC
 	CFB
 	OFB
+
+	// CTR and GCM are returned by RecommendMode. EncryptWithKey and
+	// DecryptWithKey do not accept them; use cipher.NewCTR or CTRSeek,
+	// and NewGCM.
+	CTR
+	GCM
 )
 
 // EncryptWithKey encrypts data using the provided key and returns the encrypted data
 // This is a convenience function that handles key setup and cipher creation automatically
+//
+// CBC, CFB and OFB use the package IV, which is all zero unless changed
+// with SetIV, so equal plaintexts give equal ciphertexts. Prefer
+// EncryptWithKeyIV with a fresh random IV for each message. ECB fails with
+// ErrECBDisabled when AllowECB is false.
 func EncryptWithKey(key, data []byte, mode CipherMode) ([]byte, error) {
 	if len(key) != BlockSize {
 		return nil, errors.New("SM4: invalid key size")
@@ -61,3 +74,228 @@ func DecryptWithKey(key, data []byte, mode CipherMode) ([]byte, error) {
 	}
 }
 
+
+// DecryptWithKeyLegacy decrypts data like DecryptWithKey but also accepts
+// block-aligned ciphertext written without the trailing PKCS#7 padding
+// block, as some older versions of this library produced. The padding is
+// removed only when the last block carries valid PKCS#7 padding; otherwise
+// the whole decryption is returned.
+//
+// The two formats cannot be told apart in general: a legacy plaintext that
+// happens to end in valid padding bytes (e.g. a final 0x01) is truncated.
+// Only use this function for data known to come from such versions.
+func DecryptWithKeyLegacy(key, data []byte, mode CipherMode) ([]byte, error) {
+	block, err := NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(data)%BlockSize != 0 {
+		return nil, errCiphertextNotBlocks
+	}
+
+	out := make([]byte, len(data))
+	iv := make([]byte, BlockSize)
+	copy(iv, IV)
+	switch mode {
+	case ECB:
+		for i := 0; i < len(data); i += BlockSize {
+			block.Decrypt(out[i:i+BlockSize], data[i:i+BlockSize])
+		}
+	case CBC:
+		cipher.NewCBCDecrypter(block, iv).CryptBlocks(out, data)
+	case CFB:
+		cipher.NewCFBDecrypter(block, iv).XORKeyStream(out, data)
+	case OFB:
+		cipher.NewOFB(block, iv).XORKeyStream(out, data)
+	default:
+		return nil, errors.New("SM4: unsupported cipher mode")
+	}
+	if unpadded, err := pkcs7UnPadding(out); err == nil {
+		return unpadded, nil
+	}
+	return out, nil
+}
+
+// Encryptor encrypts and decrypts messages like EncryptWithKey and
+// DecryptWithKey, but expands the key once in NewEncryptor instead of on
+// every call. Like those functions it uses PKCS#7 padding and reads the
+// package IV at each call. It is safe for concurrent use as long as IV is
+// not changed concurrently.
+type Encryptor struct {
+	block cipher.Block
+	mode  CipherMode
+}
+
+// NewEncryptor returns an Encryptor for key in mode, which must be ECB, CBC,
+// CFB or OFB.
+func NewEncryptor(key []byte, mode CipherMode) (*Encryptor, error) {
+	switch mode {
+	case ECB, CBC, CFB, OFB:
+	default:
+		return nil, errors.New("SM4: unsupported cipher mode")
+	}
+	block, err := NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return &Encryptor{block: block, mode: mode}, nil
+}
+
+// Encrypt pads data and encrypts it; the result equals EncryptWithKey's.
+func (e *Encryptor) Encrypt(data []byte) ([]byte, error) {
+	if err := checkECB(e.mode); err != nil {
+		return nil, err
+	}
+	return e.encrypt(data, IV), nil
+}
+
+// Decrypt decrypts data and removes the padding. Unlike DecryptWithKey it
+// reports an error for input that is not a whole number of blocks or that
+// does not end in valid padding.
+func (e *Encryptor) Decrypt(data []byte) ([]byte, error) {
+	return e.decrypt(data, IV)
+}
+
+func (e *Encryptor) encrypt(data, iv []byte) []byte {
+	// pkcs7Padding may append into the spare capacity of data, which must
+	// not be encrypted in place.
+	out := make([]byte, paddedLen(len(data)))
+	e.encryptInto(out, data, iv)
+	return out
+}
+
+// paddedLen returns the length of n bytes after PKCS#7 padding.
+func paddedLen(n int) int {
+	return n + BlockSize - n%BlockSize
+}
+
+// encryptInto pads data into out, which must be paddedLen(len(data)) bytes
+// and must not overlap data, and encrypts it in place.
+func (e *Encryptor) encryptInto(out, data, iv []byte) {
+	pad := len(out) - len(data)
+	copy(out, data)
+	for i := len(data); i < len(out); i++ {
+		out[i] = byte(pad)
+	}
+	e.crypt(out, out, iv, true)
+}
+
+func (e *Encryptor) decrypt(data, iv []byte) ([]byte, error) {
+	if len(data) == 0 || len(data)%BlockSize != 0 {
+		return nil, errCiphertextNotBlocks
+	}
+	out := make([]byte, len(data))
+	e.crypt(out, data, iv, false)
+	return pkcs7UnPadding(out)
+}
+
+func (e *Encryptor) crypt(dst, src, ivIn []byte, encrypt bool) {
+	var iv [BlockSize]byte
+	copy(iv[:], ivIn)
+	switch e.mode {
+	case ECB:
+		for i := 0; i < len(src); i += BlockSize {
+			if encrypt {
+				e.block.Encrypt(dst[i:i+BlockSize], src[i:i+BlockSize])
+			} else {
+				e.block.Decrypt(dst[i:i+BlockSize], src[i:i+BlockSize])
+			}
+		}
+	case CBC:
+		if encrypt {
+			cipher.NewCBCEncrypter(e.block, iv[:]).CryptBlocks(dst, src)
+		} else {
+			cipher.NewCBCDecrypter(e.block, iv[:]).CryptBlocks(dst, src)
+		}
+	case CFB:
+		if encrypt {
+			cipher.NewCFBEncrypter(e.block, iv[:]).XORKeyStream(dst, src)
+		} else {
+			cipher.NewCFBDecrypter(e.block, iv[:]).XORKeyStream(dst, src)
+		}
+	case OFB:
+		cipher.NewOFB(e.block, iv[:]).XORKeyStream(dst, src)
+	}
+}
+
+// EncryptWithKeyIV is like EncryptWithKey but uses iv instead of the package
+// IV. mode must be CBC, CFB or OFB and iv BlockSize bytes. The IV is not
+// part of the result: callers must store or send it with the ciphertext.
+// For CBC and CFB it must be unpredictable, and for OFB never reused with
+// the same key; a fresh random IV per message satisfies both.
+func EncryptWithKeyIV(key, iv, data []byte, mode CipherMode) ([]byte, error) {
+	e, err := newEncryptorIV(key, iv, mode)
+	if err != nil {
+		return nil, err
+	}
+	return e.encrypt(data, iv), nil
+}
+
+// DecryptWithKeyIV decrypts ciphertext produced by EncryptWithKeyIV with the
+// same key, iv and mode, and removes the padding.
+func DecryptWithKeyIV(key, iv, ciphertext []byte, mode CipherMode) ([]byte, error) {
+	e, err := newEncryptorIV(key, iv, mode)
+	if err != nil {
+		return nil, err
+	}
+	return e.decrypt(ciphertext, iv)
+}
+
+func newEncryptorIV(key, iv []byte, mode CipherMode) (*Encryptor, error) {
+	if mode == ECB {
+		return nil, errors.New("SM4: ECB does not use an IV")
+	}
+	if len(iv) != BlockSize {
+		return nil, errors.New("SM4: invalid iv size")
+	}
+	return NewEncryptor(key, mode)
+}
+
+// maxPooledBuffer is the largest buffer capacity PutBuffer keeps, so one
+// large message does not pin its memory in the pool.
+const maxPooledBuffer = 64 << 10
+
+var bufferPool = sync.Pool{
+	New: func() interface{} {
+		b := make([]byte, 0, 4<<10)
+		return &b
+	},
+}
+
+// getBuffer returns a pooled slice of length n.
+func getBuffer(n int) []byte {
+	bp := bufferPool.Get().(*[]byte)
+	if cap(*bp) < n {
+		bufferPool.Put(bp)
+		return make([]byte, n)
+	}
+	return (*bp)[:n]
+}
+
+// PutBuffer returns a slice obtained from EncryptWithKeyPooled to the pool.
+// The caller must not use buf, or any slice of it, afterwards. Passing other
+// slices is allowed but only useful if nothing else references them.
+func PutBuffer(buf []byte) {
+	if cap(buf) == 0 || cap(buf) > maxPooledBuffer {
+		return
+	}
+	buf = buf[:0]
+	bufferPool.Put(&buf)
+}
+
+// EncryptWithKeyPooled is like EncryptWithKey but writes the ciphertext to
+// a buffer from an internal pool. The result is valid until the caller
+// passes it to PutBuffer; returning it is optional, and a buffer that is
+// never returned is simply garbage collected.
+func EncryptWithKeyPooled(key, data []byte, mode CipherMode) ([]byte, error) {
+	if err := checkECB(mode); err != nil {
+		return nil, err
+	}
+	e, err := NewEncryptor(key, mode)
+	if err != nil {
+		return nil, err
+	}
+	out := getBuffer(paddedLen(len(data)))
+	e.encryptInto(out, data, IV)
+	return out, nil
+}
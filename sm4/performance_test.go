@@ -0,0 +1,295 @@
+package sm4
+
+import (
+	"bytes"
+	"crypto/cipher"
+	"testing"
+)
+
+func TestDecryptWithKeyLegacy(t *testing.T) {
+	key := []byte("1234567890abcdef")
+	plaintext := []byte("0123456789abcdef0123456789abcdef")
+	block, err := NewCipher(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	legacyEncrypt := func(mode CipherMode, src []byte) []byte {
+		dst := make([]byte, len(src))
+		iv := append([]byte(nil), IV...)
+		switch mode {
+		case ECB:
+			for i := 0; i < len(src); i += BlockSize {
+				block.Encrypt(dst[i:i+BlockSize], src[i:i+BlockSize])
+			}
+		case CBC:
+			cipher.NewCBCEncrypter(block, iv).CryptBlocks(dst, src)
+		case CFB:
+			cipher.NewCFBEncrypter(block, iv).XORKeyStream(dst, src)
+		case OFB:
+			cipher.NewOFB(block, iv).XORKeyStream(dst, src)
+		}
+		return dst
+	}
+
+	for _, mode := range []CipherMode{ECB, CBC, CFB, OFB} {
+		current, err := EncryptWithKey(key, append([]byte(nil), plaintext...), mode)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(current) != len(plaintext)+BlockSize {
+			t.Fatalf("mode %d: current ciphertext lacks the padding block", mode)
+		}
+		for name, ct := range map[string][]byte{"current": current, "legacy": legacyEncrypt(mode, plaintext)} {
+			got, err := DecryptWithKeyLegacy(key, ct, mode)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, plaintext) {
+				t.Errorf("mode %d, %s ciphertext: got %q", mode, name, got)
+			}
+		}
+	}
+
+	// The documented ambiguity: unpadded legacy data ending in what looks
+	// like padding loses those bytes.
+	ambiguous := append([]byte("0123456789abcde"), 0x01)
+	got, err := DecryptWithKeyLegacy(key, legacyEncrypt(ECB, ambiguous), ECB)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, ambiguous[:15]) {
+		t.Errorf("ambiguous input decrypted to %q", got)
+	}
+
+	if _, err := DecryptWithKeyLegacy(key, plaintext[:20], ECB); err == nil {
+		t.Error("unaligned ciphertext accepted")
+	}
+}
+
+func TestSm4EcbInto(t *testing.T) {
+	key := []byte("1234567890abcdef")
+	src := []byte("0123456789abcdef0123456789abcdef")
+	block, err := NewCipher(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := make([]byte, len(src))
+	for i := 0; i < len(src); i += BlockSize {
+		block.Encrypt(want[i:], src[i:i+BlockSize])
+	}
+	dst := make([]byte, len(src))
+	if err := Sm4EcbInto(dst, src, key); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(dst, want) {
+		t.Fatalf("got %x, want %x", dst, want)
+	}
+	if allocs := testing.AllocsPerRun(100, func() { Sm4EcbInto(dst, src, key) }); allocs != 0 {
+		t.Errorf("Sm4EcbInto allocates %v times", allocs)
+	}
+
+	if err := Sm4EcbInto(dst[:BlockSize], src, key); err == nil {
+		t.Error("short dst accepted")
+	}
+	if err := Sm4EcbInto(dst, src[:BlockSize+1], key); err == nil {
+		t.Error("partial block accepted")
+	}
+	if err := Sm4EcbInto(dst, src, key[:8]); err == nil {
+		t.Error("short key accepted")
+	}
+}
+
+func BenchmarkSm4EcbInto(b *testing.B) {
+	key := []byte("1234567890abcdef")
+	src := make([]byte, 1024)
+	dst := make([]byte, len(src))
+	b.SetBytes(int64(len(src)))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if err := Sm4EcbInto(dst, src, key); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestEncryptor(t *testing.T) {
+	key := []byte("1234567890abcdef")
+	for _, mode := range []CipherMode{ECB, CBC, CFB, OFB} {
+		enc, err := NewEncryptor(key, mode)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, n := range []int{0, 1, 15, 16, 17, 100} {
+			data := make([]byte, n, n+BlockSize)
+			for i := range data {
+				data[i] = byte(i)
+			}
+			want, err := EncryptWithKey(key, data, mode)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := enc.Encrypt(data)
+			if err != nil || !bytes.Equal(got, want) {
+				t.Errorf("mode %d, %d bytes: Encrypt differs from EncryptWithKey", mode, n)
+			}
+			for i := range data {
+				if data[i] != byte(i) {
+					t.Fatalf("mode %d, %d bytes: Encrypt modified its input", mode, n)
+				}
+			}
+			pt, err := enc.Decrypt(got)
+			if err != nil || !bytes.Equal(pt, data) {
+				t.Errorf("mode %d, %d bytes: round trip failed: %v", mode, n, err)
+			}
+		}
+		if _, err := enc.Decrypt(make([]byte, 17)); err == nil {
+			t.Errorf("mode %d: partial block accepted", mode)
+		}
+	}
+	if _, err := NewEncryptor(key, GCM); err == nil {
+		t.Error("GCM accepted")
+	}
+	if _, err := NewEncryptor(key[:8], CBC); err == nil {
+		t.Error("short key accepted")
+	}
+}
+
+func BenchmarkEncryptor(b *testing.B) {
+	key := []byte("1234567890abcdef")
+	msg := make([]byte, 32)
+	b.Run("EncryptWithKey", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			for j := 0; j < 1000; j++ {
+				if _, err := EncryptWithKey(key, msg, CBC); err != nil {
+					b.Fatal(err)
+				}
+			}
+		}
+	})
+	b.Run("Encryptor", func(b *testing.B) {
+		enc, err := NewEncryptor(key, CBC)
+		if err != nil {
+			b.Fatal(err)
+		}
+		for i := 0; i < b.N; i++ {
+			for j := 0; j < 1000; j++ {
+				if _, err := enc.Encrypt(msg); err != nil {
+					b.Fatal(err)
+				}
+			}
+		}
+	})
+}
+
+func TestEncryptWithKeyIV(t *testing.T) {
+	key := []byte("1234567890abcdef")
+	data := []byte("same plaintext, different ivs")
+	iv1 := []byte("0123456789abcdef")
+	iv2 := []byte("fedcba9876543210")
+	for _, mode := range []CipherMode{CBC, CFB, OFB} {
+		want, err := EncryptWithKey(key, data, mode)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := EncryptWithKeyIV(key, IV, data, mode)
+		if err != nil || !bytes.Equal(got, want) {
+			t.Errorf("mode %d: the package IV does not reproduce EncryptWithKey", mode)
+		}
+
+		ct1, err := EncryptWithKeyIV(key, iv1, data, mode)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ct2, err := EncryptWithKeyIV(key, iv2, data, mode)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if bytes.Equal(ct1, ct2) {
+			t.Errorf("mode %d: different ivs give the same ciphertext", mode)
+		}
+		pt, err := DecryptWithKeyIV(key, iv1, ct1, mode)
+		if err != nil || !bytes.Equal(pt, data) {
+			t.Errorf("mode %d: round trip failed: %v", mode, err)
+		}
+		if pt, err := DecryptWithKeyIV(key, iv2, ct1, mode); err == nil && bytes.Equal(pt, data) {
+			t.Errorf("mode %d: decrypted with the wrong iv", mode)
+		}
+		if _, err := EncryptWithKeyIV(key, iv1[:8], data, mode); err == nil {
+			t.Errorf("mode %d: short iv accepted", mode)
+		}
+	}
+	if _, err := EncryptWithKeyIV(key, iv1, data, ECB); err == nil {
+		t.Error("ECB accepted")
+	}
+	if _, err := DecryptWithKeyIV(key, iv1, make([]byte, 16), GCM); err == nil {
+		t.Error("GCM accepted")
+	}
+}
+
+func TestEncryptWithKeyPooled(t *testing.T) {
+	key := []byte("1234567890abcdef")
+	for _, mode := range []CipherMode{ECB, CBC, CFB, OFB} {
+		for _, n := range []int{0, 15, 16, 100, 5000} {
+			data := bytes.Repeat([]byte{byte(n)}, n)
+			want, err := EncryptWithKey(key, data, mode)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := EncryptWithKeyPooled(key, data, mode)
+			if err != nil || !bytes.Equal(got, want) {
+				t.Errorf("mode %d, %d bytes: EncryptWithKeyPooled differs from EncryptWithKey", mode, n)
+			}
+			PutBuffer(got)
+		}
+	}
+	if _, err := EncryptWithKeyPooled(key, nil, GCM); err == nil {
+		t.Error("GCM accepted")
+	}
+	PutBuffer(nil)
+	PutBuffer(make([]byte, maxPooledBuffer+1))
+}
+
+func BenchmarkEncryptWithKeyPooled(b *testing.B) {
+	key := []byte("1234567890abcdef")
+	msg := make([]byte, 1024)
+	b.Run("EncryptWithKey", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if _, err := EncryptWithKey(key, msg, CBC); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("Pooled", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			out, err := EncryptWithKeyPooled(key, msg, CBC)
+			if err != nil {
+				b.Fatal(err)
+			}
+			PutBuffer(out)
+		}
+	})
+}
+
+// BenchmarkBlock compares single-block encryption and decryption. Decrypt
+// reads the same round keys in reverse order, so the two should match.
+func BenchmarkBlock(b *testing.B) {
+	c, err := NewCipher([]byte("1234567890abcdef"))
+	if err != nil {
+		b.Fatal(err)
+	}
+	buf := make([]byte, BlockSize)
+	b.Run("Encrypt", func(b *testing.B) {
+		b.SetBytes(BlockSize)
+		for i := 0; i < b.N; i++ {
+			c.Encrypt(buf, buf)
+		}
+	})
+	b.Run("Decrypt", func(b *testing.B) {
+		b.SetBytes(BlockSize)
+		for i := 0; i < b.N; i++ {
+			c.Decrypt(buf, buf)
+		}
+	})
+}
@@ -0,0 +1,55 @@
+package sm4
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/tjfoc/gmsm/sm3"
+)
+
+var ratchetLabel = []byte("SM4 ratchet")
+
+// RatchetKey derives the key following currentKey in a key chain using the
+// SM3 KDF, then wipes currentKey in place. Because the KDF is one-way, a
+// compromised later key does not reveal earlier ones.
+func RatchetKey(currentKey []byte) (nextKey []byte) {
+	nextKey = sm3.KDF(BlockSize, ratchetLabel, currentKey)
+	for i := range currentKey {
+		currentKey[i] = 0
+	}
+	return nextKey
+}
+
+// Ratchet manages a chain of SM4 keys derived from a root key with
+// RatchetKey. The chain is deterministic: two ratchets built from the same
+// root produce the same sequence of keys.
+type Ratchet struct {
+	key  []byte
+	step uint64
+}
+
+// NewRatchet returns a Ratchet whose chain starts at root. The root is
+// copied; the caller stays responsible for wiping its own copy.
+func NewRatchet(root []byte) (*Ratchet, error) {
+	if len(root) != BlockSize {
+		return nil, errors.New("SM4: invalid key size " + strconv.Itoa(len(root)))
+	}
+	key := make([]byte, BlockSize)
+	copy(key, root)
+	return &Ratchet{key: key}, nil
+}
+
+// Next advances the chain and returns the new message key. The previous
+// chain key is wiped. The returned slice is a copy owned by the caller.
+func (r *Ratchet) Next() []byte {
+	r.key = RatchetKey(r.key)
+	r.step++
+	key := make([]byte, BlockSize)
+	copy(key, r.key)
+	return key
+}
+
+// Step returns how many times the chain has been advanced.
+func (r *Ratchet) Step() uint64 {
+	return r.step
+}
@@ -0,0 +1,43 @@
+package sm4
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestRatchet(t *testing.T) {
+	root := []byte("1234567890abcdef")
+	r1, err := NewRatchet(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r2, _ := NewRatchet(root)
+
+	seen := map[string]bool{string(root): true}
+	for i := 0; i < 16; i++ {
+		k1, k2 := r1.Next(), r2.Next()
+		if !bytes.Equal(k1, k2) {
+			t.Fatalf("step %d: ratchet is not deterministic", i)
+		}
+		if seen[string(k1)] {
+			t.Fatalf("step %d: key repeated", i)
+		}
+		seen[string(k1)] = true
+	}
+	if r1.Step() != 16 {
+		t.Errorf("Step() = %d, want 16", r1.Step())
+	}
+
+	cur := []byte("1234567890abcdef")
+	next := RatchetKey(cur)
+	if !bytes.Equal(cur, make([]byte, BlockSize)) {
+		t.Error("current key was not wiped")
+	}
+	if r3, _ := NewRatchet(root); !bytes.Equal(r3.Next(), next) {
+		t.Error("RatchetKey and Ratchet disagree")
+	}
+
+	if _, err := NewRatchet(root[:8]); err == nil {
+		t.Error("expected error for invalid root size")
+	}
+}
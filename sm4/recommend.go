@@ -0,0 +1,27 @@
+package sm4
+
+// streamingThreshold is the data size from which RecommendMode prefers CTR
+// over CBC for unauthenticated encryption.
+const streamingThreshold = 64 << 10
+
+// RecommendMode returns the mode to use for dataSize bytes of data, with a
+// negative dataSize meaning the size is unknown in advance:
+//
+//   - GCM whenever needAuth is set, whatever the size;
+//   - CTR for large or unknown sized data without authentication, since it
+//     needs no padding and can be processed as a stream;
+//   - CBC for smaller data without authentication.
+//
+// ECB is never recommended: it leaks which plaintext blocks are equal.
+// Without authentication, ciphertexts can be modified undetected, so prefer
+// needAuth unless integrity is provided by another layer.
+func RecommendMode(dataSize int, needAuth bool) CipherMode {
+	switch {
+	case needAuth:
+		return GCM
+	case dataSize < 0 || dataSize >= streamingThreshold:
+		return CTR
+	default:
+		return CBC
+	}
+}
@@ -0,0 +1,30 @@
+package sm4
+
+import "testing"
+
+func TestRecommendMode(t *testing.T) {
+	for _, tc := range []struct {
+		size     int
+		needAuth bool
+		want     CipherMode
+	}{
+		{0, true, GCM},
+		{100, true, GCM},
+		{1 << 30, true, GCM},
+		{-1, true, GCM},
+		{0, false, CBC},
+		{100, false, CBC},
+		{streamingThreshold - 1, false, CBC},
+		{streamingThreshold, false, CTR},
+		{1 << 30, false, CTR},
+		{-1, false, CTR},
+	} {
+		got := RecommendMode(tc.size, tc.needAuth)
+		if got != tc.want {
+			t.Errorf("RecommendMode(%d, %v) = %d, want %d", tc.size, tc.needAuth, got, tc.want)
+		}
+		if got == ECB {
+			t.Errorf("RecommendMode(%d, %v) recommended ECB", tc.size, tc.needAuth)
+		}
+	}
+}
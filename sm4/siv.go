@@ -0,0 +1,90 @@
+package sm4
+
+import (
+	"crypto/cipher"
+	"crypto/subtle"
+	"errors"
+	"strconv"
+)
+
+var errSIVOpen = errors.New("SM4: SIV message authentication failed")
+
+// DeterministicSeal encrypts plaintext with SM4-SIV (RFC 5297 with SM4 in
+// place of AES) and returns the 16 byte synthetic IV followed by the
+// ciphertext. key is 32 bytes: the first half keys the S2V CMAC and the
+// second half the CTR encryption. It panics if key has another length.
+//
+// No nonce is used: the IV is derived from aad and plaintext, so sealing
+// the same (key, aad, plaintext) twice yields the same output. This leaks
+// which messages are equal to anyone who sees the ciphertexts; only use it
+// where that is acceptable, such as key wrapping or deduplicated storage.
+// Apart from that leak, reusing inputs does not weaken it as nonce reuse
+// weakens GCM.
+func DeterministicSeal(key, aad, plaintext []byte) []byte {
+	mac, ctr := sivKeys(key)
+	v := s2v(mac, aad, plaintext)
+	out := make([]byte, BlockSize+len(plaintext))
+	copy(out, v)
+	cipher.NewCTR(ctr, sivCounter(v)).XORKeyStream(out[BlockSize:], plaintext)
+	return out
+}
+
+// DeterministicOpen verifies and decrypts the output of DeterministicSeal.
+// No plaintext is returned unless the synthetic IV matches.
+func DeterministicOpen(key, aad, ciphertext []byte) ([]byte, error) {
+	if len(key) != 2*BlockSize {
+		return nil, errors.New("SM4: invalid SIV key size " + strconv.Itoa(len(key)))
+	}
+	if len(ciphertext) < BlockSize {
+		return nil, errSIVOpen
+	}
+	mac, ctr := sivKeys(key)
+	v := ciphertext[:BlockSize]
+	plaintext := make([]byte, len(ciphertext)-BlockSize)
+	cipher.NewCTR(ctr, sivCounter(v)).XORKeyStream(plaintext, ciphertext[BlockSize:])
+	if subtle.ConstantTimeCompare(s2v(mac, aad, plaintext), v) != 1 {
+		for i := range plaintext {
+			plaintext[i] = 0
+		}
+		return nil, errSIVOpen
+	}
+	return plaintext, nil
+}
+
+func sivKeys(key []byte) (mac *cmacState, ctr cipher.Block) {
+	if len(key) != 2*BlockSize {
+		panic("SM4: invalid SIV key size " + strconv.Itoa(len(key)))
+	}
+	k1, _ := NewCipher(key[:BlockSize])
+	k2, _ := NewCipher(key[BlockSize:])
+	return newCMAC(k1), k2
+}
+
+// s2v computes the S2V function of RFC 5297 over the two strings aad and
+// plaintext. aad is always a component, even when empty.
+func s2v(mac *cmacState, aad, plaintext []byte) []byte {
+	var zero, d [BlockSize]byte
+	copy(d[:], mac.sum(zero[:]))
+	d = gfDouble(d)
+	subtle.XORBytes(d[:], d[:], mac.sum(aad))
+	if len(plaintext) >= BlockSize {
+		t := append([]byte(nil), plaintext...)
+		subtle.XORBytes(t[len(t)-BlockSize:], t[len(t)-BlockSize:], d[:])
+		return mac.sum(t)
+	}
+	d = gfDouble(d)
+	var t [BlockSize]byte
+	copy(t[:], plaintext)
+	t[len(plaintext)] = 0x80
+	subtle.XORBytes(t[:], t[:], d[:])
+	return mac.sum(t[:])
+}
+
+// sivCounter returns the initial CTR block: v with the top bit of its
+// last two 32-bit words cleared.
+func sivCounter(v []byte) []byte {
+	q := append([]byte(nil), v...)
+	q[8] &= 0x7f
+	q[12] &= 0x7f
+	return q
+}
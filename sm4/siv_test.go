@@ -0,0 +1,52 @@
+package sm4
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestDeterministicSeal(t *testing.T) {
+	key := []byte("0123456789abcdef0123456789ABCDEF")
+	aad := []byte("header")
+	for _, n := range []int{0, 5, 15, 16, 17, 40} {
+		plaintext := bytes.Repeat([]byte{byte(n)}, n)
+		ct := DeterministicSeal(key, aad, plaintext)
+		if len(ct) != BlockSize+n {
+			t.Fatalf("%d bytes: ciphertext is %d bytes", n, len(ct))
+		}
+		if !bytes.Equal(DeterministicSeal(key, aad, plaintext), ct) {
+			t.Errorf("%d bytes: sealing is not deterministic", n)
+		}
+		if bytes.Equal(DeterministicSeal(key, []byte("other"), plaintext), ct) {
+			t.Errorf("%d bytes: aad does not affect the ciphertext", n)
+		}
+		pt, err := DeterministicOpen(key, aad, ct)
+		if err != nil || !bytes.Equal(pt, plaintext) {
+			t.Errorf("%d bytes: round trip failed: %v", n, err)
+		}
+
+		for i := range ct {
+			tampered := append([]byte(nil), ct...)
+			tampered[i] ^= 0x01
+			if pt, err := DeterministicOpen(key, aad, tampered); err == nil || pt != nil {
+				t.Errorf("%d bytes: flipped byte %d accepted", n, i)
+			}
+		}
+		if _, err := DeterministicOpen(key, []byte("headex"), ct); err == nil {
+			t.Errorf("%d bytes: wrong aad accepted", n)
+		}
+	}
+
+	if _, err := DeterministicOpen(key, aad, make([]byte, BlockSize-1)); err == nil {
+		t.Error("short ciphertext accepted")
+	}
+	if _, err := DeterministicOpen(key[:16], aad, make([]byte, 32)); err == nil {
+		t.Error("16 byte key accepted")
+	}
+	defer func() {
+		if recover() == nil {
+			t.Error("DeterministicSeal did not panic on a 16 byte key")
+		}
+	}()
+	DeterministicSeal(key[:16], aad, nil)
+}
@@ -36,6 +36,9 @@ type Sm4Cipher struct {
 	subkeys []uint32
 	block1  []uint32
 	block2  []byte
+
+	// decSubkeys holds the round keys in reverse order, for the assembly.
+	decSubkeys []uint32
 }
 
 // sm4密钥参量
@@ -227,8 +230,14 @@ func cryptBlock(subkeys []uint32, b []uint32, r []byte, dst, src []byte, decrypt
 
 func generateSubKeys(key []byte) []uint32 {
 	subkeys := make([]uint32, 32)
-	b := make([]uint32, 4)
-	permuteInitialBlock(b, key)
+	expandKey(subkeys, key)
+	return subkeys
+}
+
+// expandKey writes the 32 round keys for key into subkeys.
+func expandKey(subkeys []uint32, key []byte) {
+	var b [4]uint32
+	permuteInitialBlock(b[:], key)
 	b[0] ^= fk[0]
 	b[1] ^= fk[1]
 	b[2] ^= fk[2]
@@ -237,7 +246,6 @@ func generateSubKeys(key []byte) []uint32 {
 		subkeys[i] = feistel0(b[0], b[1], b[2], b[3], ck[i])
 		b[0], b[1], b[2], b[3] = b[1], b[2], b[3], subkeys[i]
 	}
-	return subkeys
 }
 
 // NewCipher creates and returns a new cipher.Block.
@@ -247,6 +255,12 @@ func NewCipher(key []byte) (cipher.Block, error) {
 	}
 	c := new(Sm4Cipher)
 	c.subkeys = generateSubKeys(key)
+	if useAESNI {
+		c.decSubkeys = make([]uint32, 32)
+		for i, k := range c.subkeys {
+			c.decSubkeys[31-i] = k
+		}
+	}
 	c.block1 = make([]uint32, 4)
 	c.block2 = make([]byte, 16)
 	return c, nil
@@ -256,14 +270,55 @@ func (c *Sm4Cipher) BlockSize() int {
 	return BlockSize
 }
 
+// Encrypt encrypts the first block of src into dst. src is read completely
+// before dst is written, so dst and src may overlap entirely.
 func (c *Sm4Cipher) Encrypt(dst, src []byte) {
 	cryptBlock(c.subkeys, c.block1, c.block2, dst, src, false)
 }
 
+// Decrypt decrypts the first block of src into dst. As with Encrypt, dst
+// and src may overlap entirely. The round keys expanded by NewCipher are
+// read in reverse order, not reversed per block, so decryption costs the
+// same as encryption.
 func (c *Sm4Cipher) Decrypt(dst, src []byte) {
 	cryptBlock(c.subkeys, c.block1, c.block2, dst, src, true)
 }
 
+// EncryptInPlace encrypts buf, which must be exactly one block, in place.
+func (c *Sm4Cipher) EncryptInPlace(buf []byte) {
+	if len(buf) != BlockSize {
+		panic("SM4: EncryptInPlace requires exactly one block")
+	}
+	c.Encrypt(buf, buf)
+}
+
+// DecryptInPlace decrypts buf, which must be exactly one block, in place.
+func (c *Sm4Cipher) DecryptInPlace(buf []byte) {
+	if len(buf) != BlockSize {
+		panic("SM4: DecryptInPlace requires exactly one block")
+	}
+	c.Decrypt(buf, buf)
+}
+
+// Zeroize overwrites the round keys and the block buffers of c with zeros
+// and drops them, so no key material is left behind once c is garbage
+// collected. c is unusable afterwards: Encrypt, Decrypt and any mode built
+// on c, including CTR streams and CBC decrypters created before Zeroize,
+// panic instead of silently using all-zero round keys. Keys that a mode
+// derives for itself, such as the GHASH table of NewGCM, are not reached
+// and stay in memory until the mode is garbage collected.
+func (c *Sm4Cipher) Zeroize() {
+	for _, k := range [][]uint32{c.subkeys, c.decSubkeys, c.block1} {
+		for i := range k {
+			k[i] = 0
+		}
+	}
+	for i := range c.block2 {
+		c.block2[i] = 0
+	}
+	c.subkeys, c.decSubkeys, c.block1, c.block2 = nil, nil, nil, nil
+}
+
 func xor(in, iv []byte) (out []byte) {
 	if len(in) != len(iv) {
 		return nil
@@ -282,6 +337,10 @@ func pkcs7Padding(src []byte) []byte {
 	return append(src, padtext...)
 }
 
+// errCiphertextNotBlocks is returned when decrypting a padded ciphertext
+// that is empty or not a whole number of blocks.
+var errCiphertextNotBlocks = errors.New("SM4: ciphertext is not a multiple of the block size")
+
 func pkcs7UnPadding(src []byte) ([]byte, error) {
 	length := len(src)
 	if length == 0 {
@@ -317,6 +376,9 @@ func Sm4Cbc(key []byte, in []byte, mode bool) (out []byte, err error) {
 	if mode {
 		inData = pkcs7Padding(in)
 	} else {
+		if len(in) == 0 || len(in)%BlockSize != 0 {
+			return nil, errCiphertextNotBlocks
+		}
 		inData = in
 	}
 	iv := make([]byte, BlockSize)
@@ -343,7 +405,7 @@ func Sm4Cbc(key []byte, in []byte, mode bool) (out []byte, err error) {
 			copy(out[i*16:i*16+16], out_tmp)
 			iv = in_tmp
 		}
-		out, _ = pkcs7UnPadding(out)
+		return pkcs7UnPadding(out)
 	}
 
 	return out, nil
@@ -352,37 +414,62 @@ func Sm4Ecb(key []byte, in []byte, mode bool) (out []byte, err error) {
 	if len(key) != BlockSize {
 		return nil, errors.New("SM4: invalid key size " + strconv.Itoa(len(key)))
 	}
+	if mode && !AllowECB {
+		return nil, ErrECBDisabled
+	}
 	var inData []byte
 	if mode {
 		inData = pkcs7Padding(in)
 	} else {
+		if len(in) == 0 || len(in)%BlockSize != 0 {
+			return nil, errCiphertextNotBlocks
+		}
 		inData = in
 	}
 	out = make([]byte, len(inData))
-	c, err := NewCipher(key)
-	if err != nil {
+	if err := ecbCrypt(out, inData, key, !mode); err != nil {
 		return nil, err
 	}
-	if mode {
-		for i := 0; i < len(inData)/16; i++ {
-			in_tmp := inData[i*16 : i*16+16]
-			out_tmp := make([]byte, 16)
-			c.Encrypt(out_tmp, in_tmp)
-			copy(out[i*16:i*16+16], out_tmp)
-		}
-	} else {
-		for i := 0; i < len(inData)/16; i++ {
-			in_tmp := inData[i*16 : i*16+16]
-			out_tmp := make([]byte, 16)
-			c.Decrypt(out_tmp, in_tmp)
-			copy(out[i*16:i*16+16], out_tmp)
-		}
-		out, _ = pkcs7UnPadding(out)
+	if !mode {
+		return pkcs7UnPadding(out)
 	}
 
 	return out, nil
 }
 
+// Sm4EcbInto encrypts src in ECB mode into dst without padding and without
+// allocating. len(src) must be a multiple of BlockSize and dst at least as
+// long as src.
+func Sm4EcbInto(dst, src, key []byte) error {
+	if !AllowECB {
+		return ErrECBDisabled
+	}
+	return ecbCrypt(dst, src, key, false)
+}
+
+func ecbCrypt(dst, src, key []byte, decrypt bool) error {
+	if len(key) != BlockSize {
+		return errors.New("SM4: invalid key size " + strconv.Itoa(len(key)))
+	}
+	if len(src)%BlockSize != 0 {
+		return errors.New("SM4: input not full blocks")
+	}
+	if len(dst) < len(src) {
+		return errors.New("SM4: output smaller than input")
+	}
+	var subkeys [32]uint32
+	var b [4]uint32
+	var r [BlockSize]byte
+	expandKey(subkeys[:], key)
+	for i := 0; i < len(src); i += BlockSize {
+		cryptBlock(subkeys[:], b[:], r[:], dst[i:i+BlockSize], src[i:i+BlockSize], decrypt)
+	}
+	for i := range subkeys {
+		subkeys[i] = 0
+	}
+	return nil
+}
+
 //密码反馈模式（Cipher FeedBack (CFB)）
 //https://blog.csdn.net/zy_strive_2012/article/details/102520356
 //https://blog.csdn.net/sinat_23338865/article/details/72869841
@@ -394,6 +481,9 @@ func Sm4CFB(key []byte, in []byte, mode bool) (out []byte, err error) {
 	if mode {
 		inData = pkcs7Padding(in)
 	} else {
+		if len(in) == 0 || len(in)%BlockSize != 0 {
+			return nil, errCiphertextNotBlocks
+		}
 		inData = in
 	}
 
@@ -436,7 +526,7 @@ func Sm4CFB(key []byte, in []byte, mode bool) (out []byte, err error) {
 
 		}
 
-		out, _ = pkcs7UnPadding(out)
+		return pkcs7UnPadding(out)
 	}
 
 	return out, nil
@@ -453,6 +543,9 @@ func Sm4OFB(key []byte, in []byte, mode bool) (out []byte, err error) {
 	if mode {
 		inData = pkcs7Padding(in)
 	} else {
+		if len(in) == 0 || len(in)%BlockSize != 0 {
+			return nil, errCiphertextNotBlocks
+		}
 		inData = in
 	}
 
@@ -495,7 +588,7 @@ func Sm4OFB(key []byte, in []byte, mode bool) (out []byte, err error) {
 			copy(out[i*16:i*16+16], plainBlock)
 			copy(shiftIV, K[:BlockSize])
 		}
-		out, _ = pkcs7UnPadding(out)
+		return pkcs7UnPadding(out)
 	}
 
 	return out, nil
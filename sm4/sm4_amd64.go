@@ -0,0 +1,15 @@
+//go:build amd64 && !purego
+
+package sm4
+
+import "golang.org/x/sys/cpu"
+
+// useAESNI selects the amd64 assembly, which computes the SM4 S-box with
+// the AES-NI AESENCLAST instruction and processes four blocks at once.
+var useAESNI = cpu.X86.HasAES && cpu.X86.HasSSSE3
+
+// cryptBlocks4AESNI encrypts the four blocks at src into dst with the 32
+// round keys at rk; decryption passes the round keys in reverse order.
+//
+//go:noescape
+func cryptBlocks4AESNI(rk *uint32, dst, src *byte)
@@ -0,0 +1,162 @@
+//go:build amd64 && !purego
+
+#include "textflag.h"
+
+// The SM4 S-box is affine equivalent to the AES S-box: S(x) =
+// post(AESSubBytes(pre(x))). Both affine maps are applied as two 4-bit
+// table lookups with PSHUFB, and AESENCLAST provides SubBytes.
+DATA sm4PreLo<>+0x00(SB)/8, $0x078b37bb820eb23e
+DATA sm4PreLo<>+0x08(SB)/8, $0x9814a8241d912da1
+GLOBL sm4PreLo<>(SB), RODATA|NOPTR, $16
+DATA sm4PreHi<>+0x00(SB)/8, $0x37eb19c5f22edc00
+DATA sm4PreHi<>+0x08(SB)/8, $0x3fe311cdfa26d408
+GLOBL sm4PreHi<>(SB), RODATA|NOPTR, $16
+
+// The post tables absorb the 0x0f AESENCLAST round key, which spares a
+// zero register: the low table is indexed by the nibble XOR 0xf.
+DATA sm4PostLo<>+0x00(SB)/8, $0x0bb3c179358dff47
+DATA sm4PostLo<>+0x08(SB)/8, $0x6cd4a61e52ea9820
+GLOBL sm4PostLo<>(SB), RODATA|NOPTR, $16
+DATA sm4PostHi<>+0x00(SB)/8, $0x2dcd7d9db050e000
+DATA sm4PostHi<>+0x08(SB)/8, $0xed0dbd5d709020c0
+GLOBL sm4PostHi<>(SB), RODATA|NOPTR, $16
+
+DATA sm4Nibble<>+0x00(SB)/8, $0x0f0f0f0f0f0f0f0f
+DATA sm4Nibble<>+0x08(SB)/8, $0x0f0f0f0f0f0f0f0f
+GLOBL sm4Nibble<>(SB), RODATA|NOPTR, $16
+
+// Inverse ShiftRows, undoing the ShiftRows step of AESENCLAST, alone and
+// combined with rotations of each 32-bit word left by 8, 16 and 24 bits.
+DATA sm4InvShiftRows<>+0x00(SB)/8, $0x0b0e0104070a0d00
+DATA sm4InvShiftRows<>+0x08(SB)/8, $0x0306090c0f020508
+GLOBL sm4InvShiftRows<>(SB), RODATA|NOPTR, $16
+DATA sm4InvShiftRowsRol8<>+0x00(SB)/8, $0x0e01040b0a0d0007
+DATA sm4InvShiftRowsRol8<>+0x08(SB)/8, $0x06090c030205080f
+GLOBL sm4InvShiftRowsRol8<>(SB), RODATA|NOPTR, $16
+DATA sm4InvShiftRowsRol16<>+0x00(SB)/8, $0x01040b0e0d00070a
+DATA sm4InvShiftRowsRol16<>+0x08(SB)/8, $0x090c030605080f02
+GLOBL sm4InvShiftRowsRol16<>(SB), RODATA|NOPTR, $16
+DATA sm4InvShiftRowsRol24<>+0x00(SB)/8, $0x040b0e0100070a0d
+DATA sm4InvShiftRowsRol24<>+0x08(SB)/8, $0x0c030609080f0205
+GLOBL sm4InvShiftRowsRol24<>(SB), RODATA|NOPTR, $16
+
+// Byte swap of each 32-bit word: SM4 words are big-endian.
+DATA sm4Bswap32<>+0x00(SB)/8, $0x0405060700010203
+DATA sm4Bswap32<>+0x08(SB)/8, $0x0c0d0e0f08090a0b
+GLOBL sm4Bswap32<>(SB), RODATA|NOPTR, $16
+
+// AFFINE applies the affine map with nibble tables LO and HI to the bytes
+// of X4, using X5 and X6.
+#define AFFINE(LO, HI) \
+	MOVOU X4, X5; \
+	PSRLL $4, X5; \
+	PAND  X7, X4; \
+	PAND  X7, X5; \
+	MOVOU LO, X6; \
+	PSHUFB X4, X6; \
+	MOVOU HI, X4; \
+	PSHUFB X5, X4; \
+	PXOR  X6, X4
+
+// ROUND computes B0 ^= L(S(B1 ^ B2 ^ B3 ^ rk)) for the round key at
+// OFF(AX) on four blocks at once, with
+// L(B) = B ^ (B <<< 2) ^ (B <<< 10) ^ (B <<< 18) ^ (B <<< 24)
+//      = B ^ (B <<< 24) ^ ((B ^ (B <<< 8) ^ (B <<< 16)) <<< 2).
+#define ROUND(OFF, B0, B1, B2, B3) \
+	MOVL  OFF(AX), R8; \
+	MOVQ  R8, X4; \
+	PSHUFD $0, X4, X4; \
+	PXOR  B1, X4; \
+	PXOR  B2, X4; \
+	PXOR  B3, X4; \
+	AFFINE(X8, X9); \
+	AESENCLAST X7, X4; \
+	AFFINE(X10, X11); \
+	MOVOU X4, X5; \
+	PSHUFB X13, X5; \
+	MOVOU X4, X6; \
+	PSHUFB X14, X6; \
+	PXOR  X6, X5; \
+	MOVOU X4, X6; \
+	PSHUFB X15, X6; \
+	PSHUFB X12, X4; \
+	PXOR  X4, X5; \
+	PXOR  X4, X6; \
+	PXOR  X6, B0; \
+	MOVOU X5, X4; \
+	PSLLL $2, X5; \
+	PSRLL $30, X4; \
+	PXOR  X4, X5; \
+	PXOR  X5, B0
+
+// TRANSPOSE transposes the 4x4 matrix of 32-bit words in R0..R3, using
+// X4..X7.
+#define TRANSPOSE(R0, R1, R2, R3) \
+	MOVOU R0, X4; \
+	PUNPCKLLQ R1, X4; \
+	MOVOU R0, X5; \
+	PUNPCKHLQ R1, X5; \
+	MOVOU R2, X6; \
+	PUNPCKLLQ R3, X6; \
+	MOVOU R2, X7; \
+	PUNPCKHLQ R3, X7; \
+	MOVOU X4, R0; \
+	PUNPCKLQDQ X6, R0; \
+	MOVOU X4, R1; \
+	PUNPCKHQDQ X6, R1; \
+	MOVOU X5, R2; \
+	PUNPCKLQDQ X7, R2; \
+	MOVOU X5, R3; \
+	PUNPCKHQDQ X7, R3
+
+// func cryptBlocks4AESNI(rk *uint32, dst, src *byte)
+//
+// X15 is used freely: this is an ABI0 function, and the ABI wrapper
+// restores the zero register on return to Go code.
+TEXT ·cryptBlocks4AESNI(SB), NOSPLIT, $0-24
+	MOVQ rk+0(FP), AX
+	MOVQ dst+8(FP), DI
+	MOVQ src+16(FP), SI
+
+	MOVOU sm4Bswap32<>(SB), X8
+	MOVOU 0(SI), X0
+	MOVOU 16(SI), X1
+	MOVOU 32(SI), X2
+	MOVOU 48(SI), X3
+	PSHUFB X8, X0
+	PSHUFB X8, X1
+	PSHUFB X8, X2
+	PSHUFB X8, X3
+	TRANSPOSE(X0, X1, X2, X3)
+
+	MOVOU sm4Nibble<>(SB), X7
+	MOVOU sm4PreLo<>(SB), X8
+	MOVOU sm4PreHi<>(SB), X9
+	MOVOU sm4PostLo<>(SB), X10
+	MOVOU sm4PostHi<>(SB), X11
+	MOVOU sm4InvShiftRows<>(SB), X12
+	MOVOU sm4InvShiftRowsRol8<>(SB), X13
+	MOVOU sm4InvShiftRowsRol16<>(SB), X14
+	MOVOU sm4InvShiftRowsRol24<>(SB), X15
+
+	MOVQ $8, CX
+loop:
+	ROUND(0, X0, X1, X2, X3)
+	ROUND(4, X1, X2, X3, X0)
+	ROUND(8, X2, X3, X0, X1)
+	ROUND(12, X3, X0, X1, X2)
+	ADDQ $16, AX
+	DECQ CX
+	JNZ  loop
+
+	TRANSPOSE(X3, X2, X1, X0)
+	MOVOU sm4Bswap32<>(SB), X8
+	PSHUFB X8, X3
+	PSHUFB X8, X2
+	PSHUFB X8, X1
+	PSHUFB X8, X0
+	MOVOU X3, 0(DI)
+	MOVOU X2, 16(DI)
+	MOVOU X1, 32(DI)
+	MOVOU X0, 48(DI)
+	RET
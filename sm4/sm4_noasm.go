@@ -0,0 +1,9 @@
+//go:build !amd64 || purego
+
+package sm4
+
+const useAESNI = false
+
+func cryptBlocks4AESNI(rk *uint32, dst, src *byte) {
+	panic("SM4: no assembly implementation")
+}
@@ -16,6 +16,8 @@ limitations under the License.
 package sm4
 
 import (
+	"bytes"
+	"crypto/cipher"
 	"fmt"
 	"reflect"
 	"testing"
@@ -151,3 +153,102 @@ func testCompare(key1, key2 []byte) bool {
 	}
 	return true
 }
+
+func TestInPlace(t *testing.T) {
+	key := []byte("0123456789abcdef")
+	c, err := NewCipher(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sc := c.(*Sm4Cipher)
+	plain := []byte("fedcba9876543210")
+	want := make([]byte, BlockSize)
+	c.Encrypt(want, plain)
+
+	buf := append([]byte(nil), plain...)
+	c.Encrypt(buf, buf)
+	if !bytes.Equal(buf, want) {
+		t.Errorf("in-place Encrypt: got %x, want %x", buf, want)
+	}
+	c.Decrypt(buf, buf)
+	if !bytes.Equal(buf, plain) {
+		t.Errorf("in-place Decrypt: got %x, want %x", buf, plain)
+	}
+
+	sc.EncryptInPlace(buf)
+	if !bytes.Equal(buf, want) {
+		t.Errorf("EncryptInPlace: got %x, want %x", buf, want)
+	}
+	sc.DecryptInPlace(buf)
+	if !bytes.Equal(buf, plain) {
+		t.Errorf("DecryptInPlace: got %x, want %x", buf, plain)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("EncryptInPlace accepted a partial block")
+		}
+	}()
+	sc.EncryptInPlace(buf[:8])
+}
+
+func TestZeroize(t *testing.T) {
+	block, err := NewCipher([]byte("1234567890abcdef"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := block.(*Sm4Cipher)
+	buf := make([]byte, BlockSize)
+	c.Encrypt(buf, buf)
+	subkeys, decSubkeys, block1, block2 := c.subkeys, c.decSubkeys, c.block1, c.block2
+
+	c.Zeroize()
+	for _, k := range [][]uint32{subkeys, decSubkeys, block1} {
+		for i, w := range k {
+			if w != 0 {
+				t.Fatalf("word %d not zeroed: %08x", i, w)
+			}
+		}
+	}
+	for i, b := range block2 {
+		if b != 0 {
+			t.Fatalf("buffer byte %d not zeroed", i)
+		}
+	}
+	if c.subkeys != nil || c.decSubkeys != nil {
+		t.Error("round keys still referenced after Zeroize")
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("Encrypt after Zeroize did not panic")
+		}
+	}()
+	c.Encrypt(buf, buf)
+}
+
+func TestZeroizeModes(t *testing.T) {
+	block, err := NewCipher([]byte("1234567890abcdef"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	iv := make([]byte, BlockSize)
+	ctr := cipher.NewCTR(block, iv)
+	cbc := cipher.NewCBCDecrypter(block, iv)
+	block.(*Sm4Cipher).Zeroize()
+
+	buf := make([]byte, 4*BlockSize)
+	for name, use := range map[string]func(){
+		"CTR":           func() { ctr.XORKeyStream(buf, buf) },
+		"CBC decrypter": func() { cbc.CryptBlocks(buf, buf) },
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%s created before Zeroize kept working", name)
+				}
+			}()
+			use()
+		}()
+	}
+}
@@ -0,0 +1,36 @@
+package sm4
+
+import (
+	"crypto/cipher"
+	"errors"
+)
+
+var errStreamIV = errors.New("SM4: IV length must equal block size")
+
+// NewOFB returns a stream that encrypts or decrypts with block in OFB mode.
+// XORKeyStream accepts slices of any length and keeps the keystream position
+// across calls, so chunked output matches Sm4OFB on the padded message.
+func NewOFB(block cipher.Block, iv []byte) (cipher.Stream, error) {
+	if len(iv) != block.BlockSize() {
+		return nil, errStreamIV
+	}
+	return cipher.NewOFB(block, iv), nil
+}
+
+// NewCFBEncrypter returns a stream that encrypts with block in CFB mode
+// with full-block feedback, matching Sm4CFB. Like NewOFB it takes input in
+// chunks of any length.
+func NewCFBEncrypter(block cipher.Block, iv []byte) (cipher.Stream, error) {
+	if len(iv) != block.BlockSize() {
+		return nil, errStreamIV
+	}
+	return cipher.NewCFBEncrypter(block, iv), nil
+}
+
+// NewCFBDecrypter returns the stream that reverses NewCFBEncrypter.
+func NewCFBDecrypter(block cipher.Block, iv []byte) (cipher.Stream, error) {
+	if len(iv) != block.BlockSize() {
+		return nil, errStreamIV
+	}
+	return cipher.NewCFBDecrypter(block, iv), nil
+}
@@ -0,0 +1,73 @@
+package sm4
+
+import (
+	"bytes"
+	"crypto/cipher"
+	"testing"
+)
+
+// xorChunks runs s over src in chunks of the given sizes, cycling through
+// them until the input is consumed.
+func xorChunks(s cipher.Stream, src []byte, sizes []int) []byte {
+	dst := make([]byte, len(src))
+	for off, i := 0, 0; off < len(src); i++ {
+		n := sizes[i%len(sizes)]
+		if off+n > len(src) {
+			n = len(src) - off
+		}
+		s.XORKeyStream(dst[off:off+n], src[off:off+n])
+		off += n
+	}
+	return dst
+}
+
+func TestStreams(t *testing.T) {
+	key := []byte("1234567890abcdef")
+	data := pkcs7Padding(bytes.Repeat([]byte("streaming chunks"), 7)[:100])
+	block, err := NewCipher(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sizes := []int{1, 15, 3, 16, 33, 0, 7}
+
+	ofb, err := Sm4OFB(key, data[:100], true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := NewOFB(block, IV)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := xorChunks(s, data, sizes); !bytes.Equal(got, ofb) {
+		t.Error("chunked OFB differs from Sm4OFB")
+	}
+	s, _ = NewOFB(block, IV)
+	if got := xorChunks(s, ofb, sizes[1:]); !bytes.Equal(got, data) {
+		t.Error("chunked OFB does not decrypt")
+	}
+
+	cfb, err := Sm4CFB(key, data[:100], true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err = NewCFBEncrypter(block, IV)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := xorChunks(s, data, sizes); !bytes.Equal(got, cfb) {
+		t.Error("chunked CFB differs from Sm4CFB")
+	}
+	s, err = NewCFBDecrypter(block, IV)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := xorChunks(s, cfb, sizes[2:]); !bytes.Equal(got, data) {
+		t.Error("chunked CFB does not decrypt")
+	}
+
+	for _, f := range []func(cipher.Block, []byte) (cipher.Stream, error){NewOFB, NewCFBEncrypter, NewCFBDecrypter} {
+		if _, err := f(block, IV[:8]); err == nil {
+			t.Error("short IV accepted")
+		}
+	}
+}
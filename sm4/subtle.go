@@ -0,0 +1,16 @@
+package sm4
+
+import "crypto/subtle"
+
+// ConstantTimeSelect returns a copy of a if condition == 1 and a copy of b if
+// condition == 0, without branching on condition. Its behavior is undefined
+// if condition takes any other value. a and b must have the same length.
+func ConstantTimeSelect(condition int, a, b []byte) []byte {
+	if len(a) != len(b) {
+		panic("SM4: ConstantTimeSelect inputs have different lengths")
+	}
+	out := make([]byte, len(b))
+	copy(out, b)
+	subtle.ConstantTimeCopy(condition, out, a)
+	return out
+}
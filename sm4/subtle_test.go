@@ -0,0 +1,32 @@
+package sm4
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestConstantTimeSelect(t *testing.T) {
+	a := []byte("ciphertext one..")
+	b := []byte("ciphertext two..")
+	if got := ConstantTimeSelect(1, a, b); !bytes.Equal(got, a) {
+		t.Errorf("condition 1 selected %q", got)
+	}
+	if got := ConstantTimeSelect(0, a, b); !bytes.Equal(got, b) {
+		t.Errorf("condition 0 selected %q", got)
+	}
+	got := ConstantTimeSelect(1, a, b)
+	got[0] ^= 1
+	if a[0] != 'c' {
+		t.Error("result aliases the input")
+	}
+	if got := ConstantTimeSelect(0, nil, nil); len(got) != 0 {
+		t.Error("empty inputs should select an empty slice")
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("inputs of different lengths did not panic")
+		}
+	}()
+	ConstantTimeSelect(1, a, b[:3])
+}
@@ -0,0 +1,25 @@
+package sm4
+
+import (
+	"crypto/cipher"
+
+	"github.com/tjfoc/gmsm/sm3"
+)
+
+// NewTLSAEAD derives the SM4-GCM record protection of TLS_SM4_GCM_SM3
+// (RFC 8998) from a TLS 1.3 style traffic secret:
+//
+//	key = TLSKeySchedule(secret, "key", "", 16)
+//	iv  = TLSKeySchedule(secret, "iv", "", 12)
+//
+// It returns the AEAD and the static iv; the nonce of each record is iv
+// with the 64 bit record sequence number XORed into its last 8 bytes.
+func NewTLSAEAD(trafficSecret []byte) (cipher.AEAD, []byte, error) {
+	key := sm3.TLSKeySchedule(trafficSecret, []byte("key"), nil, BlockSize)
+	iv := sm3.TLSKeySchedule(trafficSecret, []byte("iv"), nil, 12)
+	aead, err := newGCMFromKey(key)
+	if err != nil {
+		return nil, nil, err
+	}
+	return aead, iv, nil
+}
@@ -0,0 +1,42 @@
+package sm4
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+
+	"github.com/tjfoc/gmsm/sm3"
+)
+
+func TestNewTLSAEAD(t *testing.T) {
+	secret := bytes.Repeat([]byte{0x42}, 32)
+	aead, iv, err := NewTLSAEAD(secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(iv) != aead.NonceSize() {
+		t.Fatalf("iv is %d bytes, want %d", len(iv), aead.NonceSize())
+	}
+	if !bytes.Equal(iv, sm3.TLSKeySchedule(secret, []byte("iv"), nil, 12)) {
+		t.Error("iv does not match the key schedule")
+	}
+	key := sm3.TLSKeySchedule(secret, []byte("key"), nil, 16)
+
+	nonce := append([]byte(nil), iv...)
+	seq := binary.BigEndian.Uint64(nonce[4:]) ^ 7
+	binary.BigEndian.PutUint64(nonce[4:], seq)
+	ct := aead.Seal(nil, nonce, []byte("record"), []byte("header"))
+
+	block, err := NewCipher(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ref, err := NewGCM(block)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pt, err := ref.Open(nil, nonce, ct, []byte("header"))
+	if err != nil || string(pt) != "record" {
+		t.Errorf("record does not open with the derived key: %v", err)
+	}
+}
@@ -0,0 +1,82 @@
+package sm4
+
+import (
+	"bytes"
+	"crypto/cipher"
+	"errors"
+)
+
+// ErrWeakKey is returned by NewCipherStrict for keys reported by IsWeakKey.
+var ErrWeakKey = errors.New("SM4: weak or default key")
+
+// WeakKeyChecks are the patterns IsWeakKey tests, each reporting whether a
+// 16 byte key matches. Deployments may append their own checks, such as
+// the keys of a vendor's sample configuration, at init time; the slice
+// must not be modified concurrently with IsWeakKey.
+//
+// Unlike DES, SM4 has no known weak or semi-weak keys whose round keys
+// repeat or cancel out. The default checks instead catch keys that point to
+// a misconfiguration rather than a random key:
+//   - a single repeated byte, such as all zero or all 0xff bytes, typical
+//     of unset or erased key storage;
+//   - two identical 8 byte halves, as produced by padding a 64-bit key by
+//     repetition;
+//   - the sample keys of the SM4 standard (GB/T 32907 appendix A) and the
+//     "1234567890abcdef" key common in examples, which must never protect
+//     real data.
+var WeakKeyChecks = []func(key []byte) bool{
+	repeatedByteKey,
+	repeatedHalvesKey,
+	sampleKey,
+}
+
+var sampleKeys = [][]byte{
+	{0x01, 0x23, 0x45, 0x67, 0x89, 0xab, 0xcd, 0xef, 0xfe, 0xdc, 0xba, 0x98, 0x76, 0x54, 0x32, 0x10},
+	[]byte("1234567890abcdef"),
+	[]byte("0123456789abcdef"),
+}
+
+func repeatedByteKey(key []byte) bool {
+	for _, b := range key[1:] {
+		if b != key[0] {
+			return false
+		}
+	}
+	return true
+}
+
+func repeatedHalvesKey(key []byte) bool {
+	return bytes.Equal(key[:BlockSize/2], key[BlockSize/2:])
+}
+
+func sampleKey(key []byte) bool {
+	for _, k := range sampleKeys {
+		if bytes.Equal(key, k) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsWeakKey reports whether key matches any of WeakKeyChecks. Keys that are
+// not 16 bytes long are invalid rather than weak, and return false.
+func IsWeakKey(key []byte) bool {
+	if len(key) != BlockSize {
+		return false
+	}
+	for _, check := range WeakKeyChecks {
+		if check(key) {
+			return true
+		}
+	}
+	return false
+}
+
+// NewCipherStrict is like NewCipher but returns ErrWeakKey for keys
+// reported by IsWeakKey.
+func NewCipherStrict(key []byte) (cipher.Block, error) {
+	if IsWeakKey(key) {
+		return nil, ErrWeakKey
+	}
+	return NewCipher(key)
+}
@@ -0,0 +1,55 @@
+package sm4
+
+import (
+	"bytes"
+	"crypto/rand"
+	"testing"
+)
+
+func TestIsWeakKey(t *testing.T) {
+	for _, key := range [][]byte{
+		make([]byte, 16),
+		bytes.Repeat([]byte{0xff}, 16),
+		bytes.Repeat([]byte{0x5a}, 16),
+		[]byte("abcdefghabcdefgh"),
+		{0x01, 0x23, 0x45, 0x67, 0x89, 0xab, 0xcd, 0xef, 0xfe, 0xdc, 0xba, 0x98, 0x76, 0x54, 0x32, 0x10},
+		[]byte("1234567890abcdef"),
+	} {
+		if !IsWeakKey(key) {
+			t.Errorf("%x not reported as weak", key)
+		}
+		if _, err := NewCipherStrict(key); err != ErrWeakKey {
+			t.Errorf("%x: NewCipherStrict returned %v, want ErrWeakKey", key, err)
+		}
+	}
+
+	key := make([]byte, 16)
+	if _, err := rand.Read(key); err != nil {
+		t.Fatal(err)
+	}
+	if IsWeakKey(key) {
+		t.Errorf("random key %x reported as weak", key)
+	}
+	if _, err := NewCipherStrict(key); err != nil {
+		t.Error(err)
+	}
+	if IsWeakKey(make([]byte, 8)) {
+		t.Error("short key reported as weak")
+	}
+	if _, err := NewCipherStrict(make([]byte, 8)); err == nil || err == ErrWeakKey {
+		t.Errorf("short key: got %v, want a key size error", err)
+	}
+
+	custom := []byte("vendor sample k!")
+	if IsWeakKey(custom) {
+		t.Fatal("custom key weak before adding a check")
+	}
+	saved := WeakKeyChecks
+	defer func() { WeakKeyChecks = saved }()
+	WeakKeyChecks = append(WeakKeyChecks[:len(WeakKeyChecks):len(WeakKeyChecks)], func(k []byte) bool {
+		return bytes.Equal(k, custom)
+	})
+	if !IsWeakKey(custom) {
+		t.Error("custom check not applied")
+	}
+}
@@ -280,6 +280,46 @@ func ParseSm2CertifateToX509(asn1data []byte) (*x509.Certificate, error) {
 	}
 	return sm2Cert.ToX509Certificate(), nil
 }
+
+// ParseSM2PublicKeyFromCert returns the SM2 public key of cert, parsed from
+// its SubjectPublicKeyInfo. The key must be an id-ecPublicKey with the SM2
+// named curve (1.2.156.10197.1.301) and an uncompressed point on that
+// curve.
+func ParseSM2PublicKeyFromCert(cert *Certificate) (*sm2.PublicKey, error) {
+	var spki publicKeyInfo
+	rest, err := asn1.Unmarshal(cert.RawSubjectPublicKeyInfo, &spki)
+	if err != nil {
+		return nil, err
+	}
+	if len(rest) != 0 {
+		return nil, errors.New("x509: trailing data after SubjectPublicKeyInfo")
+	}
+	if !spki.Algorithm.Algorithm.Equal(oidPublicKeyECDSA) {
+		return nil, errors.New("x509: certificate key is not an elliptic curve key")
+	}
+	var curve asn1.ObjectIdentifier
+	if rest, err := asn1.Unmarshal(spki.Algorithm.Parameters.FullBytes, &curve); err != nil || len(rest) != 0 {
+		return nil, errors.New("x509: invalid elliptic curve parameters")
+	}
+	if !curve.Equal(oidNamedCurveP256SM2) {
+		return nil, errors.New("x509: certificate key does not use the SM2 curve")
+	}
+	return sm2.Unmarshal(spki.PublicKey.RightAlign())
+}
+
+// VerifyCertSignature verifies an ASN.1 SM2 signature over data, with the
+// default user ID, under the public key of cert. Like sm2.VerifyDetailed it
+// returns (false, nil) for a well-formed signature that does not match and
+// (false, err) for a malformed signature or a certificate without an SM2
+// key.
+func VerifyCertSignature(cert *Certificate, data, sig []byte) (bool, error) {
+	pub, err := ParseSM2PublicKeyFromCert(cert)
+	if err != nil {
+		return false, err
+	}
+	return sm2.VerifyDetailed(pub, data, sig)
+}
+
 // 32byte
 func zeroByteSlice() []byte {
 	return []byte{
@@ -566,3 +566,29 @@ NextCert:
 
 	return true
 }
+
+// VerifyChain verifies that leaf chains up to one of roots, using
+// intermediates if needed, at the time now. Every certificate on the chain
+// must be within its validity period, every issuing certificate must be a CA
+// permitted to sign certificates by its basic constraints and key usage, and
+// every signature, including SM2WithSM3 ones, must verify against the
+// issuer's public key. Extended key usages are not constrained.
+func VerifyChain(leaf *Certificate, intermediates, roots []*Certificate, now time.Time) error {
+	if len(roots) == 0 {
+		return errors.New("x509: no root certificates given")
+	}
+	opts := VerifyOptions{
+		Intermediates: NewCertPool(),
+		Roots:         NewCertPool(),
+		CurrentTime:   now,
+		KeyUsages:     []ExtKeyUsage{ExtKeyUsageAny},
+	}
+	for _, cert := range intermediates {
+		opts.Intermediates.AddCert(cert)
+	}
+	for _, cert := range roots {
+		opts.Roots.AddCert(cert)
+	}
+	_, err := leaf.Verify(opts)
+	return err
+}
@@ -0,0 +1,124 @@
+package x509
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/tjfoc/gmsm/sm2"
+)
+
+func createTestCert(t *testing.T, serial int64, cn string, notBefore, notAfter time.Time, isCA bool,
+	parent *Certificate, pub *sm2.PublicKey, signer *sm2.PrivateKey) *Certificate {
+	template := &Certificate{
+		SerialNumber:          big.NewInt(serial),
+		Subject:               pkix.Name{CommonName: cn},
+		NotBefore:             notBefore,
+		NotAfter:              notAfter,
+		SignatureAlgorithm:    SM2WithSM3,
+		BasicConstraintsValid: true,
+		IsCA:                  isCA,
+	}
+	if isCA {
+		template.KeyUsage = KeyUsageCertSign | KeyUsageCRLSign
+	} else {
+		template.KeyUsage = KeyUsageDigitalSignature
+	}
+	if parent == nil {
+		parent = template
+	}
+	der, err := CreateCertificate(template, parent, pub, signer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert
+}
+
+func TestVerifyChain(t *testing.T) {
+	rootKey, _ := sm2.GenerateKey(nil)
+	interKey, _ := sm2.GenerateKey(nil)
+	leafKey, _ := sm2.GenerateKey(nil)
+	now := time.Now()
+	start, end := now.Add(-time.Hour), now.Add(24*time.Hour)
+
+	root := createTestCert(t, 1, "root", start, end, true, nil, &rootKey.PublicKey, rootKey)
+	inter := createTestCert(t, 2, "intermediate", start, end, true, root, &interKey.PublicKey, rootKey)
+	leaf := createTestCert(t, 3, "leaf", start, end, false, inter, &leafKey.PublicKey, interKey)
+
+	if err := VerifyChain(leaf, []*Certificate{inter}, []*Certificate{root}, now); err != nil {
+		t.Fatalf("valid chain rejected: %v", err)
+	}
+	if err := VerifyChain(leaf, nil, []*Certificate{root}, now); err == nil {
+		t.Error("chain without intermediate accepted")
+	}
+	if err := VerifyChain(leaf, []*Certificate{inter}, []*Certificate{root}, end.Add(time.Hour)); err == nil {
+		t.Error("chain accepted after expiry")
+	}
+
+	expiredInter := createTestCert(t, 4, "intermediate", start.Add(-48*time.Hour), now.Add(-time.Minute), true,
+		root, &interKey.PublicKey, rootKey)
+	if err := VerifyChain(leaf, []*Certificate{expiredInter}, []*Certificate{root}, now); err == nil {
+		t.Error("chain with expired intermediate accepted")
+	}
+
+	notCA := createTestCert(t, 5, "intermediate", start, end, false, root, &interKey.PublicKey, rootKey)
+	if err := VerifyChain(leaf, []*Certificate{notCA}, []*Certificate{root}, now); err == nil {
+		t.Error("chain with non-CA intermediate accepted")
+	}
+}
+
+func TestVerifyCertSignature(t *testing.T) {
+	priv, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	cert := createTestCert(t, 1, "signer", now, now.Add(time.Hour), false, nil, &priv.PublicKey, priv)
+
+	pub, err := ParseSM2PublicKeyFromCert(cert)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pub.X.Cmp(priv.X) != 0 || pub.Y.Cmp(priv.Y) != 0 || pub.Curve != sm2.P256Sm2() {
+		t.Error("parsed key does not match the certificate key")
+	}
+
+	data := []byte("signed with a certified key")
+	sig, err := priv.Sign(rand.Reader, data, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := VerifyCertSignature(cert, data, sig); !ok || err != nil {
+		t.Errorf("valid signature: got %v, %v", ok, err)
+	}
+	if ok, err := VerifyCertSignature(cert, []byte("other data"), sig); ok || err != nil {
+		t.Errorf("other data: got %v, %v; want false, nil", ok, err)
+	}
+	if ok, err := VerifyCertSignature(cert, data, sig[:len(sig)-1]); ok || err == nil {
+		t.Errorf("truncated signature: got %v, %v; want an error", ok, err)
+	}
+
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	spki, err := x509.MarshalPKIXPublicKey(&ecKey.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ParseSM2PublicKeyFromCert(&Certificate{RawSubjectPublicKeyInfo: spki}); err == nil {
+		t.Error("P-256 key accepted as an SM2 key")
+	}
+	if _, err := VerifyCertSignature(&Certificate{}, data, sig); err == nil {
+		t.Error("certificate without a key accepted")
+	}
+}
@@ -219,8 +219,12 @@ func init() {
 	RegisterHash(SM3, sm3.New)
 }
 
-// HashFunc simply returns the value of h so that Hash implements SignerOpts.
+// HashFunc returns the crypto.Hash matching h so that Hash implements
+// SignerOpts. SM3 has no crypto.Hash value of its own and maps to sm3.Hash.
 func (h Hash) HashFunc() crypto.Hash {
+	if h == SM3 {
+		return sm3.Hash
+	}
 	return crypto.Hash(h)
 }
 
@@ -2140,7 +2144,10 @@ func parseCSRExtensions(rawAttributes []asn1.RawValue) ([]pkix.Extension, error)
 // The returned slice is the certificate request in DER encoding.
 //
 // All keys types that are implemented via crypto.Signer are supported (This
-// includes *rsa.PublicKey and *ecdsa.PublicKey.)
+// includes *rsa.PublicKey, *ecdsa.PublicKey and *sm2.PrivateKey.) SM2 keys
+// default to SM2WithSM3, signed over ZA with the default user ID, and are
+// encoded as id-ecPublicKey on the SM2 curve. ParseCertificateRequest and
+// CheckSignature accept such requests.
 func CreateCertificateRequest(rand io.Reader, template *CertificateRequest, signer crypto.Signer) (csr []byte, err error) {
 	var hashFunc Hash
 	var sigAlgo pkix.AlgorithmIdentifier
@@ -16,7 +16,9 @@ limitations under the License.
 package x509
 
 import (
+	"bytes"
 	"crypto"
+	"crypto/ecdsa"
 	"crypto/rand"
 	"crypto/rsa"
 	"crypto/x509/pkix"
@@ -29,6 +31,7 @@ import (
 	"time"
 
 	"github.com/tjfoc/gmsm/sm2"
+	"github.com/tjfoc/gmsm/sm3"
 )
 
 func TestX509(t *testing.T) {
@@ -448,3 +451,69 @@ func TestCreateRevocationList(t *testing.T) {
 		})
 	}
 }
+
+func TestCreateCertificateRequestSM2(t *testing.T) {
+	priv, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// SignatureAlgorithm is left unset: SM2 keys default to SM2WithSM3.
+	template := &CertificateRequest{
+		Subject:  pkix.Name{CommonName: "csr.example.com", Organization: []string{"Test"}},
+		DNSNames: []string{"csr.example.com"},
+	}
+	der, err := CreateCertificateRequest(rand.Reader, template, priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var raw certificateRequest
+	if _, err := asn1.Unmarshal(der, &raw); err != nil {
+		t.Fatal(err)
+	}
+	if !raw.SignatureAlgorithm.Algorithm.Equal(asn1.ObjectIdentifier{1, 2, 156, 10197, 1, 501}) {
+		t.Errorf("signature algorithm OID is %v", raw.SignatureAlgorithm.Algorithm)
+	}
+
+	csr, err := ParseCertificateRequest(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if csr.SignatureAlgorithm != SM2WithSM3 || csr.Subject.CommonName != "csr.example.com" {
+		t.Errorf("parsed CSR has algorithm %v and subject %v", csr.SignatureAlgorithm, csr.Subject)
+	}
+	if err := csr.CheckSignature(); err != nil {
+		t.Fatal(err)
+	}
+	// SM2 public keys are parsed as *ecdsa.PublicKey on the SM2 curve.
+	key, ok := csr.PublicKey.(*ecdsa.PublicKey)
+	if !ok || key.Curve != sm2.P256Sm2() {
+		t.Fatalf("CSR public key is %T", csr.PublicKey)
+	}
+	pub := &sm2.PublicKey{Curve: key.Curve, X: key.X, Y: key.Y}
+	r, s, err := sm2.SignDataToSignDigit(csr.Signature)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The signature uses the default user ID of GM/T 0009.
+	if !sm2.Sm2Verify(pub, csr.RawTBSCertificateRequest, nil, r, s) {
+		t.Error("CSR signature does not verify with the default user ID")
+	}
+
+	tampered := append([]byte(nil), der...)
+	tampered[bytes.Index(tampered, []byte("Test"))] ^= 1
+	if csr, err := ParseCertificateRequest(tampered); err != nil {
+		t.Fatal(err)
+	} else if err := csr.CheckSignature(); err == nil {
+		t.Error("CSR with a modified subject passes CheckSignature")
+	}
+}
+
+func TestHashFunc(t *testing.T) {
+	if SM3.HashFunc() != sm3.Hash {
+		t.Errorf("SM3.HashFunc() = %v, want sm3.Hash", SM3.HashFunc())
+	}
+	if SHA256.HashFunc() != crypto.SHA256 || SHA512_256.HashFunc() != crypto.SHA512_256 {
+		t.Error("standard hashes do not map to their crypto.Hash")
+	}
+}